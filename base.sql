@@ -0,0 +1,39 @@
+-- Tables shared by every bot. Their `bot` column holds the bot's name, such as
+-- "pollbot", the name it registers its admin tables with and schedules its jobs
+-- under, not its command. Onboarding and settings rows stored under the
+-- command before can be moved with:
+--   UPDATE onboarding SET bot = CONCAT(bot, 'bot');
+--   UPDATE settings SET bot = CONCAT(bot, 'bot');
+
+CREATE TABLE `conv_archive` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `bot` varchar(64) NOT NULL,
+  `conv_id` varchar(100) NOT NULL,
+  `table_name` varchar(64) NOT NULL,
+  `row_data` mediumtext NOT NULL,
+  `reason` varchar(16) NOT NULL,
+  `ctime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `bot_conv_id` (`bot`, `conv_id`),
+  KEY `bot_ctime` (`bot`, `ctime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `onboarding` (
+  `bot` varchar(64) NOT NULL,
+  `team` varchar(255) NOT NULL,
+  `welcome_msg` text,
+  `welcome_disabled` boolean NOT NULL DEFAULT false,
+  `welcomed_time` datetime DEFAULT NULL,
+  `completed_time` datetime DEFAULT NULL,
+  PRIMARY KEY (`bot`, `team`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `settings` (
+  `bot` varchar(64) NOT NULL,
+  `scope` varchar(16) NOT NULL,
+  `scope_id` varchar(255) NOT NULL,
+  `name` varchar(64) NOT NULL,
+  `value` text NOT NULL,
+  PRIMARY KEY (`bot`, `scope`, `scope_id`, `name`),
+  KEY `scope_id` (`scope_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,476 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// AdminTable describes one of a bot's tables to the admin tool.
+type AdminTable struct {
+	// Name of the table in the database
+	Name string
+	// ConvColumn holds the ID of the conversation a row belongs to, empty for
+	// tables such as oauth which aren't tied to a conversation.
+	ConvColumn string
+	// ConvWhere is an optional SQL condition for which rows hold a
+	// conversation ID in ConvColumn.
+	ConvWhere string
+	// Where is an optional SQL condition for which rows are the bot's, for
+	// the tables in base.sql which every bot shares.
+	Where string
+	// Parent is set for tables whose rows belong to a row of another of the
+	// bot's tables rather than holding a conversation ID, such as the votes
+	// of a poll. They're archived along with the parent's rows, ParentColumn
+	// holding the value of the parent's ParentKey column.
+	Parent       string
+	ParentColumn string
+	ParentKey    string
+	// KeyColumns are the columns rows can be looked up and deleted by.
+	KeyColumns []string
+	// SecretColumns are never printed.
+	SecretColumns []string
+}
+
+func (t AdminTable) hasColumn(column string) bool {
+	if column == t.ConvColumn {
+		return true
+	}
+	for _, key := range t.KeyColumns {
+		if key == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (t AdminTable) isSecret(column string) bool {
+	for _, secret := range t.SecretColumns {
+		if secret == column {
+			return true
+		}
+	}
+	return false
+}
+
+// convCondition returns the condition selecting the bot's rows of
+// conversations.
+func (t AdminTable) convCondition() string {
+	return t.scoped(t.convMatch())
+}
+
+func (t AdminTable) convMatch() string {
+	if t.ConvWhere == "" {
+		return fmt.Sprintf("%s = ?", t.ConvColumn)
+	}
+	return fmt.Sprintf("%s = ? AND (%s)", t.ConvColumn, t.ConvWhere)
+}
+
+// childCondition returns the condition selecting the rows of the child table
+// which belong to the parent's rows of conversations.
+func (t AdminTable) childCondition(parent AdminTable) string {
+	return t.scoped(fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+		t.ParentColumn, t.ParentKey, parent.Name, parent.convCondition()))
+}
+
+// scoped limits cond, which may be empty, to the bot's rows.
+func (t AdminTable) scoped(cond string) string {
+	switch {
+	case t.Where == "":
+		return cond
+	case cond == "":
+		return fmt.Sprintf("(%s)", t.Where)
+	default:
+		return fmt.Sprintf("%s AND (%s)", cond, t.Where)
+	}
+}
+
+var adminRegistry = struct {
+	sync.Mutex
+	tables map[string][]AdminTable
+}{tables: make(map[string][]AdminTable)}
+
+// RegisterAdminTables makes a bot's tables available to the admin tool, bot
+// packages call this from init.
+func RegisterAdminTables(bot string, tables ...AdminTable) {
+	adminRegistry.Lock()
+	defer adminRegistry.Unlock()
+	adminRegistry.tables[bot] = append(adminRegistry.tables[bot], tables...)
+}
+
+func RegisteredAdminBots() (bots []string) {
+	adminRegistry.Lock()
+	defer adminRegistry.Unlock()
+	for bot := range adminRegistry.tables {
+		bots = append(bots, bot)
+	}
+	sort.Strings(bots)
+	return bots
+}
+
+func RegisteredAdminTables(bot string) []AdminTable {
+	adminRegistry.Lock()
+	defer adminRegistry.Unlock()
+	return adminRegistry.tables[bot]
+}
+
+// AdminRow is a row as printed by the admin tool.
+type AdminRow struct {
+	Table   string
+	Columns []string
+	Values  []string
+}
+
+func (r AdminRow) String() string {
+	fields := make([]string, 0, len(r.Columns))
+	for i, column := range r.Columns {
+		fields = append(fields, fmt.Sprintf("%s=%s", column, r.Values[i]))
+	}
+	return fmt.Sprintf("%s: %s", r.Table, strings.Join(fields, " "))
+}
+
+// AdminFilter matches rows where each column equals its value. The column
+// "conv" stands for the table's ConvColumn.
+type AdminFilter map[string]string
+
+// ParseAdminFilter parses column=value arguments.
+func ParseAdminFilter(args []string) (AdminFilter, error) {
+	filter := make(AdminFilter)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid filter %q, must be column=value", arg)
+		}
+		filter[parts[0]] = parts[1]
+	}
+	return filter, nil
+}
+
+// where builds the WHERE clause for the filter, only allowing the table's
+// known columns so they're safe to put in the query.
+func (f AdminFilter) where(table AdminTable) (string, []interface{}, error) {
+	columns := make([]string, 0, len(f))
+	for column := range f {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	var conds []string
+	var args []interface{}
+	for _, column := range columns {
+		value := f[column]
+		if column == "conv" && table.ConvColumn != "" {
+			conds = append(conds, table.convMatch())
+			args = append(args, value)
+			continue
+		}
+		if !table.hasColumn(column) {
+			return "", nil, fmt.Errorf("%s can't be filtered by %q, use one of: %s",
+				table.Name, column, strings.Join(append([]string{"conv"}, table.KeyColumns...), ", "))
+		}
+		conds = append(conds, fmt.Sprintf("%s = ?", column))
+		args = append(args, value)
+	}
+	cond := table.scoped(strings.Join(conds, " AND "))
+	if cond == "" {
+		return "", nil, nil
+	}
+	return "WHERE " + cond, args, nil
+}
+
+// OrphanedConv is a conversation which no longer exists but still has rows.
+type OrphanedConv struct {
+	ConvID chat1.ConvIDStr
+	// Reason is why the conversation is gone, ConvGoneDeleted or
+	// ConvGoneRemoved
+	Reason string
+	// Rows counts the conversation's rows by table
+	Rows map[string]int
+}
+
+// Admin inspects and edits a bot's state out of band.
+type Admin struct {
+	*DebugOutput
+	db     *DB
+	bot    string
+	tables []AdminTable
+}
+
+// NewAdmin manages the state in the tables the bot registered with
+// RegisterAdminTables.
+func NewAdmin(db *DB, bot string) *Admin {
+	return &Admin{
+		DebugOutput: NewDebugOutput("Admin", nil),
+		db:          db,
+		bot:         bot,
+		tables:      RegisteredAdminTables(bot),
+	}
+}
+
+func (a *Admin) Tables() []AdminTable {
+	return a.tables
+}
+
+func (a *Admin) getTable(name string) (AdminTable, error) {
+	var names []string
+	for _, table := range a.tables {
+		if table.Name == name {
+			return table, nil
+		}
+		names = append(names, table.Name)
+	}
+	return AdminTable{}, fmt.Errorf("unknown table %q, have: %s", name, strings.Join(names, ", "))
+}
+
+func (a *Admin) convTables() (tables []AdminTable) {
+	for _, table := range a.tables {
+		if table.ConvColumn != "" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+func (a *Admin) List(tableName string, filter AdminFilter) (res []AdminRow, err error) {
+	table, err := a.getTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return a.list(table, filter)
+}
+
+func (a *Admin) list(table AdminTable, filter AdminFilter) (res []AdminRow, err error) {
+	where, args, err := filter.where(table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := a.db.Query(fmt.Sprintf("SELECT * FROM %s %s", table.Name, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dests := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, err
+		}
+		row := AdminRow{Table: table.Name, Columns: columns}
+		for i, value := range values {
+			switch {
+			case table.isSecret(columns[i]):
+				row.Values = append(row.Values, "<redacted>")
+			case !value.Valid:
+				row.Values = append(row.Values, "NULL")
+			default:
+				row.Values = append(row.Values, value.String)
+			}
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// Inspect returns every row belonging to the conversation.
+func (a *Admin) Inspect(convID chat1.ConvIDStr) (res []AdminRow, err error) {
+	for _, table := range a.convTables() {
+		rows, err := a.list(table, AdminFilter{"conv": string(convID)})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", table.Name, err)
+		}
+		res = append(res, rows...)
+	}
+	return res, nil
+}
+
+// Move reassigns a conversation's rows to another conversation, in one table
+// or in all of them if tableName is empty. It returns the number of rows
+// moved by table.
+func (a *Admin) Move(tableName string, from, to chat1.ConvIDStr) (moved map[string]int64, err error) {
+	tables := a.convTables()
+	if tableName != "" {
+		table, err := a.getTable(tableName)
+		if err != nil {
+			return nil, err
+		}
+		if table.ConvColumn == "" {
+			return nil, fmt.Errorf("%s isn't tied to a conversation", table.Name)
+		}
+		tables = []AdminTable{table}
+	}
+	moved = make(map[string]int64)
+	err = a.db.RunTxn(func(tx *sql.Tx) error {
+		for _, table := range tables {
+			res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s",
+				table.Name, table.ConvColumn, table.convCondition()), to, from)
+			if err != nil {
+				return fmt.Errorf("%s: %s", table.Name, err)
+			}
+			if moved[table.Name], err = res.RowsAffected(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// Delete removes the table's rows matching the filter, which must not be
+// empty.
+func (a *Admin) Delete(tableName string, filter AdminFilter) (deleted int64, err error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("refusing to delete every row, specify column=value filters")
+	}
+	table, err := a.getTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+	where, args, err := filter.where(table)
+	if err != nil {
+		return 0, err
+	}
+	err = a.db.RunTxn(func(tx *sql.Tx) error {
+		res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s %s", table.Name, where), args...)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
+
+func (a *Admin) convIDs() (convs map[chat1.ConvIDStr]map[string]int, err error) {
+	convs = make(map[chat1.ConvIDStr]map[string]int)
+	for _, table := range a.convTables() {
+		query := fmt.Sprintf("SELECT %s, COUNT(*) FROM %s", table.ConvColumn, table.Name)
+		var cond string
+		if table.ConvWhere != "" {
+			cond = fmt.Sprintf("(%s)", table.ConvWhere)
+		}
+		if cond = table.scoped(cond); cond != "" {
+			query += fmt.Sprintf(" WHERE %s", cond)
+		}
+		rows, err := a.db.Query(query + fmt.Sprintf(" GROUP BY %s", table.ConvColumn))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", table.Name, err)
+		}
+		for rows.Next() {
+			var convID chat1.ConvIDStr
+			var count int
+			if err := rows.Scan(&convID, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if convs[convID] == nil {
+				convs[convID] = make(map[string]int)
+			}
+			convs[convID][table.Name] = count
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return convs, nil
+}
+
+// ConvRows counts the rows of every conversation by table.
+func (a *Admin) ConvRows() (map[chat1.ConvIDStr]map[string]int, error) {
+	return a.convIDs()
+}
+
+// TableRows counts the rows of the tables which aren't tied to a
+// conversation, such as the OAuth identities in oauth.
+func (a *Admin) TableRows() (counts map[string]int, err error) {
+	counts = make(map[string]int)
+	for _, table := range a.tables {
+		if table.ConvColumn != "" {
+			continue
+		}
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s", table.Name)
+		if cond := table.scoped(""); cond != "" {
+			query += fmt.Sprintf(" WHERE %s", cond)
+		}
+		var count int
+		if err := a.db.QueryRow(query).Scan(&count); err != nil {
+			return nil, fmt.Errorf("%s: %s", table.Name, err)
+		}
+		counts[table.Name] = count
+	}
+	return counts, nil
+}
+
+// Orphans finds conversations with rows which the chat service says no
+// longer exist, or which the bot is no longer a member of.
+func (a *Admin) Orphans(kbc *kbchat.API) (res []OrphanedConv, err error) {
+	convs, err := a.convIDs()
+	if err != nil {
+		return nil, err
+	}
+	for convID, counts := range convs {
+		reason := ConvGoneDeleted
+		if conv, err := kbc.GetConversation(convID); err == nil {
+			if !IsConvGoneStatus(conv.MemberStatus) {
+				continue
+			}
+			reason = ConvGoneRemoved
+		} else if !IsDeletedConvError(err) {
+			return nil, fmt.Errorf("unable to look up %s: %s", convID, err)
+		}
+		res = append(res, OrphanedConv{ConvID: convID, Reason: reason, Rows: counts})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ConvID < res[j].ConvID })
+	return res, nil
+}
+
+// ArchiveConv moves every row belonging to the conversation to conv_archive,
+// as the bot does itself when it finds a conversation is gone.
+func (a *Admin) ArchiveConv(convID chat1.ConvIDStr) (archived int, err error) {
+	err = a.db.RunTxn(func(tx *sql.Tx) error {
+		archived, err = archiveConv(tx, a.bot, a.tables, convID, ConvGoneDeleted)
+		return err
+	})
+	return archived, err
+}
+
+// RestoreConv puts the conversation's archived rows back, if they haven't
+// been purged yet.
+func (a *Admin) RestoreConv(convID chat1.ConvIDStr) (restored int, err error) {
+	err = a.db.RunTxn(func(tx *sql.Tx) error {
+		restored, err = restoreConv(tx, a.bot, a.tables, convID)
+		return err
+	})
+	return restored, err
+}
+
+// OAuthAdminTables describes the tables used by OAuthDB.
+func OAuthAdminTables() []AdminTable {
+	return []AdminTable{
+		{
+			Name:          "oauth",
+			KeyColumns:    []string{"identifier"},
+			SecretColumns: []string{"access_token", "refresh_token"},
+		},
+		{
+			Name:       "oauth_state",
+			ConvColumn: "conv_id",
+			KeyColumns: []string{"state", "identifier"},
+		},
+	}
+}
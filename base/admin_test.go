@@ -0,0 +1,33 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminFilterWhere(t *testing.T) {
+	table := AdminTable{
+		Name:       "macro",
+		ConvColumn: "channel_name",
+		ConvWhere:  "is_conv = 1",
+		KeyColumns: []string{"channel_name", "macro_name"},
+	}
+	filter, err := ParseAdminFilter([]string{"macro_name=deploy", "conv=abc"})
+	require.NoError(t, err)
+	where, args, err := filter.where(table)
+	require.NoError(t, err)
+	require.Equal(t, "WHERE channel_name = ? AND (is_conv = 1) AND macro_name = ?", where)
+	require.Equal(t, []interface{}{"abc", "deploy"}, args)
+
+	where, args, err = AdminFilter{}.where(table)
+	require.NoError(t, err)
+	require.Empty(t, where)
+	require.Empty(t, args)
+
+	// only known columns can end up in the query
+	_, _, err = AdminFilter{"1=1 OR macro_message": "x"}.where(table)
+	require.Error(t, err)
+	_, err = ParseAdminFilter([]string{"macro_name"})
+	require.Error(t, err)
+}
@@ -0,0 +1,364 @@
+package base
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// DefaultConvArchiveRetention is how long the state of a conversation the bot
+// can no longer reach is kept in conv_archive before being purged.
+const DefaultConvArchiveRetention = 30 * 24 * time.Hour
+
+// convSweepInterval is how often conversations the bot has state for are
+// checked, for the ones which went away without the bot noticing.
+const convSweepInterval = 24 * time.Hour
+
+const (
+	ConvGoneDeleted = "deleted"
+	ConvGoneLeft    = "left"
+	ConvGoneRemoved = "removed"
+)
+
+// IsConvGoneStatus reports whether the bot's member status in a conversation
+// means it can no longer reach it.
+func IsConvGoneStatus(memberStatus string) bool {
+	switch strings.ToLower(memberStatus) {
+	case "removed", "left":
+		return true
+	}
+	return false
+}
+
+// ConvCleaner removes the state of conversations which have been deleted or
+// which the bot has been removed from, using the tables the bot registered
+// with RegisterAdminTables. Rows are moved to the conv_archive table first,
+// so they can be restored with botadmin until they're purged. Bots sharing a
+// database each only see their own archived rows.
+type ConvCleaner struct {
+	sync.Mutex
+	*DebugOutput
+	stats     *StatsRegistry
+	db        *DB
+	bot       string
+	tables    []AdminTable
+	retention time.Duration
+
+	// set by the Server and the bot, see SetConvCleaner and SetScheduler
+	kbc       *kbchat.API
+	isLeader  func() bool
+	scheduler *Scheduler
+
+	inFlight   map[chat1.ConvIDStr]bool
+	shutdownCh chan struct{}
+	shutdownMu sync.Once
+}
+
+func NewConvCleaner(stats *StatsRegistry, debugConfig *ChatDebugOutputConfig, db *DB, bot string) *ConvCleaner {
+	var tables []AdminTable
+	for _, table := range RegisteredAdminTables(bot) {
+		if table.ConvColumn != "" || table.Parent != "" {
+			tables = append(tables, table)
+		}
+	}
+	return &ConvCleaner{
+		DebugOutput: NewDebugOutput("ConvCleaner", debugConfig),
+		stats:       stats.SetPrefix("ConvCleaner"),
+		db:          db,
+		bot:         bot,
+		tables:      tables,
+		retention:   DefaultConvArchiveRetention,
+		inFlight:    make(map[chat1.ConvIDStr]bool),
+		shutdownCh:  make(chan struct{}),
+	}
+}
+
+// SetScheduler has the cleaner cancel the conversation's jobs, the ones with
+// ids from ConvJobID.
+func (c *ConvCleaner) SetScheduler(scheduler *Scheduler) {
+	c.Lock()
+	defer c.Unlock()
+	c.scheduler = scheduler
+}
+
+func (c *ConvCleaner) setServer(kbc *kbchat.API, isLeader func() bool) {
+	c.Lock()
+	defer c.Unlock()
+	c.kbc = kbc
+	c.isLeader = isLeader
+}
+
+// ConvGone cleans up after the conversation in the background. It's safe to
+// call on a nil ConvCleaner, for bots which have no state to clean up.
+func (c *ConvCleaner) ConvGone(convID chat1.ConvIDStr, reason string) {
+	if c == nil || convID == "" {
+		return
+	}
+	c.Lock()
+	if c.inFlight[convID] {
+		c.Unlock()
+		return
+	}
+	c.inFlight[convID] = true
+	c.Unlock()
+	GoWithRecover(c.DebugOutput, func() {
+		defer func() {
+			c.Lock()
+			delete(c.inFlight, convID)
+			c.Unlock()
+		}()
+		if _, err := c.Cleanup(convID, reason); err != nil {
+			c.Errorf("ConvGone: unable to clean up %s: %v", convID, err)
+		}
+	})
+}
+
+// Cleanup archives and deletes the conversation's rows, returning how many
+// there were, and cancels its jobs.
+func (c *ConvCleaner) Cleanup(convID chat1.ConvIDStr, reason string) (archived int, err error) {
+	defer c.Trace(&err, "Cleanup: %s (%s)", convID, reason)()
+	err = c.db.RunTxn(func(tx *sql.Tx) error {
+		archived, err = archiveConv(tx, c.bot, c.tables, convID, reason)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	c.Lock()
+	scheduler := c.scheduler
+	c.Unlock()
+	if scheduler != nil {
+		if err := scheduler.CancelByPrefix(convJobPrefix(c.bot, convID)); err != nil {
+			return 0, fmt.Errorf("unable to cancel jobs: %s", err)
+		}
+	}
+	if archived > 0 {
+		c.stats.Count("Cleanup - " + reason)
+		c.stats.CountMult("Cleanup - rows", archived)
+	}
+	return archived, nil
+}
+
+// Purge deletes archived rows older than the retention period.
+func (c *ConvCleaner) Purge() (err error) {
+	defer c.Trace(&err, "Purge")()
+	return c.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM conv_archive
+			WHERE bot = ? AND ctime < FROM_UNIXTIME(?)`, c.bot, time.Now().Add(-c.retention).Unix())
+		return err
+	})
+}
+
+// Sweep cleans up after the conversations the bot has state for which were
+// deleted, or which the bot was removed from, without it seeing a message
+// about it.
+func (c *ConvCleaner) Sweep() (err error) {
+	defer c.Trace(&err, "Sweep")()
+	c.Lock()
+	kbc, isLeader := c.kbc, c.isLeader
+	c.Unlock()
+	if kbc == nil || (isLeader != nil && !isLeader()) {
+		return nil
+	}
+	orphans, err := NewAdmin(c.db, c.bot).Orphans(kbc)
+	if err != nil {
+		return err
+	}
+	for _, orphan := range orphans {
+		if _, err := c.Cleanup(orphan.ConvID, orphan.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run purges the archive every hour, and sweeps conversations every day,
+// until shutdown.
+func (c *ConvCleaner) Run() error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	lastSweep := time.Now()
+	for {
+		if err := c.Purge(); err != nil {
+			c.Errorf("Run: unable to purge archive: %v", err)
+		}
+		if time.Since(lastSweep) >= convSweepInterval {
+			lastSweep = time.Now()
+			if err := c.Sweep(); err != nil {
+				c.Errorf("Run: unable to sweep conversations: %v", err)
+			}
+		}
+		select {
+		case <-c.shutdownCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *ConvCleaner) Shutdown() error {
+	c.shutdownMu.Do(func() { close(c.shutdownCh) })
+	return nil
+}
+
+type archivedRow map[string]*string
+
+// convArchiveStep is a table to archive rows of and the condition selecting
+// them, taking the conversation ID.
+type convArchiveStep struct {
+	table AdminTable
+	cond  string
+}
+
+// convArchiveSteps orders the tables so the rows of child tables are archived
+// before their parent's rows, which they're found through, are deleted.
+// Tables neither tied to a conversation nor to a parent are skipped.
+func convArchiveSteps(tables []AdminTable) (steps []convArchiveStep) {
+	for _, table := range tables {
+		if table.ConvColumn == "" {
+			continue
+		}
+		for _, child := range tables {
+			if child.Parent == table.Name {
+				steps = append(steps, convArchiveStep{table: child, cond: child.childCondition(table)})
+			}
+		}
+		steps = append(steps, convArchiveStep{table: table, cond: table.convCondition()})
+	}
+	return steps
+}
+
+// archiveConv copies the bot's rows of the conversation into conv_archive and
+// deletes them.
+func archiveConv(tx *sql.Tx, bot string, tables []AdminTable, convID chat1.ConvIDStr, reason string) (archived int, err error) {
+	for _, step := range convArchiveSteps(tables) {
+		table := step.table
+		rows, err := selectArchiveRows(tx, table, step.cond, convID)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %s", table.Name, err)
+		}
+		for _, row := range rows {
+			data, err := json.Marshal(row)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := tx.Exec(`INSERT INTO conv_archive
+				(bot, conv_id, table_name, row_data, reason, ctime)
+				VALUES (?, ?, ?, ?, ?, NOW())`, bot, convID, table.Name, data, reason); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s",
+			table.Name, step.cond), convID); err != nil {
+			return 0, fmt.Errorf("%s: %s", table.Name, err)
+		}
+		archived += len(rows)
+	}
+	return archived, nil
+}
+
+func selectArchiveRows(tx *sql.Tx, table AdminTable, cond string, convID chat1.ConvIDStr) (res []archivedRow, err error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s WHERE %s FOR UPDATE",
+		table.Name, cond), convID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dests := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, err
+		}
+		row := make(archivedRow)
+		for i, value := range values {
+			if value.Valid {
+				s := value.String
+				row[columns[i]] = &s
+			} else {
+				row[columns[i]] = nil
+			}
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// restoreConv puts the bot's archived rows of the conversation back into the
+// tables.
+func restoreConv(tx *sql.Tx, bot string, tables []AdminTable, convID chat1.ConvIDStr) (restored int, err error) {
+	known := make(map[string]bool)
+	for _, table := range tables {
+		known[table.Name] = true
+	}
+	rows, err := tx.Query(`SELECT id, table_name, row_data
+		FROM conv_archive
+		WHERE bot = ? AND conv_id = ?
+		ORDER BY id`, bot, convID)
+	if err != nil {
+		return 0, err
+	}
+	type archived struct {
+		id    int64
+		table string
+		row   archivedRow
+	}
+	var toRestore []archived
+	for rows.Next() {
+		var a archived
+		var data []byte
+		if err := rows.Scan(&a.id, &a.table, &data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(data, &a.row); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("invalid archived row %d: %s", a.id, err)
+		}
+		toRestore = append(toRestore, a)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, a := range toRestore {
+		if !known[a.table] {
+			return 0, fmt.Errorf("archived row %d is for unknown table %q", a.id, a.table)
+		}
+		var columns, placeholders []string
+		for column := range a.row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		args := make([]interface{}, 0, len(columns))
+		for i, column := range columns {
+			columns[i] = fmt.Sprintf("`%s`", strings.ReplaceAll(column, "`", ""))
+			placeholders = append(placeholders, "?")
+			args = append(args, a.row[column])
+		}
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", a.table,
+			strings.Join(columns, ", "), strings.Join(placeholders, ", ")), args...); err != nil {
+			return 0, fmt.Errorf("%s: %s", a.table, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM conv_archive WHERE id = ?`, a.id); err != nil {
+			return 0, err
+		}
+	}
+	return len(toRestore), nil
+}
@@ -0,0 +1,359 @@
+package base
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// dashboardLoginTTL is how long a login link and the session it starts last.
+const dashboardLoginTTL = 12 * time.Hour
+
+// maxDashboardConvs bounds the conversations listed on the dashboard.
+const maxDashboardConvs = 200
+
+type DashboardOptions struct {
+	// URL is the public URL of the bot's HTTP server, login links point at it
+	URL string
+	// Secret signs login tokens. It must be shared by every instance of the
+	// bot, if empty a random one is used and logins only work on this
+	// instance until it restarts.
+	Secret string
+}
+
+func (o *DashboardOptions) IsEmpty() bool {
+	return o == nil || o.URL == ""
+}
+
+// Dashboard is an admin area served at /<bot>/admin by the bot's HTTPSrv. Bot
+// admins get a login link by sending the bot `!dashboard`.
+type Dashboard struct {
+	*DebugOutput
+	server *Server
+	stats  *StatsRegistry
+	admin  *Admin
+	url    string
+	secret []byte
+}
+
+// NewDashboard registers the dashboard's routes, it returns nil if opts
+// doesn't enable it. db may be nil for bots without a database.
+func NewDashboard(s *Server, stats *StatsRegistry, debugConfig *ChatDebugOutputConfig, db *DB,
+	opts *DashboardOptions) *Dashboard {
+	if opts.IsEmpty() {
+		return nil
+	}
+	secret := []byte(opts.Secret)
+	if len(secret) == 0 {
+		var err error
+		if secret, err = RandBytes(32); err != nil {
+			panic(err)
+		}
+	}
+	d := &Dashboard{
+		DebugOutput: NewDebugOutput("Dashboard", debugConfig),
+		server:      s,
+		stats:       stats,
+		url:         strings.TrimSuffix(opts.URL, "/"),
+		secret:      secret,
+	}
+	if db != nil {
+		d.admin = NewAdmin(db, s.Name())
+	}
+	http.HandleFunc(d.path(""), d.handleDashboard)
+	http.HandleFunc(d.path("/login"), d.handleLogin)
+	s.setDashboard(d)
+	return d
+}
+
+func (d *Dashboard) path(page string) string {
+	return fmt.Sprintf("/%s/admin%s", d.server.Name(), page)
+}
+
+func (d *Dashboard) cookieName() string {
+	return d.server.Name() + "_admin"
+}
+
+// loginToken signs the username with the time the login expires.
+func (d *Dashboard) loginToken(username string, expires int64) string {
+	mac := hmac.New(sha256.New, d.secret)
+	fmt.Fprintf(mac, "%s:%d", username, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LoginURL returns a link logging the user in to the dashboard.
+func (d *Dashboard) LoginURL(username string) string {
+	expires := time.Now().Add(dashboardLoginTTL).Unix()
+	query := url.Values{}
+	query.Set("username", username)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("token", d.loginToken(username, expires))
+	return fmt.Sprintf("%s%s?%s", d.url, d.path("/login"), query.Encode())
+}
+
+func (d *Dashboard) checkToken(username, expiresStr, token string) bool {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	if !hmac.Equal([]byte(token), []byte(d.loginToken(username, expires))) {
+		return false
+	}
+	// admins can be removed after the link was sent
+	return d.server.isBotAdmin(username)
+}
+
+func (d *Dashboard) handleLogin(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	username, expires, token := query.Get("username"), query.Get("expires"), query.Get("token")
+	if !d.checkToken(username, expires, token) {
+		d.Debug("handleLogin: invalid login for %q", username)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+	http.SetCookie(w, &http.Cookie{
+		Name:     d.cookieName(),
+		Value:    strings.Join([]string{username, expires, token}, ":"),
+		Path:     d.path(""),
+		Expires:  time.Unix(expiresAt, 0),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(d.url, "https://"),
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, d.path(""), http.StatusFound)
+}
+
+func (d *Dashboard) checkLogin(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(d.cookieName())
+	if err != nil {
+		return "", false
+	}
+	toks := strings.Split(cookie.Value, ":")
+	if len(toks) != 3 {
+		d.Debug("checkLogin: malformed cookie")
+		return "", false
+	}
+	if !d.checkToken(toks[0], toks[1], toks[2]) {
+		return "", false
+	}
+	return toks[0], true
+}
+
+// DashboardConv is a conversation holding some of the bot's state.
+type DashboardConv struct {
+	ConvID chat1.ConvIDStr
+	Rows   map[string]int
+	Total  int
+}
+
+type dashboardPage struct {
+	Bot       string
+	Username  string
+	Now       time.Time
+	Leader    LeaderStatus
+	Errors    []RecentError
+	Stats     StatsSnapshot
+	HasDB     bool
+	DBError   string
+	Convs     []DashboardConv
+	MoreConvs int
+	Tables    map[string]int
+}
+
+func (d *Dashboard) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	username, ok := d.checkLogin(r)
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprintf(w, "Send `!dashboard` to @%s to get a login link.\n", d.server.Name())
+		return
+	}
+	page := dashboardPage{
+		Bot:      d.server.Name(),
+		Username: username,
+		Now:      time.Now(),
+		Leader:   d.server.LeaderStatus(),
+		Errors:   d.recentErrors(),
+		Stats:    d.stats.Snapshot(),
+		HasDB:    d.admin != nil,
+	}
+	if d.admin != nil {
+		var err error
+		if page.Convs, page.MoreConvs, err = d.convs(); err == nil {
+			page.Tables, err = d.admin.TableRows()
+		}
+		if err != nil {
+			d.Errorf("handleDashboard: unable to count rows: %s", err)
+			page.DBError = err.Error()
+		}
+	}
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, page); err != nil {
+		d.Errorf("handleDashboard: unable to render: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// recentErrors merges the errors reported by the bot and by its Server.
+func (d *Dashboard) recentErrors() []RecentError {
+	errs := d.Config().RecentErrors()
+	if serverConfig := d.server.Config(); serverConfig != d.Config() {
+		errs = append(errs, serverConfig.RecentErrors()...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Time.After(errs[j].Time) })
+	if len(errs) > maxRecentErrors {
+		errs = errs[:maxRecentErrors]
+	}
+	return errs
+}
+
+func (d *Dashboard) convs() (res []DashboardConv, more int, err error) {
+	rows, err := d.admin.ConvRows()
+	if err != nil {
+		return nil, 0, err
+	}
+	for convID, counts := range rows {
+		conv := DashboardConv{ConvID: convID, Rows: counts}
+		for _, count := range counts {
+			conv.Total += count
+		}
+		res = append(res, conv)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return res[i].ConvID < res[j].ConvID
+	})
+	if len(res) > maxDashboardConvs {
+		more = len(res) - maxDashboardConvs
+		res = res[:maxDashboardConvs]
+	}
+	return res, more, nil
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago": func(now, t time.Time) string {
+		return now.Sub(t).Truncate(time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>{{.Bot}} | admin</title>
+  <style>
+	body {
+	  font-family: 'Lucida Sans', 'Lucida Sans Regular', 'Lucida Grande', 'Lucida Sans Unicode', Geneva,
+		Verdana, sans-serif;
+	  font-size: 14px;
+	  padding: 20px 50px;
+	}
+	table {
+	  border-collapse: collapse;
+	  margin-bottom: 20px;
+	}
+	th, td {
+	  border: 1px solid #ddd;
+	  padding: 4px 8px;
+	  text-align: left;
+	  vertical-align: top;
+	}
+	.mono {
+	  font-family: 'Courier New', Courier, monospace;
+	}
+	.bad {
+	  color: #c00;
+	}
+  </style>
+</head>
+<body>
+  <h1>{{.Bot}}</h1>
+  <p>Logged in as @{{.Username}} at {{.Now.UTC.Format "2006-01-02 15:04:05 MST"}}.</p>
+
+  <h2>Leadership</h2>
+  {{if .Leader.Coordinated}}
+  <table>
+	<tr><th>Instance</th><td class="mono">{{.Leader.ID}}</td></tr>
+	<tr><th>Leader</th><td class="mono">{{if .Leader.LeaderID}}{{.Leader.LeaderID}}{{else}}<span class="bad">none</span>{{end}}</td></tr>
+	<tr><th>This instance leads</th><td>{{.Leader.IsLeader}}</td></tr>
+  </table>
+  {{else}}
+  <p>Not coordinating with other instances, this instance always leads.</p>
+  {{end}}
+
+  <h2>Recent errors</h2>
+  {{if .Errors}}
+  <table>
+	<tr><th>Age</th><th>Source</th><th>Error</th></tr>
+	{{range .Errors}}
+	<tr><td>{{ago $.Now .Time}}</td><td>{{.Source}}</td><td class="mono">{{.Msg}}</td></tr>
+	{{end}}
+  </table>
+  {{else}}
+  <p>No errors since the bot started.</p>
+  {{end}}
+
+  <h2>Stats</h2>
+  {{if or .Stats.Counts .Stats.Values}}
+  <table>
+	<tr><th>Stat</th><th>Total</th></tr>
+	{{range $name, $count := .Stats.Counts}}
+	<tr><td>{{$name}}</td><td>{{$count}}</td></tr>
+	{{end}}
+	{{range $name, $value := .Stats.Values}}
+	<tr><td>{{$name}}</td><td>{{printf "%.2f" $value}} (last value)</td></tr>
+	{{end}}
+  </table>
+  {{else}}
+  <p>No stats posted since the bot started.</p>
+  {{end}}
+
+  {{if .HasDB}}
+  {{if .DBError}}
+  <p class="bad">Unable to read the database: {{.DBError}}</p>
+  {{else}}
+  <h2>Identities and other state</h2>
+  {{if .Tables}}
+  <table>
+	<tr><th>Table</th><th>Rows</th></tr>
+	{{range $table, $count := .Tables}}
+	<tr><td>{{$table}}</td><td>{{$count}}</td></tr>
+	{{end}}
+  </table>
+  {{else}}
+  <p>None.</p>
+  {{end}}
+
+  <h2>Conversations</h2>
+  {{if .Convs}}
+  <table>
+	<tr><th>Conversation</th><th>Rows</th></tr>
+	{{range .Convs}}
+	<tr>
+	  <td class="mono">{{.ConvID}}</td>
+	  <td>{{range $table, $count := .Rows}}{{$table}}: {{$count}}<br>{{end}}</td>
+	</tr>
+	{{end}}
+  </table>
+  {{if .MoreConvs}}<p>and {{.MoreConvs}} more.</p>{{end}}
+  {{else}}
+  <p>No conversations have any state.</p>
+  {{end}}
+  {{end}}
+  {{end}}
+</body>
+</html>
+`))
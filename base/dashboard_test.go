@@ -0,0 +1,68 @@
+package base
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorLog(t *testing.T) {
+	log := newErrorLog(3)
+	require.Empty(t, log.list())
+	for i := 0; i < 5; i++ {
+		log.add(RecentError{Msg: fmt.Sprintf("%d", i)})
+	}
+	var msgs []string
+	for _, e := range log.list() {
+		msgs = append(msgs, e.Msg)
+	}
+	require.Equal(t, []string{"4", "3", "2"}, msgs)
+
+	var nilLog *errorLog
+	nilLog.add(RecentError{Msg: "dropped"})
+	require.Empty(t, nilLog.list())
+}
+
+func TestStatsSnapshot(t *testing.T) {
+	stats := NewStatsRegistryWithBackend(nil, NewDummyStatsBackend(nil))
+	bot := stats.SetPrefix("bot")
+	bot.Count("ping")
+	bot.CountMult("ping", 2)
+	bot.SetPrefix("HTTPSrv").Value("latency", 1.5)
+	stats.SetPrefix("other").Count("ping")
+
+	snapshot := bot.Snapshot()
+	require.Equal(t, map[string]int64{"ping": 3}, snapshot.Counts)
+	require.Equal(t, map[string]float64{"HTTPSrv - latency": 1.5}, snapshot.Values)
+	require.Equal(t, int64(1), stats.Snapshot().Counts["other - ping"])
+}
+
+func TestDashboardLogin(t *testing.T) {
+	d := &Dashboard{
+		server: &Server{name: "testbot", botAdmins: []string{"alice", "bob"}},
+		url:    "https://bots.example.com",
+		secret: []byte("secret"),
+	}
+	login, err := url.Parse(d.LoginURL("alice"))
+	require.NoError(t, err)
+	require.Equal(t, "/testbot/admin/login", login.Path)
+	query := login.Query()
+	require.True(t, d.checkToken("alice", query.Get("expires"), query.Get("token")))
+	require.False(t, d.checkToken("bob", query.Get("expires"), query.Get("token")))
+
+	// extending the expiry invalidates the token
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	require.NoError(t, err)
+	require.False(t, d.checkToken("alice", strconv.FormatInt(expires+1, 10), query.Get("token")))
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	require.False(t, d.checkToken("alice", strconv.FormatInt(expired, 10), d.loginToken("alice", expired)))
+
+	// removed admins can't log in anymore
+	d.server.SetBotAdmins([]string{"bob"})
+	require.False(t, d.checkToken("alice", query.Get("expires"), query.Get("token")))
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -18,8 +19,18 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
+// RunTxn runs fn in a transaction which isn't traced, callers with a context
+// should use RunTxnContext.
 func (d *DB) RunTxn(fn func(tx *sql.Tx) error) error {
-	tx, err := d.Begin()
+	return d.RunTxnContext(context.Background(), fn)
+}
+
+// RunTxnContext is RunTxn with the transaction traced as a child of ctx's
+// span, if it has one.
+func (d *DB) RunTxnContext(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	ctx, end := startChildSpan(ctx, "DB.RunTxn")
+	defer end(&err)
+	tx, err := d.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -59,8 +70,8 @@ func (d *BaseOAuthDB) GetState(state string) (*OAuthRequest, error) {
 	}
 }
 
-func (d *BaseOAuthDB) PutState(state string, oauthState *OAuthRequest) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *BaseOAuthDB) PutState(ctx context.Context, state string, oauthState *OAuthRequest) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`INSERT INTO oauth_state
 		(state, identifier, conv_id, msg_id)
 		VALUES (?, ?, ?, ?)
@@ -74,8 +85,8 @@ func (d *BaseOAuthDB) PutState(state string, oauthState *OAuthRequest) error {
 	return err
 }
 
-func (d *BaseOAuthDB) CompleteState(state string) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *BaseOAuthDB) CompleteState(ctx context.Context, state string) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`UPDATE oauth_state
 		SET is_complete=true
 		WHERE state = ?`, state)
@@ -113,8 +124,8 @@ func (d *OAuthDB) GetToken(identifier string) (*oauth2.Token, error) {
 	}
 }
 
-func (d *OAuthDB) PutToken(identifier string, token *oauth2.Token) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *OAuthDB) PutToken(ctx context.Context, identifier string, token *oauth2.Token) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`INSERT INTO oauth
 		(identifier, access_token, token_type, refresh_token, expiry, ctime, mtime)
 		VALUES (?, ?, ?, ?, ?, NOW(), NOW())
@@ -129,8 +140,8 @@ func (d *OAuthDB) PutToken(identifier string, token *oauth2.Token) error {
 	return err
 }
 
-func (d *OAuthDB) DeleteToken(identifier string) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *OAuthDB) DeleteToken(ctx context.Context, identifier string) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`DELETE FROM oauth
 	WHERE identifier = ?`, identifier)
 		return err
@@ -0,0 +1,221 @@
+package git
+
+import (
+	"path"
+	"strings"
+)
+
+// Features are the kinds of events a subscription notifies about. nil
+// Features allow every kind.
+type Features struct {
+	Issues       bool
+	PullRequests bool
+	Commits      bool
+	Statuses     bool
+	Releases     bool
+	Comments     bool
+	Tags         bool
+	Reviews      bool
+	Deployments  bool
+	// Workflows replaces the checks of CI workflows by one summary per
+	// workflow run. It's a mode rather than a kind of event, nil Features
+	// don't have it.
+	Workflows bool
+}
+
+type feature struct {
+	// name is used in commands, description in messages
+	name        string
+	description string
+	// kind is empty for modes
+	kind EventKind
+}
+
+var features = []feature{
+	{"issues", "issues", EventIssue},
+	{"pulls", "pull requests", EventPullRequest},
+	{"commits", "commits", EventPush},
+	{"statuses", "commit statuses", EventCheck},
+	{"releases", "releases", EventRelease},
+	{"comments", "comments", EventComment},
+	{"tags", "tags", EventTag},
+	{"reviews", "reviews", EventReview},
+	{"deployments", "deployments", EventDeployment},
+	{"workflows", "workflow summaries instead of checks", ""},
+}
+
+// FeatureNames returns the names subscriptions' features are toggled with.
+func FeatureNames() (res []string) {
+	for _, f := range features {
+		res = append(res, f.name)
+	}
+	return res
+}
+
+// IsFeature reports whether name is one of FeatureNames.
+func IsFeature(name string) bool {
+	_, ok := lookupFeature(name)
+	return ok
+}
+
+// IsMode reports whether name is a feature changing how events are sent
+// rather than which.
+func IsMode(name string) bool {
+	f, ok := lookupFeature(name)
+	return ok && f.kind == ""
+}
+
+func lookupFeature(name string) (feature, bool) {
+	for _, f := range features {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return feature{}, false
+}
+
+// AllFeatures returns Features allowing every kind of event, without any
+// mode.
+func AllFeatures() *Features {
+	res := &Features{}
+	for _, feature := range features {
+		if feature.kind != "" {
+			*res.field(feature.name) = true
+		}
+	}
+	return res
+}
+
+func (f *Features) field(name string) *bool {
+	switch name {
+	case "workflows":
+		return &f.Workflows
+	}
+	feature, _ := lookupFeature(name)
+	switch feature.kind {
+	case EventIssue:
+		return &f.Issues
+	case EventPullRequest:
+		return &f.PullRequests
+	case EventPush:
+		return &f.Commits
+	case EventCheck:
+		return &f.Statuses
+	case EventRelease:
+		return &f.Releases
+	case EventComment:
+		return &f.Comments
+	case EventTag:
+		return &f.Tags
+	case EventReview:
+		return &f.Reviews
+	case EventDeployment:
+		return &f.Deployments
+	}
+	return nil
+}
+
+// Allows reports whether events of the kind are wanted.
+func (f *Features) Allows(kind EventKind) bool {
+	if f == nil {
+		return true
+	}
+	for _, feature := range features {
+		if feature.kind == kind {
+			return *f.field(feature.name)
+		}
+	}
+	return false
+}
+
+// Set toggles the feature with the given name, it returns false if there's
+// no such feature.
+func (f *Features) Set(name string, enable bool) bool {
+	if !IsFeature(name) {
+		return false
+	}
+	*f.field(name) = enable
+	return true
+}
+
+func (f *Features) String() string {
+	if f == nil {
+		return "all events"
+	}
+	var res []string
+	kinds := 0
+	for _, feature := range features {
+		if feature.kind != "" {
+			kinds++
+		}
+		if *f.field(feature.name) {
+			res = append(res, feature.description)
+		}
+	}
+	switch {
+	case len(res) == 0:
+		return "no events"
+	case len(res) == kinds && !f.Workflows:
+		return "all events"
+	case len(res) == kinds+1 && f.Workflows:
+		return "all events, workflow summaries instead of checks"
+	}
+	return strings.Join(res, ", ")
+}
+
+// Filter decides which events a conversation's subscription to a repository
+// notifies about.
+type Filter struct {
+	Features *Features
+	// Branches are branch names or patterns such as "release/*", events for
+	// every branch are allowed if there are none.
+	Branches []string
+	Rules    []Rule
+}
+
+// MatchBranch reports whether branch is one of the names or patterns.
+func MatchBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if pattern == branch {
+			return true
+		}
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRepoPattern reports whether the subscription is to the repos matching a
+// pattern such as "keybase/*", rather than to a single repo.
+func IsRepoPattern(repo string) bool {
+	return strings.Contains(repo, "*")
+}
+
+// MatchRepo reports whether the repo is the subscription's, or matches its
+// pattern. Repos are compared case-insensitively.
+func MatchRepo(pattern, repo string) bool {
+	pattern, repo = strings.ToLower(pattern), strings.ToLower(repo)
+	if pattern == repo {
+		return true
+	}
+	matched, err := path.Match(pattern, repo)
+	return err == nil && matched
+}
+
+// Allows reports whether the event should be sent to the subscription.
+func (f Filter) Allows(event *Event) bool {
+	if !f.Features.Allows(event.Kind) {
+		return false
+	}
+	if event.Kind == EventCheck && event.CheckScope != CheckScopeStandalone {
+		perWorkflow := f.Features != nil && f.Features.Workflows
+		if perWorkflow != (event.CheckScope == CheckScopeWorkflow) {
+			return false
+		}
+	}
+	if event.Branch != "" && len(f.Branches) > 0 && !MatchBranch(f.Branches, event.Branch) {
+		return false
+	}
+	return matchRules(f.Rules, event)
+}
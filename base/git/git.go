@@ -1,23 +1,38 @@
 package git
 
 import (
-	"fmt"
 	"strings"
 )
 
 /*
-This package contains formatting methods for common Git-hosting webhook events.
+This package contains a provider-agnostic model of Git-hosting webhook events,
+with the filtering, routing and rendering shared by the bots translating them.
 Currently supports:
 - GitHub
 - GitLab
+- Gitea and Forgejo
+- Bitbucket Cloud and Server
 
 */
 
+// Provider is the Git-hosting service an event comes from.
+type Provider int
+
 const (
-	GITHUB = iota
+	GITHUB Provider = iota
 	GITLAB
+	GITEA
+	BITBUCKET
 )
 
+// RequestName is what the provider calls pull requests.
+func (p Provider) RequestName() string {
+	if p == GITLAB {
+		return "merge request"
+	}
+	return "pull request"
+}
+
 func RefToName(ref string) (branch string) {
 	// refs are always given in the form "refs/heads/{branch name}" or "refs/tags/{tag name}"
 	branch = strings.Split(ref, "refs/")[1]
@@ -28,132 +43,132 @@ func RefToName(ref string) (branch string) {
 	return branch
 }
 
-/*
-Push Events
-
-GitHub: https://developer.github.com/v3/activity/events/types/#pushevent
-GitLab: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#push-events
-
-*/
-
-func FormatPushMsg(username string, repo string, branch string, numCommits int, messages []string, commitURL string) (res string) {
-	res = fmt.Sprintf("%s pushed %d commit", username, numCommits)
-	if numCommits != 1 {
-		res += "s"
-	}
-	res += fmt.Sprintf(" to %s/%s:\n", repo, branch)
-	for _, msg := range messages {
-		res += fmt.Sprintf("- `%s`\n", formatCommitString(msg, 50))
-	}
-
-	urlSplit := strings.Split(commitURL, "://")
-	if len(urlSplit) != 2 {
-		// if the compare URL isn't formatted as expected, just skip it
-		return res
-	}
-	res += fmt.Sprintf("\n%s", urlSplit[1])
-	return res
-}
-
-func formatCommitString(commit string, maxLen int) string {
-	firstLine := strings.Split(commit, "\n")[0]
-	if len(firstLine) > maxLen {
-		firstLine = strings.TrimSpace(firstLine[:maxLen]) + "..."
-	}
-	return firstLine
+// IsTagRef reports whether ref is a "refs/tags/{tag name}" ref.
+func IsTagRef(ref string) bool {
+	return strings.HasPrefix(ref, "refs/tags/")
 }
 
-/*
-Issue Events
+type EventKind string
 
-GitHub: https://developer.github.com/v3/activity/events/types/#issuesevent
-Namespace: "opened", "reopened", "closed"
+const (
+	EventPush        EventKind = "push"
+	EventPullRequest EventKind = "pull_request"
+	EventIssue       EventKind = "issue"
+	// EventCheck is a CI result, a GitHub check run or commit status or a
+	// GitLab pipeline
+	EventCheck   EventKind = "check"
+	EventRelease EventKind = "release"
+	EventComment EventKind = "comment"
+	EventTag     EventKind = "tag"
+	// EventReview is a review requested or submitted on a pull request
+	EventReview     EventKind = "review"
+	EventDeployment EventKind = "deployment"
+)
 
-GitLab: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#issues-events
-Namespace: "open", "reopen", "close"
+// CheckScope tells apart the checks which belong to a CI workflow, so
+// subscriptions can hear about either every check or one summary per
+// workflow run.
+type CheckScope string
 
-*/
+const (
+	// CheckScopeStandalone checks aren't part of a workflow.
+	CheckScopeStandalone CheckScope = ""
+	// CheckScopeJob checks are one of a workflow run's jobs.
+	CheckScopeJob CheckScope = "job"
+	// CheckScopeWorkflow checks summarize a workflow run.
+	CheckScopeWorkflow CheckScope = "workflow"
+)
 
-func FormatIssueMsg(action string, username string, repo string, repoNum int, issueTitle string, issueURL string) (res string) {
-	switch action {
-	case "open", "opened":
-		res = fmt.Sprintf("%s opened issue #%d on %s: “%s”\n", username, repoNum, repo, issueTitle)
-		res += issueURL
-	case "reopen", "reopened":
-		res = fmt.Sprintf("%s reopened issue #%d on %s: “%s”\n", username, repoNum, repo, issueTitle)
-		res += issueURL
-	case "close", "closed":
-		res = fmt.Sprintf("%s closed issue #%d on %s.\n", username, repoNum, repo)
-		res += issueURL
-	}
-	return res
+// Event is a webhook event translated from a provider's payload.
+type Event struct {
+	Kind     EventKind
+	Provider Provider
+	// Action is what happened, such as "opened" or "merged". Either the GitHub
+	// ("opened") or the GitLab ("open") vocabulary works. Reviews use
+	// "review_requested" or the review's state: "approved",
+	// "changes_requested" or "commented".
+	Action string
+	// Repo is the repository subscriptions are for, RepoName is how messages
+	// refer to it.
+	Repo     string
+	RepoName string
+	// Branch is the branch pushed to or checked, empty for events which aren't
+	// about a branch. Subscriptions' branch filters only apply to it.
+	Branch string
+	// Author is the provider username of who the event is about: the pusher,
+	// the issue or pull request author, or whoever the checked pull request
+	// belongs to.
+	Author string
+	// Number and Title of the issue or pull request.
+	Number int
+	Title  string
+	// IsPullRequest is set for checks and comments on pull requests.
+	IsPullRequest bool
+	// TargetBranch is the branch a pull request merges into.
+	TargetBranch string
+	// Reviewer is the provider username, or team name, a review was
+	// requested from.
+	Reviewer string
+	URL      string
+	// Commits are the messages of pushed commits.
+	Commits []string
+	// Commit is the SHA of a commented or checked commit, or the new head of
+	// a push whose Commits the provider doesn't send.
+	Commit string
+	// Tag is a tag or a release's version, Name and Body describe releases,
+	// Body is also the text of comments and reviews.
+	Tag  string
+	Name string
+	Body string
+	// CheckName and CheckState ("success", "failure", "cancelled" or
+	// "pending", which isn't rendered) of a check, or of a deployment.
+	CheckName  string
+	CheckState string
+	CheckScope CheckScope
+	// FailedChecks are the names of the failed jobs of a workflow run.
+	FailedChecks []string
+	// Environment a deployment is for.
+	Environment string
+	// Labels of the issue or pull request, Paths changed by the push or pull
+	// request, and whether the pull request is a Draft, for filter rules.
+	Labels []string
+	Paths  []string
+	Draft  bool
+	// loadPaths gets Paths the first time a path rule needs them.
+	loadPaths func() []string
 }
 
-/*
-Pull Request / Merge Request Event
-
-GitHub: https://developer.github.com/v3/activity/events/types/#pullrequestevent
-Namespace: "opened", "reopened", closed"
-> Note: Action is set to "merged" when `event.GetPullRequest().GetMerged()` is true. "merged" doesn't actually exist
-as an action in GitHub.
-
-
-GitLab: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#merge-request-events
-Namespace: "open", "reopen", "close", "merge"
-
-*/
-
-func FormatPullRequestMsg(provider int, action string, username string, repo string, repoNum int, issueTitle string, prURL string, targetBranch string) (res string) {
-	var requestName string
-	if provider == GITLAB {
-		requestName = "merge"
-	} else {
-		requestName = "pull"
-	}
+// SetPathsLoader defers getting the changed paths, which can take API calls,
+// until a subscription's path rule needs them.
+func (e *Event) SetPathsLoader(load func() []string) {
+	e.loadPaths = load
+}
 
-	switch action {
-	case "open", "opened":
-		res = fmt.Sprintf("%s opened %s request #%d on %s: “%s”\n", username, requestName, repoNum, repo, issueTitle)
-		res += prURL
-	case "reopen", "reopened":
-		res = fmt.Sprintf("%s reopened %s request #%d on %s: “%s”\n", username, requestName, repoNum, repo, issueTitle)
-		res += prURL
-	case "close", "closed":
-		res = fmt.Sprintf("%s closed %s request #%d on %s.\n", username, requestName, repoNum, repo)
-		res += prURL
-	case "merge", "merged":
-		res = fmt.Sprintf("%s merged %s request #%d into %s/%s.\n", username, requestName, repoNum, repo, targetBranch)
-		res += prURL
+// paths returns Paths, loading them first if they were deferred.
+func (e *Event) paths() []string {
+	if e.loadPaths != nil {
+		e.Paths = e.loadPaths()
+		e.loadPaths = nil
 	}
-
-	return res
+	return e.Paths
 }
 
-/*
-Release Events
-
-GitHub: https://developer.github.com/v3/activity/events/types/#release
-Namespace: "published", "created", "edited", "deleted"
-
-GitLab: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#release
-Namespace: "publish", "create", "edit", "delete"
-
-*/
+var actions = map[string]string{
+	"open":    "opened",
+	"reopen":  "reopened",
+	"close":   "closed",
+	"merge":   "merged",
+	"publish": "published",
+	"create":  "created",
+	"edit":    "edited",
+	"update":  "edited",
+	"delete":  "deleted",
+}
 
-func FormatReleaseMsg(action, username, repo, version, name, releaseURL, changes string) (res string) {
-	switch action {
-	case "publish", "published":
-		res = fmt.Sprintf("%s published the release %s (%s) on %s: \n%s\n", username, version, name, repo, changes)
-		res += releaseURL
-	case "create", "created":
-		res = fmt.Sprintf("%s created the release %s (%s) on %s: \n%s\n", username, version, name, repo, changes)
-		res += releaseURL
-	case "edit", "edited":
-		res = fmt.Sprintf("%s edited the release %s (%s) on %s: \n%s\n", username, version, name, repo, changes)
-		res += releaseURL
-	case "delete", "deleted":
-		res = fmt.Sprintf("%s deleted the release %s (%s) on %s: \n%s\n", username, version, name, repo, changes)
-		res += releaseURL
+// NormalizeAction translates provider actions to GitHub's vocabulary.
+func NormalizeAction(action string) string {
+	if normalized, ok := actions[action]; ok {
+		return normalized
 	}
-	return res
+	return action
 }
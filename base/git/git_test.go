@@ -0,0 +1,226 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	mention := func(username string) string {
+		if username == "alice" {
+			return "@alice"
+		}
+		return username
+	}
+	cases := []struct {
+		event  Event
+		output string
+	}{
+		{
+			event: Event{Kind: EventPush, Author: "alice", RepoName: "keybase/client", Branch: "master",
+				Commits: []string{"fix the thing\n\nlonger description"}, URL: "https://github.com/keybase/client/compare/a...b"},
+			output: "@alice pushed 1 commit to keybase/client/master:\n- `fix the thing`\n\ngithub.com/keybase/client/compare/a...b",
+		},
+		{
+			event:  Event{Kind: EventPush, Author: "alice", RepoName: "keybase/client", Branch: "master"},
+			output: "",
+		},
+		{
+			event: Event{Kind: EventIssue, Provider: GITLAB, Action: "open", Author: "bob", RepoName: "keybase/client",
+				Number: 5, Title: "Broken", URL: "https://gitlab.com/keybase/client/issues/5"},
+			output: "bob opened issue #5 on keybase/client: “Broken”\nhttps://gitlab.com/keybase/client/issues/5",
+		},
+		{
+			event: Event{Kind: EventPullRequest, Provider: GITLAB, Action: "merge", Author: "bob", RepoName: "keybase/client",
+				Number: 7, TargetBranch: "main", URL: "https://gitlab.com/keybase/client/merge_requests/7"},
+			output: "bob merged merge request #7 into keybase/client/main.\nhttps://gitlab.com/keybase/client/merge_requests/7",
+		},
+		{
+			event: Event{Kind: EventCheck, Author: "alice", RepoName: "keybase/client", Number: 3, IsPullRequest: true,
+				CheckName: "ci", CheckState: "failure", URL: "https://ci.example.com/3"},
+			output: ":x: *ci* failed for pull request #3 on keybase/client.\nci.example.com/3\n@alice",
+		},
+		{
+			event: Event{Kind: EventCheck, Author: "bob", RepoName: "keybase/client", Number: 3, IsPullRequest: true,
+				CheckState: "success"},
+			output: ":white_check_mark: Tests passed for pull request #3 on keybase/client.\n(for bob)",
+		},
+		{
+			event:  Event{Kind: EventCheck, RepoName: "keybase/client", Branch: "master", CheckState: "pending"},
+			output: "",
+		},
+		{
+			event: Event{Kind: EventComment, Action: "created", Author: "bob", RepoName: "keybase/client", Number: 3,
+				IsPullRequest: true, Title: "Fix", Body: "looks good\nthanks", URL: "https://github.com/keybase/client/pull/3"},
+			output: "bob commented on pull request #3 on keybase/client: “Fix”\n> looks good\nhttps://github.com/keybase/client/pull/3",
+		},
+		{
+			event: Event{Kind: EventComment, Provider: GITLAB, Action: "create", Author: "bob", RepoName: "keybase/client",
+				Commit: "0123456789abcdef", Body: "nice", URL: "https://gitlab.com/c"},
+			output: "bob commented on commit 01234567 on keybase/client:\n> nice\nhttps://gitlab.com/c",
+		},
+		{
+			event:  Event{Kind: EventTag, Action: "deleted", Author: "alice", RepoName: "keybase/client", Tag: "v1.0.0"},
+			output: "@alice deleted the tag v1.0.0 from keybase/client.",
+		},
+	}
+	for _, c := range cases {
+		c := c
+		require.Equal(t, c.output, Render(&c.event, mention))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	push := &Event{Kind: EventPush, Branch: "release/1.0"}
+	issue := &Event{Kind: EventIssue}
+
+	require.True(t, Filter{}.Allows(push))
+	require.True(t, Filter{Branches: []string{"release/*"}}.Allows(push))
+	require.False(t, Filter{Branches: []string{"master"}}.Allows(push))
+	require.True(t, Filter{Branches: []string{"master"}}.Allows(issue))
+
+	features := &Features{}
+	require.Equal(t, "no events", features.String())
+	require.True(t, features.Set("issues", true))
+	require.False(t, features.Set("wiki", true))
+	require.Equal(t, "issues", features.String())
+	require.True(t, Filter{Features: features}.Allows(issue))
+	require.False(t, Filter{Features: features}.Allows(push))
+	require.Equal(t, "all events", (*Features)(nil).String())
+}
+
+func TestRenderReview(t *testing.T) {
+	mention := func(username string) string { return "@" + username }
+	event := Event{Kind: EventReview, Action: "review_requested", Author: "alice", RepoName: "client", Number: 3,
+		Title: "Fix", Reviewer: "bob", URL: "https://github.com/keybase/client/pull/3"}
+	require.Equal(t, "@alice requested a review from @bob on pull request #3 on client: “Fix”\nhttps://github.com/keybase/client/pull/3",
+		Render(&event, mention))
+
+	event.Reviewer = "keybase/design"
+	require.Equal(t, "@alice requested a review from keybase/design on pull request #3 on client: “Fix”\nhttps://github.com/keybase/client/pull/3",
+		Render(&event, mention))
+
+	event = Event{Kind: EventReview, Action: "changes_requested", Author: "bob", RepoName: "client", Number: 3,
+		Title: "Fix", Body: "Needs tests", URL: "https://github.com/keybase/client/pull/3#pullrequestreview-1"}
+	require.Equal(t, "@bob requested changes on pull request #3 on client: “Fix”\n> Needs tests\nhttps://github.com/keybase/client/pull/3#pullrequestreview-1",
+		Render(&event, mention))
+
+	event.Action = "commented"
+	event.Body = ""
+	require.Equal(t, "", Render(&event, mention))
+}
+
+func TestFilterWorkflows(t *testing.T) {
+	job := &Event{Kind: EventCheck, CheckScope: CheckScopeJob}
+	workflow := &Event{Kind: EventCheck, CheckScope: CheckScopeWorkflow}
+	standalone := &Event{Kind: EventCheck}
+
+	require.True(t, Filter{}.Allows(job))
+	require.False(t, Filter{}.Allows(workflow))
+	require.True(t, Filter{}.Allows(standalone))
+
+	features := AllFeatures()
+	require.Equal(t, "all events", features.String())
+	require.True(t, features.Set("workflows", true))
+	require.Equal(t, "all events, workflow summaries instead of checks", features.String())
+	require.False(t, Filter{Features: features}.Allows(job))
+	require.True(t, Filter{Features: features}.Allows(workflow))
+	require.True(t, Filter{Features: features}.Allows(standalone))
+
+	require.True(t, IsMode("workflows"))
+	require.False(t, IsMode("statuses"))
+}
+
+func TestRenderWorkflow(t *testing.T) {
+	event := Event{Kind: EventCheck, RepoName: "client", Branch: "master", CheckName: "CI", CheckState: "failure",
+		CheckScope: CheckScopeWorkflow, FailedChecks: []string{"lint", "test (windows)"},
+		URL: "https://github.com/keybase/client/actions/runs/1"}
+	require.Equal(t, ":x: *CI* failed for client/master.\nFailed: lint, test (windows)\ngithub.com/keybase/client/actions/runs/1",
+		Render(&event, nil))
+
+	event = Event{Kind: EventDeployment, Author: "alice", RepoName: "client", Branch: "master", CheckState: "success",
+		Environment: "production", URL: "https://client.example.com"}
+	require.Equal(t, ":rocket: alice deployed client/master to production.\nhttps://client.example.com", Render(&event, nil))
+}
+
+func TestFilterRules(t *testing.T) {
+	parse := func(exprs ...string) (rules []Rule) {
+		for _, expr := range exprs {
+			rule, err := ParseRule(expr)
+			require.NoError(t, err)
+			rules = append(rules, rule)
+		}
+		return rules
+	}
+	pr := &Event{Kind: EventPullRequest, Author: "alice", Labels: []string{"Backend", "bug"},
+		Paths: []string{"services/api/main.go", "README.md"}}
+	push := &Event{Kind: EventPush, Author: "dependabot", Paths: []string{"web/package.json"}}
+	release := &Event{Kind: EventRelease, Author: "bob"}
+
+	filter := Filter{Rules: parse("label:backend", "label:frontend")}
+	require.True(t, filter.Allows(pr))
+	require.True(t, filter.Allows(push))
+	require.False(t, filter.Allows(&Event{Kind: EventIssue, Labels: []string{"docs"}}))
+
+	filter = Filter{Rules: parse("path:services/**", "-author:dependabot")}
+	require.True(t, filter.Allows(pr))
+	require.False(t, filter.Allows(push))
+	require.True(t, filter.Allows(release))
+	require.True(t, filter.Allows(&Event{Kind: EventIssue, Author: "bob"}))
+
+	filter = Filter{Rules: parse("path:web/*.json", "draft:false")}
+	require.True(t, filter.Allows(push))
+	require.False(t, filter.Allows(pr))
+	require.True(t, Filter{Rules: parse("path:*.md", "draft:false")}.Allows(pr))
+	pr.Draft = true
+	require.False(t, Filter{Rules: parse("path:*.md", "draft:false")}.Allows(pr))
+
+	rule, err := ParseRule("-Path:/services/api/")
+	require.NoError(t, err)
+	require.Equal(t, "-path:services/api", rule.String())
+	_, err = ParseRule("milestone:v1")
+	require.Error(t, err)
+	_, err = ParseRule("draft:maybe")
+	require.Error(t, err)
+	_, err = ParseRule("label")
+	require.Error(t, err)
+}
+
+func TestMatchPath(t *testing.T) {
+	require.True(t, matchPath("services/api", "services/api/main.go"))
+	require.True(t, matchPath("services/**", "services/api/main.go"))
+	require.False(t, matchPath("services/api", "web/services/api/main.go"))
+	require.True(t, matchPath("services/**/*.go", "services/api/v2/main.go"))
+	require.True(t, matchPath("services/**/*.go", "services/main.go"))
+	require.False(t, matchPath("services/**/*.go", "services/api/README.md"))
+	require.True(t, matchPath("**/testdata", "bitbucketbot/bitbucketbot/testdata/push.json"))
+	require.True(t, matchPath("*.go", "base/git/rules.go"))
+	require.True(t, matchPath("docs", "web/docs/index.md"))
+	require.False(t, matchPath("*.go", "base/git/rules.go.orig"))
+	require.False(t, matchPath("web/*.json", "web/src/package.json"))
+
+	pr := &Event{Kind: EventPullRequest}
+	loads := 0
+	pr.SetPathsLoader(func() []string {
+		loads++
+		return []string{"docs/index.md"}
+	})
+	require.True(t, Filter{Rules: []Rule{{Kind: RuleLabel, Value: "docs", Negated: true}}}.Allows(pr))
+	require.Equal(t, 0, loads)
+	require.True(t, Filter{Rules: []Rule{{Kind: RulePath, Value: "*.md"}}}.Allows(pr))
+	require.False(t, Filter{Rules: []Rule{{Kind: RulePath, Value: "*.go"}}}.Allows(pr))
+	require.Equal(t, 1, loads)
+}
+
+func TestMatchRepo(t *testing.T) {
+	require.True(t, MatchRepo("keybase/client", "keybase/client"))
+	require.True(t, MatchRepo("keybase/*", "Keybase/Client"))
+	require.True(t, MatchRepo("keybase/service-*", "keybase/service-chat"))
+	require.False(t, MatchRepo("keybase/service-*", "keybase/client"))
+	require.False(t, MatchRepo("keybase/*", "other/client"))
+	require.False(t, MatchRepo("keybase/client", "keybase/client-go"))
+
+	require.True(t, IsRepoPattern("keybase/*"))
+	require.False(t, IsRepoPattern("keybase/client"))
+}
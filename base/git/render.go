@@ -0,0 +1,277 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Rendering
+
+GitHub:
+- Push: https://developer.github.com/v3/activity/events/types/#pushevent
+- Issues: https://developer.github.com/v3/activity/events/types/#issuesevent
+- Pull requests: https://developer.github.com/v3/activity/events/types/#pullrequestevent
+> Note: Action is set to "merged" when `event.GetPullRequest().GetMerged()` is true. "merged" doesn't actually exist
+as an action in GitHub.
+- Releases: https://developer.github.com/v3/activity/events/types/#releaseevent
+
+GitLab: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html
+
+*/
+
+// Render formats the event as a chat message, or returns an empty string for
+// events which aren't worth one. mention shows provider usernames, they're
+// shown as is if it's nil.
+func Render(event *Event, mention func(username string) string) string {
+	if mention == nil {
+		mention = func(username string) string { return username }
+	}
+	author := mention(event.Author)
+	action := NormalizeAction(event.Action)
+	switch event.Kind {
+	case EventPush:
+		if len(event.Commits) == 0 && event.Commit != "" {
+			return formatPushHeadMsg(author, event.RepoName, event.Branch, event.Commit, event.URL)
+		}
+		return formatPushMsg(author, event.RepoName, event.Branch, event.Commits, event.URL)
+	case EventIssue:
+		return formatIssueMsg(action, author, event.RepoName, event.Number, event.Title, event.URL)
+	case EventPullRequest:
+		return formatPullRequestMsg(event.Provider, action, author, event.RepoName, event.Number, event.Title,
+			event.URL, event.TargetBranch)
+	case EventRelease:
+		return formatReleaseMsg(action, author, event.RepoName, event.Tag, event.Name, event.URL, event.Body)
+	case EventCheck:
+		return formatCheckMsg(event, author)
+	case EventComment:
+		return formatCommentMsg(event, action, author)
+	case EventTag:
+		return formatTagMsg(action, author, event.RepoName, event.Tag, event.URL)
+	case EventReview:
+		return formatReviewMsg(event, action, author, mention)
+	case EventDeployment:
+		return formatDeploymentMsg(event, author)
+	}
+	return ""
+}
+
+// stripScheme removes the protocol from URLs so chat doesn't ask to unfurl
+// them, it returns an empty string if the URL isn't formatted as expected.
+func stripScheme(url string) string {
+	urlSplit := strings.Split(url, "://")
+	if len(urlSplit) != 2 {
+		return ""
+	}
+	return urlSplit[1]
+}
+
+func formatPushMsg(username string, repo string, branch string, messages []string, commitURL string) (res string) {
+	if len(messages) == 0 {
+		return ""
+	}
+	res = fmt.Sprintf("%s pushed %d commit", username, len(messages))
+	if len(messages) != 1 {
+		res += "s"
+	}
+	res += fmt.Sprintf(" to %s/%s:\n", repo, branch)
+	for _, msg := range messages {
+		res += fmt.Sprintf("- `%s`\n", formatCommitString(msg, 50))
+	}
+
+	if url := stripScheme(commitURL); url != "" {
+		res += fmt.Sprintf("\n%s", url)
+	}
+	return res
+}
+
+// formatPushHeadMsg announces a push by the commit the branch now points at.
+func formatPushHeadMsg(username string, repo string, branch string, head string, commitURL string) (res string) {
+	if len(head) > 7 {
+		head = head[:7]
+	}
+	res = fmt.Sprintf("%s pushed to %s/%s, which is now at `%s`.\n", username, repo, branch, head)
+	if url := stripScheme(commitURL); url != "" {
+		res += fmt.Sprintf("\n%s", url)
+	}
+	return res
+}
+
+func formatCommitString(commit string, maxLen int) string {
+	firstLine := strings.Split(commit, "\n")[0]
+	if len(firstLine) > maxLen {
+		firstLine = strings.TrimSpace(firstLine[:maxLen]) + "..."
+	}
+	return firstLine
+}
+
+func formatIssueMsg(action string, username string, repo string, repoNum int, issueTitle string, issueURL string) (res string) {
+	switch action {
+	case "opened":
+		res = fmt.Sprintf("%s opened issue #%d on %s: “%s”\n", username, repoNum, repo, issueTitle)
+		res += issueURL
+	case "reopened":
+		res = fmt.Sprintf("%s reopened issue #%d on %s: “%s”\n", username, repoNum, repo, issueTitle)
+		res += issueURL
+	case "closed":
+		res = fmt.Sprintf("%s closed issue #%d on %s.\n", username, repoNum, repo)
+		res += issueURL
+	}
+	return res
+}
+
+func formatPullRequestMsg(provider Provider, action string, username string, repo string, repoNum int, issueTitle string,
+	prURL string, targetBranch string) (res string) {
+	requestName := provider.RequestName()
+	switch action {
+	case "opened":
+		res = fmt.Sprintf("%s opened %s #%d on %s: “%s”\n", username, requestName, repoNum, repo, issueTitle)
+		res += prURL
+	case "reopened":
+		res = fmt.Sprintf("%s reopened %s #%d on %s: “%s”\n", username, requestName, repoNum, repo, issueTitle)
+		res += prURL
+	case "closed":
+		res = fmt.Sprintf("%s closed %s #%d on %s.\n", username, requestName, repoNum, repo)
+		res += prURL
+	case "merged":
+		res = fmt.Sprintf("%s merged %s #%d into %s/%s.\n", username, requestName, repoNum, repo, targetBranch)
+		res += prURL
+	}
+	return res
+}
+
+func formatReleaseMsg(action, username, repo, version, name, releaseURL, changes string) (res string) {
+	switch action {
+	case "published", "created", "edited", "deleted":
+		res = fmt.Sprintf("%s %s the release %s (%s) on %s: \n%s\n", username, action, version, name, repo, changes)
+		res += releaseURL
+	}
+	return res
+}
+
+func formatCheckMsg(event *Event, author string) (res string) {
+	testName := "Tests"
+	if event.CheckName != "" {
+		testName = fmt.Sprintf("*%s*", event.CheckName)
+	}
+	subject := fmt.Sprintf("%s/%s", event.RepoName, event.Branch)
+	if event.IsPullRequest {
+		subject = fmt.Sprintf("%s #%d on %s", event.Provider.RequestName(), event.Number, event.RepoName)
+	}
+	url := event.URL
+	switch event.CheckState {
+	case "success":
+		res = fmt.Sprintf(":white_check_mark: %s passed for %s.", testName, subject)
+	case "failure":
+		res = fmt.Sprintf(":x: %s failed for %s.", testName, subject)
+		// failures link to the logs, skip the unfurl prompt
+		url = stripScheme(url)
+	case "cancelled":
+		res = fmt.Sprintf(":warning: %s cancelled for %s.", testName, subject)
+	default:
+		return ""
+	}
+	if len(event.FailedChecks) > 0 {
+		res += fmt.Sprintf("\nFailed: %s", strings.Join(event.FailedChecks, ", "))
+	}
+	if url != "" {
+		res += "\n" + url
+	}
+	if event.IsPullRequest && event.Author != "" {
+		if strings.HasPrefix(author, "@") {
+			res += "\n" + author
+		} else {
+			res += fmt.Sprintf("\n(for %s)", author)
+		}
+	}
+	return res
+}
+
+func formatCommentMsg(event *Event, action, author string) (res string) {
+	if action != "created" {
+		return ""
+	}
+	switch {
+	case event.Number != 0:
+		target := "issue"
+		if event.IsPullRequest {
+			target = event.Provider.RequestName()
+		}
+		res = fmt.Sprintf("%s commented on %s #%d on %s: “%s”\n", author, target, event.Number, event.RepoName,
+			event.Title)
+	case event.Commit != "":
+		commit := event.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		res = fmt.Sprintf("%s commented on commit %s on %s:\n", author, commit, event.RepoName)
+	default:
+		return ""
+	}
+	res += fmt.Sprintf("> %s\n", formatCommitString(event.Body, 100))
+	return res + event.URL
+}
+
+func formatTagMsg(action, username, repo, tag, tagURL string) (res string) {
+	switch action {
+	case "created":
+		res = fmt.Sprintf("%s pushed the tag %s to %s.", username, tag, repo)
+		if tagURL != "" {
+			res += "\n" + tagURL
+		}
+	case "deleted":
+		res = fmt.Sprintf("%s deleted the tag %s from %s.", username, tag, repo)
+	}
+	return res
+}
+
+func formatReviewMsg(event *Event, action, author string, mention func(string) string) (res string) {
+	subject := fmt.Sprintf("%s #%d on %s: “%s”", event.Provider.RequestName(), event.Number, event.RepoName, event.Title)
+	switch action {
+	case "review_requested":
+		if event.Reviewer == "" {
+			return ""
+		}
+		reviewer := event.Reviewer
+		if !strings.Contains(reviewer, "/") {
+			// teams can't be mentioned
+			reviewer = mention(reviewer)
+		}
+		res = fmt.Sprintf("%s requested a review from %s on %s\n", author, reviewer, subject)
+	case "approved":
+		res = fmt.Sprintf("%s approved %s\n", author, subject)
+	case "changes_requested":
+		res = fmt.Sprintf("%s requested changes on %s\n", author, subject)
+	case "commented":
+		if event.Body == "" {
+			// the review's comments are announced on their own
+			return ""
+		}
+		res = fmt.Sprintf("%s reviewed %s\n", author, subject)
+	default:
+		return ""
+	}
+	if event.Body != "" {
+		res += fmt.Sprintf("> %s\n", formatCommitString(event.Body, 100))
+	}
+	return res + event.URL
+}
+
+func formatDeploymentMsg(event *Event, author string) (res string) {
+	subject := event.RepoName
+	if event.Branch != "" {
+		subject += "/" + event.Branch
+	}
+	switch event.CheckState {
+	case "success":
+		res = fmt.Sprintf(":rocket: %s deployed %s to %s.", author, subject, event.Environment)
+	case "failure":
+		res = fmt.Sprintf(":x: %s's deployment of %s to %s failed.", author, subject, event.Environment)
+	default:
+		return ""
+	}
+	if event.URL != "" {
+		res += "\n" + event.URL
+	}
+	return res
+}
@@ -0,0 +1,169 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RuleKind is what a filter rule matches events on.
+type RuleKind string
+
+const (
+	RuleLabel  RuleKind = "label"
+	RuleAuthor RuleKind = "author"
+	// RulePath matches the files changed by pushes and pull requests.
+	RulePath  RuleKind = "path"
+	RuleDraft RuleKind = "draft"
+)
+
+var ruleKinds = []RuleKind{RuleLabel, RuleAuthor, RulePath, RuleDraft}
+
+// Rule narrows down the events of a subscription, such as `label:backend`,
+// `-author:dependabot`, `path:services/api` or `draft:false`. Rules only
+// apply to the events they make sense for: an event must match one of the
+// rules of each kind and none of the negated ones.
+type Rule struct {
+	Kind    RuleKind
+	Value   string
+	Negated bool
+}
+
+// ParseRule parses `[-]<kind>:<value>`.
+func ParseRule(expr string) (rule Rule, err error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if strings.HasPrefix(expr, "-") {
+		rule.Negated = true
+		expr = expr[1:]
+	}
+	toks := strings.SplitN(expr, ":", 2)
+	if len(toks) != 2 || toks[1] == "" {
+		return rule, fmt.Errorf("expected `<kind>:<value>`, such as `label:backend`")
+	}
+	rule.Kind, rule.Value = RuleKind(toks[0]), toks[1]
+	switch rule.Kind {
+	case RuleLabel, RuleAuthor:
+	case RulePath:
+		rule.Value = strings.Trim(rule.Value, "/")
+		if _, err := path.Match(rule.Value, ""); err != nil {
+			return rule, fmt.Errorf("invalid path pattern `%s`", rule.Value)
+		}
+	case RuleDraft:
+		if rule.Value != "true" && rule.Value != "false" {
+			return rule, fmt.Errorf("draft must be `true` or `false`")
+		}
+	default:
+		var kinds []string
+		for _, kind := range ruleKinds {
+			kinds = append(kinds, string(kind))
+		}
+		return rule, fmt.Errorf("unknown filter `%s`, expected one of %s", rule.Kind, strings.Join(kinds, ", "))
+	}
+	return rule, nil
+}
+
+func (r Rule) String() string {
+	res := fmt.Sprintf("%s:%s", r.Kind, r.Value)
+	if r.Negated {
+		res = "-" + res
+	}
+	return res
+}
+
+// appliesTo reports whether the event carries what the rule matches on.
+func (r Rule) appliesTo(event *Event) bool {
+	switch r.Kind {
+	case RuleLabel:
+		switch event.Kind {
+		case EventIssue, EventPullRequest, EventReview:
+			return true
+		case EventComment:
+			return event.Number != 0
+		}
+	case RuleAuthor:
+		return event.Author != ""
+	case RulePath:
+		return event.Kind == EventPush || event.Kind == EventPullRequest
+	case RuleDraft:
+		return event.Kind == EventPullRequest || event.Kind == EventReview
+	}
+	return false
+}
+
+func (r Rule) matches(event *Event) bool {
+	switch r.Kind {
+	case RuleLabel:
+		for _, label := range event.Labels {
+			if strings.EqualFold(label, r.Value) {
+				return true
+			}
+		}
+	case RuleAuthor:
+		return strings.EqualFold(event.Author, r.Value)
+	case RulePath:
+		for _, file := range event.paths() {
+			if matchPath(r.Value, strings.ToLower(file)) {
+				return true
+			}
+		}
+	case RuleDraft:
+		return event.Draft == (r.Value == "true")
+	}
+	return false
+}
+
+// matchPath reports whether the file, or one of its parent directories,
+// matches the pattern. `**` matches any number of directories, and a pattern
+// without a slash, such as `*.go` or `docs`, matches a file or directory of
+// that name anywhere.
+func matchPath(pattern, file string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(strings.Trim(file, "/"), "/"))
+}
+
+func matchSegments(pattern, file []string) bool {
+	if len(pattern) == 0 {
+		// the rest is inside a matched directory
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(file); i++ {
+			if matchSegments(pattern[1:], file[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(file) == 0 {
+		return false
+	}
+	if matched, err := path.Match(pattern[0], file[0]); err != nil || !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], file[1:])
+}
+
+// matchRules reports whether the event passes the rules.
+func matchRules(rules []Rule, event *Event) bool {
+	matched := map[RuleKind]bool{}
+	for _, rule := range rules {
+		if !rule.appliesTo(event) {
+			continue
+		}
+		if rule.Negated {
+			if rule.matches(event) {
+				return false
+			}
+			continue
+		}
+		matched[rule.Kind] = matched[rule.Kind] || rule.matches(event)
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,377 @@
+package git
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+)
+
+// Store keeps the filters of subscriptions in the `branches`, `features` and
+// `filter_rules` tables, keyed by conversation and repository.
+type Store struct {
+	db *base.DB
+}
+
+func NewStore(db *base.DB) *Store {
+	return &Store{db: db}
+}
+
+// FilterAdminTables describes the tables used by Store.
+func FilterAdminTables() []base.AdminTable {
+	return []base.AdminTable{
+		{Name: "branches", ConvColumn: "conv_id", KeyColumns: []string{"repo", "branch"}},
+		{Name: "features", ConvColumn: "conv_id", KeyColumns: []string{"repo"}},
+		{Name: "filter_rules", ConvColumn: "conv_id", KeyColumns: []string{"repo", "rule"}},
+	}
+}
+
+func (s *Store) WatchBranch(ctx context.Context, convID chat1.ConvIDStr, repo string, branch string) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT IGNORE INTO branches
+			(conv_id, repo, branch)
+			VALUES
+			(?, ?, ?)
+		`, convID, repo, branch)
+		return err
+	})
+}
+
+func (s *Store) UnwatchBranch(ctx context.Context, convID chat1.ConvIDStr, repo string, branch string) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			DELETE FROM branches
+			WHERE conv_id = ? AND repo = ? AND branch = ?
+		`, convID, repo, branch)
+		return err
+	})
+}
+
+func (s *Store) DeleteBranchesForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			DELETE FROM branches
+			WHERE conv_id = ? AND repo = ?
+		`, convID, repo)
+		return err
+	})
+}
+
+func (s *Store) GetAllBranchesForRepo(convID chat1.ConvIDStr, repo string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT branch
+		FROM branches
+		WHERE conv_id = ? AND repo = ?
+		ORDER BY branch`, convID, repo)
+	if err != nil {
+		return nil, err
+	}
+	res := []string{}
+	defer rows.Close()
+	for rows.Next() {
+		var branch string
+		if err := rows.Scan(&branch); err != nil {
+			return res, err
+		}
+		res = append(res, branch)
+	}
+	return res, rows.Err()
+}
+
+func (s *Store) SetFeatures(ctx context.Context, convID chat1.ConvIDStr, repo string, features *Features) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO features
+			(conv_id, repo, issues, pull_requests, commits, statuses, releases, comments, tags, reviews,
+			deployments, workflows)
+			VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+			issues=VALUES(issues),
+			pull_requests=VALUES(pull_requests),
+			commits=VALUES(commits),
+			statuses=VALUES(statuses),
+			releases=VALUES(releases),
+			comments=VALUES(comments),
+			tags=VALUES(tags),
+			reviews=VALUES(reviews),
+			deployments=VALUES(deployments),
+			workflows=VALUES(workflows)
+		`, convID, repo, features.Issues, features.PullRequests, features.Commits, features.Statuses,
+			features.Releases, features.Comments, features.Tags, features.Reviews, features.Deployments,
+			features.Workflows)
+		return err
+	})
+}
+
+// GetFeatures returns nil if the features were never changed, allowing every
+// kind of event.
+func (s *Store) GetFeatures(convID chat1.ConvIDStr, repo string) (*Features, error) {
+	row := s.db.QueryRow(`SELECT issues, pull_requests, commits, statuses, releases, comments, tags, reviews,
+		deployments, workflows
+		FROM features
+		WHERE conv_id = ? AND repo = ?`, convID, repo)
+	features := &Features{}
+	err := row.Scan(&features.Issues, &features.PullRequests, &features.Commits, &features.Statuses,
+		&features.Releases, &features.Comments, &features.Tags, &features.Reviews, &features.Deployments,
+		&features.Workflows)
+	switch err {
+	case nil:
+		return features, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (s *Store) DeleteFeaturesForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			DELETE FROM features
+			WHERE conv_id = ? AND repo = ?
+		`, convID, repo)
+		return err
+	})
+}
+
+func (s *Store) AddRule(ctx context.Context, convID chat1.ConvIDStr, repo string, rule Rule) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT IGNORE INTO filter_rules
+			(conv_id, repo, rule)
+			VALUES
+			(?, ?, ?)
+		`, convID, repo, rule.String())
+		return err
+	})
+}
+
+// RemoveRule returns false if the subscription didn't have the rule.
+func (s *Store) RemoveRule(ctx context.Context, convID chat1.ConvIDStr, repo string, rule Rule) (removed bool, err error) {
+	err = s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`
+			DELETE FROM filter_rules
+			WHERE conv_id = ? AND repo = ? AND rule = ?
+		`, convID, repo, rule.String())
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		removed = n > 0
+		return err
+	})
+	return removed, err
+}
+
+func (s *Store) DeleteRulesForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			DELETE FROM filter_rules
+			WHERE conv_id = ? AND repo = ?
+		`, convID, repo)
+		return err
+	})
+}
+
+func (s *Store) GetRules(convID chat1.ConvIDStr, repo string) ([]Rule, error) {
+	rows, err := s.db.Query(`SELECT rule
+		FROM filter_rules
+		WHERE conv_id = ? AND repo = ?
+		ORDER BY rule`, convID, repo)
+	if err != nil {
+		return nil, err
+	}
+	var res []Rule
+	defer rows.Close()
+	for rows.Next() {
+		var expr string
+		if err := rows.Scan(&expr); err != nil {
+			return res, err
+		}
+		rule, err := ParseRule(expr)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, rule)
+	}
+	return res, rows.Err()
+}
+
+func (s *Store) GetFilter(convID chat1.ConvIDStr, repo string) (filter Filter, err error) {
+	if filter.Features, err = s.GetFeatures(convID, repo); err != nil {
+		return filter, err
+	}
+	if filter.Branches, err = s.GetAllBranchesForRepo(convID, repo); err != nil {
+		return filter, err
+	}
+	if filter.Rules, err = s.GetRules(convID, repo); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// DeleteFilter removes the subscription's features, branches and rules, for when
+// the conversation unsubscribes.
+func (s *Store) DeleteFilter(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	if err := s.DeleteBranchesForRepo(ctx, convID, repo); err != nil {
+		return fmt.Errorf("error deleting branches: %s", err)
+	}
+	if err := s.DeleteFeaturesForRepo(ctx, convID, repo); err != nil {
+		return fmt.Errorf("error deleting features: %s", err)
+	}
+	if err := s.DeleteRulesForRepo(ctx, convID, repo); err != nil {
+		return fmt.Errorf("error deleting filter rules: %s", err)
+	}
+	return nil
+}
+
+// ToggleFilter enables or disables a feature, or watches or unwatches a
+// branch, for `!<cmd> subscribe|unsubscribe <repo> <feature|branch>`. It
+// returns the reply for the conversation, which must be subscribed to the
+// repo.
+func (s *Store) ToggleFilter(ctx context.Context, convID chat1.ConvIDStr, repo, arg string, enable bool) (reply string, err error) {
+	if !IsFeature(arg) {
+		if enable {
+			if err := s.WatchBranch(ctx, convID, repo, arg); err != nil {
+				return "", fmt.Errorf("error creating branch subscription: %s", err)
+			}
+			return fmt.Sprintf("Now subscribed to notifications for `%s/%s`.", repo, arg), nil
+		}
+		if err := s.UnwatchBranch(ctx, convID, repo, arg); err != nil {
+			return "", fmt.Errorf("error deleting branch subscription: %s", err)
+		}
+		return fmt.Sprintf("Okay, you won't receive notifications for `%s/%s`.", repo, arg), nil
+	}
+
+	features, err := s.GetFeatures(convID, repo)
+	if err != nil {
+		return "", fmt.Errorf("error getting current features: %s", err)
+	}
+	if features == nil {
+		// a subscription starts out with every feature, enabling the first
+		// one turns the rest off, unless it's a mode
+		features = &Features{}
+		if IsMode(arg) {
+			features = AllFeatures()
+		}
+	}
+	features.Set(arg, enable)
+	if err := s.SetFeatures(ctx, convID, repo, features); err != nil {
+		return "", fmt.Errorf("error setting features: %s", err)
+	}
+	if IsMode(arg) {
+		if enable {
+			return fmt.Sprintf("Okay, you'll receive one summary per workflow run on `%s` instead of its checks!", repo), nil
+		}
+		return fmt.Sprintf("Okay, you'll receive the checks of workflow runs on `%s`.", repo), nil
+	}
+	if enable {
+		return fmt.Sprintf("Okay, you'll receive notifications for `%s` on `%s`!", arg, repo), nil
+	}
+	return fmt.Sprintf("Okay, you won't receive notifications for `%s` on `%s`.", arg, repo), nil
+}
+
+// FormatSubscriptions lists the repos with their features, branches and
+// filter rules.
+func (s *Store) FormatSubscriptions(convID chat1.ConvIDStr, repos []string) (res string, err error) {
+	for _, repo := range repos {
+		filter, err := s.GetFilter(convID, repo)
+		if err != nil {
+			return "", err
+		}
+		res += fmt.Sprintf("- *%s* (%s)\n", repo, filter.Features)
+		if filter.Features.Allows(EventPush) || filter.Features.Allows(EventCheck) {
+			for _, branch := range filter.Branches {
+				res += fmt.Sprintf("   - %s\n", branch)
+			}
+		}
+		for _, rule := range filter.Rules {
+			res += fmt.Sprintf("   - `%s`\n", rule)
+		}
+	}
+	return res, nil
+}
+
+// Notifier routes events to the conversations whose subscriptions allow
+// them.
+type Notifier struct {
+	*base.DebugOutput
+	stats *base.StatsRegistry
+	store *Store
+}
+
+func NewNotifier(stats *base.StatsRegistry, debugConfig *base.ChatDebugOutputConfig, store *Store) *Notifier {
+	return &Notifier{
+		DebugOutput: base.NewDebugOutput("Notifier", debugConfig),
+		stats:       stats.SetPrefix("Notifier"),
+		store:       store,
+	}
+}
+
+// Subscription is a conversation's subscription to a repo, or to the repos
+// matching a pattern such as "keybase/*". Filters are stored for Repo.
+type Subscription struct {
+	ConvID chat1.ConvIDStr
+	Repo   string
+}
+
+// Allowed returns the subscribed conversations whose filter allows the
+// event.
+func (n *Notifier) Allowed(event *Event, convIDs []chat1.ConvIDStr) (res []chat1.ConvIDStr) {
+	var subs []Subscription
+	for _, convID := range convIDs {
+		subs = append(subs, Subscription{ConvID: convID, Repo: event.Repo})
+	}
+	for _, sub := range n.AllowedSubscriptions(event, subs) {
+		res = append(res, sub.ConvID)
+	}
+	return res
+}
+
+// AllowedSubscriptions returns the subscriptions whose filter allows the
+// event.
+func (n *Notifier) AllowedSubscriptions(event *Event, subs []Subscription) (res []Subscription) {
+	for _, sub := range subs {
+		filter, err := n.store.GetFilter(sub.ConvID, sub.Repo)
+		if err != nil {
+			n.Errorf("unable to get filter for %s: %s", sub.Repo, err)
+			continue
+		}
+		if !filter.Allows(event) {
+			n.stats.Count("filtered")
+			continue
+		}
+		res = append(res, sub)
+	}
+	return res
+}
+
+// Notify renders the event for each of the subscribed conversations whose
+// filter allows it. mention shows a provider username in a conversation, if
+// nil usernames are shown as is.
+func (n *Notifier) Notify(event *Event, convIDs []chat1.ConvIDStr,
+	mention func(convID chat1.ConvIDStr, username string) string) {
+	n.Deliver(event, n.Allowed(event, convIDs), mention)
+}
+
+// Deliver renders the event for each of the conversations, which must have
+// been checked with Allowed.
+func (n *Notifier) Deliver(event *Event, convIDs []chat1.ConvIDStr,
+	mention func(convID chat1.ConvIDStr, username string) string) {
+	for _, convID := range convIDs {
+		var mentionConv func(string) string
+		if mention != nil {
+			convID := convID
+			mentionConv = func(username string) string { return mention(convID, username) }
+		}
+		message := Render(event, mentionConv)
+		if message == "" {
+			continue
+		}
+		n.stats.Count("success")
+		n.ChatEcho(convID, "%s", message)
+	}
+}
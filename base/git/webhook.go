@@ -0,0 +1,304 @@
+package git
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+)
+
+// WebhookDB is the database of the bots whose repositories are set up by
+// hand to send webhooks signed with a per subscription secret, keeping the
+// subscriptions in the `subscriptions` table besides their filters.
+type WebhookDB struct {
+	*base.DB
+	*Store
+}
+
+func NewWebhookDB(db *sql.DB) *WebhookDB {
+	baseDB := base.NewDB(db)
+	return &WebhookDB{
+		DB:    baseDB,
+		Store: NewStore(baseDB),
+	}
+}
+
+// WebhookAdminTables describes the tables used by WebhookDB.
+func WebhookAdminTables() []base.AdminTable {
+	return append([]base.AdminTable{
+		{Name: "subscriptions", ConvColumn: "conv_id", KeyColumns: []string{"repo", "oauth_identifier"}},
+	}, FilterAdminTables()...)
+}
+
+func (d *WebhookDB) CreateSubscription(ctx context.Context, convID chat1.ConvIDStr, repo string, oauthIdentifier string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO subscriptions
+			(conv_id, repo, oauth_identifier)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+			oauth_identifier=VALUES(oauth_identifier)
+		`, convID, repo, oauthIdentifier)
+		return err
+	})
+}
+
+func (d *WebhookDB) DeleteSubscriptionsForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			DELETE FROM subscriptions
+			WHERE (conv_id = ? AND repo = ?)
+		`, convID, repo)
+		return err
+	})
+}
+
+func (d *WebhookDB) GetSubscribedConvs(repo string) (res []chat1.ConvIDStr, err error) {
+	rows, err := d.DB.Query(`
+		SELECT conv_id
+		FROM subscriptions
+		WHERE repo = ?
+		GROUP BY conv_id
+	`, repo)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var convID chat1.ConvIDStr
+		if err := rows.Scan(&convID); err != nil {
+			return res, err
+		}
+		res = append(res, convID)
+	}
+	return res, nil
+}
+
+func (d *WebhookDB) GetSubscriptionForRepoExists(convID chat1.ConvIDStr, repo string) (exists bool, err error) {
+	row := d.DB.QueryRow(`
+	SELECT 1
+	FROM subscriptions
+	WHERE (conv_id = ? AND repo = ?)
+	`, convID, repo)
+	var rowRes string
+	err = row.Scan(&rowRes)
+	switch err {
+	case sql.ErrNoRows:
+		return false, nil
+	case nil:
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+func (d *WebhookDB) GetAllSubscriptionsForConvID(convID chat1.ConvIDStr) (res []string, err error) {
+	rows, err := d.DB.Query(`
+		SELECT repo
+		FROM subscriptions
+		WHERE conv_id = ?
+		ORDER BY repo
+	`, convID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var repo string
+		if err := rows.Scan(&repo); err != nil {
+			return res, err
+		}
+		res = append(res, repo)
+	}
+	return res, nil
+}
+
+// WebhookProvider is what WebhookHandler needs to know about a provider.
+type WebhookProvider struct {
+	// Name is the bot's name, such as "giteabot", which its rows in the shared
+	// tables of base.sql are stored under.
+	Name string
+	// Cmd is the bot's command, such as "gitea" for `!gitea subscribe`.
+	Cmd string
+	// Welcome is the onboarding message teams get by default.
+	Welcome string
+	// RepoUsage describes the repository argument of the commands.
+	RepoUsage string
+	// ParseRepo parses the repository argument, repo being what
+	// subscriptions and events identify it by.
+	ParseRepo func(input string) (repoURL string, repo string, err error)
+	// SetupInstructions tell the subscriber how to add the webhook to the
+	// repository.
+	SetupInstructions func(repo string, repoURL string, msg chat1.MsgSummary, httpAddress string, secret string) string
+}
+
+// WebhookHandler handles the subscribe, unsubscribe and list commands of the
+// bots using WebhookDB.
+type WebhookHandler struct {
+	*base.DebugOutput
+
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	db         *WebhookDB
+	onboarding *base.Onboarding
+	provider   WebhookProvider
+	httpPrefix string
+	secret     string
+}
+
+var _ base.Handler = (*WebhookHandler)(nil)
+
+func NewWebhookHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
+	db *WebhookDB, provider WebhookProvider, httpPrefix string, secret string) *WebhookHandler {
+	return &WebhookHandler{
+		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
+		stats:       stats.SetPrefix("Handler"),
+		kbc:         kbc,
+		db:          db,
+		provider:    provider,
+		httpPrefix:  httpPrefix,
+		secret:      secret,
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, provider.Name, provider.Cmd, provider.Welcome),
+	}
+}
+
+func (h *WebhookHandler) HandleNewConv(conv chat1.ConvSummary) error {
+	return h.onboarding.HandleNewConv(conv)
+}
+
+func (h *WebhookHandler) HandleAuth(ctx context.Context, msg chat1.MsgSummary, _ string) error {
+	return h.HandleCommand(ctx, msg)
+}
+
+func (h *WebhookHandler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
+	if msg.Content.Text == nil {
+		return nil
+	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
+
+	cmd := strings.ToLower(strings.TrimSpace(msg.Content.Text.Body))
+	prefix := "!" + h.provider.Cmd
+	if !strings.HasPrefix(cmd, prefix) {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(cmd, prefix+" subscribe"):
+		h.stats.Count("subscribe")
+		return h.handleSubscribe(ctx, cmd, msg, true)
+	case strings.HasPrefix(cmd, prefix+" unsubscribe"):
+		h.stats.Count("unsubscribe")
+		return h.handleSubscribe(ctx, cmd, msg, false)
+	case strings.HasPrefix(cmd, prefix+" list"):
+		h.stats.Count("list")
+		return h.handleListSubscriptions(msg)
+	}
+	return nil
+}
+
+func (h *WebhookHandler) handleSubscribe(ctx context.Context, cmd string, msg chat1.MsgSummary, create bool) (err error) {
+	toks, userErr, err := base.SplitTokens(cmd)
+	if err != nil {
+		return err
+	} else if userErr != "" {
+		h.ChatEcho(msg.ConvID, "%s", userErr)
+		return nil
+	}
+
+	args := toks[2:]
+	if len(args) < 1 {
+		h.ChatEcho(msg.ConvID, "Bad arguments for subscribe: %v", args)
+		return nil
+	}
+
+	repoURL, repo, err := h.provider.ParseRepo(args[0])
+	if err != nil {
+		h.ChatEcho(msg.ConvID, "Invalid repo: %q, expected %s", args[0], h.provider.RepoUsage)
+		return nil
+	}
+
+	alreadyExists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
+	if err != nil {
+		return fmt.Errorf("error checking subscription: %s", err)
+	}
+
+	if len(args) == 2 {
+		// toggling a feature or branch
+		if !alreadyExists {
+			if create {
+				h.ChatEcho(msg.ConvID, "You aren't subscribed to updates yet!\nSend this first: `!%s subscribe %s`",
+					h.provider.Cmd, args[0])
+			} else {
+				h.ChatEcho(msg.ConvID, "You aren't subscribed to notifications for `%s`!", repo)
+			}
+			return nil
+		}
+		reply, err := h.db.ToggleFilter(ctx, msg.ConvID, repo, args[1], create)
+		if err != nil {
+			return err
+		}
+		h.ChatEcho(msg.ConvID, "%s", reply)
+		return nil
+	}
+
+	if create {
+		if !alreadyExists {
+			err = h.db.CreateSubscription(ctx, msg.ConvID, repo, base.IdentifierFromMsg(msg))
+			if err != nil {
+				return fmt.Errorf("error creating subscription: %s", err)
+			}
+			_, err = h.kbc.SendMessageByTlfName(msg.Sender.Username, "%s",
+				h.provider.SetupInstructions(repo, repoURL, msg, h.httpPrefix, h.secret))
+			if err != nil {
+				return fmt.Errorf("error sending message: %s", err)
+			}
+			if !base.IsDirectPrivateMessage(h.kbc.GetUsername(), msg.Sender.Username, msg.Channel) {
+				h.ChatEcho(msg.ConvID, "OK! I've sent a message to @%s to authorize me.", msg.Sender.Username)
+			}
+			return nil
+		}
+
+		h.ChatEcho(msg.ConvID, "You're already receiving notifications for `%s` here!", repo)
+		return nil
+	}
+
+	if alreadyExists {
+		err = h.db.DeleteSubscriptionsForRepo(ctx, msg.ConvID, repo)
+		if err != nil {
+			return fmt.Errorf("error deleting subscriptions: %s", err)
+		}
+		if err = h.db.DeleteFilter(ctx, msg.ConvID, repo); err != nil {
+			return err
+		}
+		h.ChatEcho(msg.ConvID, "Okay, you won't receive updates for `%s` here.", repo)
+		return nil
+	}
+
+	h.ChatEcho(msg.ConvID, "You aren't subscribed to updates for `%s`!", repo)
+	return nil
+}
+
+func (h *WebhookHandler) handleListSubscriptions(msg chat1.MsgSummary) (err error) {
+	subscriptions, err := h.db.GetAllSubscriptionsForConvID(msg.ConvID)
+	if err != nil {
+		return fmt.Errorf("error getting current repos: %s", err)
+	}
+
+	if len(subscriptions) == 0 {
+		h.ChatEcho(msg.ConvID, "Not subscribed to any repositories yet.")
+		return nil
+	}
+
+	res, err := h.db.FormatSubscriptions(msg.ConvID, subscriptions)
+	if err != nil {
+		return fmt.Errorf("error getting current features: %s", err)
+	}
+	h.ChatEcho(msg.ConvID, "%s", res)
+	return nil
+}
@@ -0,0 +1,243 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// maxSuggestions is how many "did you mean" suggestions we offer for an
+// unknown command.
+const maxSuggestions = 3
+
+// withHelpCommands adds a "<prefix> help" command for every command prefix
+// in the advertisement, so clients list it along with the bot's own commands.
+func withHelpCommands(advert kbchat.Advertisement) kbchat.Advertisement {
+	res := advert
+	res.Advertisements = nil
+	for _, ad := range advert.Advertisements {
+		cmds := append([]chat1.UserBotCommandInput{}, ad.Commands...)
+		for _, prefix := range commandPrefixes(ad.Commands) {
+			if hasCommand(cmds, prefix+" help") {
+				continue
+			}
+			cmds = append(cmds, chat1.UserBotCommandInput{
+				Name:        prefix + " help",
+				Description: "Show help for my commands",
+				Usage:       "[command]",
+			})
+		}
+		ad.Commands = cmds
+		res.Advertisements = append(res.Advertisements, ad)
+	}
+	return res
+}
+
+func hasCommand(cmds []chat1.UserBotCommandInput, name string) bool {
+	for _, cmd := range cmds {
+		if cmd.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// commandPrefixes returns the distinct first words of the advertised
+// commands, e.g. "github" for "github subscribe".
+func commandPrefixes(cmds []chat1.UserBotCommandInput) (prefixes []string) {
+	seen := make(map[string]bool)
+	for _, cmd := range cmds {
+		fields := strings.Fields(strings.ToLower(cmd.Name))
+		if len(fields) == 0 || seen[fields[0]] {
+			continue
+		}
+		// the feedback command is prefixed with the bot's username rather
+		// than its command prefix
+		if len(fields) == 2 && fields[1] == "feedback" {
+			continue
+		}
+		seen[fields[0]] = true
+		prefixes = append(prefixes, fields[0])
+	}
+	return prefixes
+}
+
+func (s *Server) setAdvertisement(advert kbchat.Advertisement) {
+	s.Lock()
+	defer s.Unlock()
+	var cmds []chat1.UserBotCommandInput
+	for _, ad := range advert.Advertisements {
+		cmds = append(cmds, ad.Commands...)
+	}
+	s.advertisedCmds = cmds
+}
+
+func (s *Server) getAdvertisedCmds() []chat1.UserBotCommandInput {
+	s.Lock()
+	defer s.Unlock()
+	return s.advertisedCmds
+}
+
+// handleHelp answers "!<prefix> help [command]" and suggests commands for
+// anything else starting with one of our command prefixes which we don't
+// advertise. It returns false if the message should go to the bot's handler.
+func (s *Server) handleHelp(msg chat1.MsgSummary, cmd string) bool {
+	cmds := s.getAdvertisedCmds()
+	toks := strings.Fields(strings.ToLower(strings.TrimPrefix(cmd, "!")))
+	if !strings.HasPrefix(cmd, "!") || len(toks) == 0 || len(cmds) == 0 {
+		return false
+	}
+	prefix := toks[0]
+	isPrefix := false
+	for _, p := range commandPrefixes(cmds) {
+		if p == prefix {
+			isPrefix = true
+			break
+		}
+	}
+	if !isPrefix {
+		return false
+	}
+	if len(toks) > 1 && toks[1] == "help" && !hasCommand(cmds, prefix+" help") {
+		s.ChatEcho(msg.ConvID, "%s", formatHelp(cmds, prefix, toks[2:]))
+		return true
+	}
+	if matchCommand(cmds, toks) != nil {
+		return false
+	}
+	s.ChatEcho(msg.ConvID, "%s", formatUnknownCommand(cmds, prefix, toks))
+	return true
+}
+
+// matchCommand returns the advertised command the tokens invoke, preferring
+// the longest match so "github subscribe" wins over "github".
+func matchCommand(cmds []chat1.UserBotCommandInput, toks []string) (match *chat1.UserBotCommandInput) {
+	best := 0
+	for i, cmd := range cmds {
+		name := strings.Fields(strings.ToLower(cmd.Name))
+		if len(name) == 0 || len(name) > len(toks) || len(name) <= best {
+			continue
+		}
+		if strings.Join(toks[:len(name)], " ") == strings.Join(name, " ") {
+			best = len(name)
+			match = &cmds[i]
+		}
+	}
+	return match
+}
+
+func commandSummary(cmd chat1.UserBotCommandInput) string {
+	usage := fmt.Sprintf("*!%s*", cmd.Name)
+	if cmd.ExtendedDescription != nil && cmd.ExtendedDescription.Title != "" {
+		usage = strings.SplitN(strings.TrimSpace(cmd.ExtendedDescription.Title), "\n", 2)[0]
+	} else if cmd.Usage != "" {
+		usage += " " + cmd.Usage
+	}
+	if cmd.Description == "" {
+		return usage
+	}
+	return fmt.Sprintf("%s - %s", usage, cmd.Description)
+}
+
+func formatHelp(cmds []chat1.UserBotCommandInput, prefix string, args []string) string {
+	if len(args) > 0 {
+		toks := append([]string{prefix}, args...)
+		cmd := matchCommand(cmds, toks)
+		if cmd == nil {
+			// also allow the full name, e.g. "!github help github subscribe"
+			cmd = matchCommand(cmds, args)
+		}
+		if cmd == nil {
+			return formatUnknownCommand(cmds, prefix, toks)
+		}
+		ext := cmd.ExtendedDescription
+		if ext == nil || ext.DesktopBody == "" {
+			return commandSummary(*cmd)
+		}
+		title := ext.Title
+		if title == "" {
+			title = fmt.Sprintf("*!%s*", cmd.Name)
+		}
+		return fmt.Sprintf("%s\n\n%s", strings.TrimSpace(title), strings.TrimSpace(ext.DesktopBody))
+	}
+
+	lines := []string{"Here's what I can do:"}
+	for _, cmd := range cmds {
+		if fields := strings.Fields(strings.ToLower(cmd.Name)); len(fields) == 0 || fields[0] != prefix {
+			continue
+		}
+		lines = append(lines, "• "+commandSummary(cmd))
+	}
+	lines = append(lines, fmt.Sprintf("\nSend `!%s help <command>` for more about a command.", prefix))
+	return strings.Join(lines, "\n")
+}
+
+func formatUnknownCommand(cmds []chat1.UserBotCommandInput, prefix string, toks []string) string {
+	input := strings.Join(toks, " ")
+	suggestions := suggestCommands(cmds, toks)
+	if len(suggestions) == 0 {
+		return fmt.Sprintf("I don't know `!%s`. Send `!%s help` to see what I can do.", input, prefix)
+	}
+	for i, suggestion := range suggestions {
+		suggestions[i] = fmt.Sprintf("`!%s`", suggestion)
+	}
+	return fmt.Sprintf("I don't know `!%s`, did you mean %s? Send `!%s help` to see what I can do.",
+		input, strings.Join(suggestions, " or "), prefix)
+}
+
+// suggestCommands returns the names of the advertised commands closest to
+// the tokens, comparing as many words as each command name has.
+func suggestCommands(cmds []chat1.UserBotCommandInput, toks []string) (res []string) {
+	type scored struct {
+		name     string
+		distance int
+	}
+	var candidates []scored
+	for _, cmd := range cmds {
+		name := strings.ToLower(cmd.Name)
+		nameToks := strings.Fields(name)
+		if len(nameToks) == 0 || nameToks[0] != toks[0] || len(nameToks) == 1 {
+			continue
+		}
+		n := len(nameToks)
+		if n > len(toks) {
+			n = len(toks)
+		}
+		input := strings.Join(toks[:n], " ")
+		distance := levenshtein(input, name)
+		// only suggest things that are a plausible typo, or which the input
+		// is the start of
+		if distance <= len(name)/3 || (n < len(nameToks) && strings.HasPrefix(name, input)) {
+			candidates = append(candidates, scored{name: name, distance: distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		res = append(res, candidates[i].name)
+	}
+	return res
+}
+
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,49 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/stretchr/testify/require"
+)
+
+var testCmds = []chat1.UserBotCommandInput{
+	{Name: "github subscribe", Description: "Enable updates from a repo", Usage: "<owner/repo>"},
+	{Name: "github unsubscribe", Description: "Disable updates from a repo", Usage: "<owner/repo>"},
+	{Name: "github list", Description: "List subscriptions"},
+	{Name: "githubbot feedback", Description: "Send feedback"},
+}
+
+func TestCommandPrefixes(t *testing.T) {
+	require.Equal(t, []string{"github"}, commandPrefixes(testCmds))
+}
+
+func TestMatchCommand(t *testing.T) {
+	cmd := matchCommand(testCmds, []string{"github", "subscribe", "keybase/client"})
+	require.NotNil(t, cmd)
+	require.Equal(t, "github subscribe", cmd.Name)
+	require.Nil(t, matchCommand(testCmds, []string{"github", "subscrbe"}))
+}
+
+func TestSuggestCommands(t *testing.T) {
+	require.Equal(t, []string{"github subscribe", "github unsubscribe"},
+		suggestCommands(testCmds, []string{"github", "subscrbe", "keybase/client"}))
+	require.Equal(t, []string{"github list"}, suggestCommands(testCmds, []string{"github", "lsit"}))
+	require.Empty(t, suggestCommands(testCmds, []string{"github", "frobnicate"}))
+}
+
+func TestFormatHelp(t *testing.T) {
+	help := formatHelp(testCmds, "github", nil)
+	require.Contains(t, help, "*!github subscribe* <owner/repo> - Enable updates from a repo")
+	require.NotContains(t, help, "feedback")
+	require.Equal(t, "*!github list* - List subscriptions", formatHelp(testCmds, "github", []string{"list"}))
+}
+
+func TestWithHelpCommands(t *testing.T) {
+	advert := withHelpCommands(kbchat.Advertisement{
+		Advertisements: []chat1.AdvertiseCommandAPIParam{{Typ: "public", Commands: testCmds}},
+	})
+	require.True(t, hasCommand(advert.Advertisements[0].Commands, "github help"))
+	require.False(t, hasCommand(advert.Advertisements[0].Commands, "githubbot help"))
+}
@@ -3,28 +3,63 @@ package base
 import (
 	"context"
 	"net/http"
+	"sync"
 )
 
+// sharedHTTP is set by the Launcher when several bots run in one process. The
+// launcher then serves http.DefaultServeMux, where every bot registers its
+// routes, and each bot's HTTPSrv just waits to be shut down.
+var sharedHTTP struct {
+	sync.Mutex
+	enabled bool
+}
+
+func setSharedHTTP(enabled bool) {
+	sharedHTTP.Lock()
+	defer sharedHTTP.Unlock()
+	sharedHTTP.enabled = enabled
+}
+
+func isSharedHTTP() bool {
+	sharedHTTP.Lock()
+	defer sharedHTTP.Unlock()
+	return sharedHTTP.enabled
+}
+
 type HTTPSrv struct {
 	*DebugOutput
 	srv   *http.Server
 	Stats *StatsRegistry
+
+	shared     bool
+	shutdownCh chan struct{}
+	closeOnce  sync.Once
 }
 
 func NewHTTPSrv(stats *StatsRegistry, debugConfig *ChatDebugOutputConfig) *HTTPSrv {
 	return &HTTPSrv{
 		DebugOutput: NewDebugOutput("HTTPSrv", debugConfig),
 		Stats:       stats.SetPrefix("HTTPSrv"),
-		srv:         &http.Server{Addr: ":8080"},
+		srv:         &http.Server{Addr: ":8080", Handler: traceHandler(http.DefaultServeMux)},
+		shared:      isSharedHTTP(),
+		shutdownCh:  make(chan struct{}),
 	}
 }
 
 func (h *HTTPSrv) Listen() (err error) {
 	defer h.Trace(&err, "ListenAndServe")()
+	if h.shared {
+		<-h.shutdownCh
+		return nil
+	}
 	return h.srv.ListenAndServe()
 }
 
 func (h *HTTPSrv) Shutdown() (err error) {
 	defer h.Trace(&err, "Shutdown")()
+	if h.shared {
+		h.closeOnce.Do(func() { close(h.shutdownCh) })
+		return nil
+	}
 	return h.srv.Shutdown(context.Background())
 }
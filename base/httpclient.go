@@ -0,0 +1,328 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type HTTPClientOptions struct {
+	// Timeout bounds a single attempt, including reading the response body.
+	Timeout time.Duration
+	// MaxRetries is how many times a failed request is retried.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between retries.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After we will wait out, responses
+	// asking for a longer wait are returned to the caller as is.
+	MaxRetryAfter time.Duration
+	// BreakerThreshold consecutive failures to a host open its circuit, failing
+	// requests to it immediately for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func DefaultHTTPClientOptions() HTTPClientOptions {
+	return HTTPClientOptions{
+		Timeout:          30 * time.Second,
+		MaxRetries:       3,
+		MinBackoff:       250 * time.Millisecond,
+		MaxBackoff:       10 * time.Second,
+		MaxRetryAfter:    30 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+type CircuitOpenError struct {
+	Host string
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s, not sending request", e.Host)
+}
+
+// HTTPClient hands out http.Clients which retry failed requests with
+// exponential backoff and stop sending requests to hosts which keep failing.
+// Circuit breaker state is shared between every client handed out, so a bot
+// should create a single HTTPClient and use it for all outbound requests.
+type HTTPClient struct {
+	*DebugOutput
+
+	stats *StatsRegistry
+	opts  HTTPClientOptions
+
+	sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func NewHTTPClient(stats *StatsRegistry, debugConfig *ChatDebugOutputConfig, opts HTTPClientOptions) *HTTPClient {
+	return &HTTPClient{
+		DebugOutput: NewDebugOutput("HTTPClient", debugConfig),
+		stats:       stats.SetPrefix("HTTPClient"),
+		opts:        opts,
+		breakers:    make(map[string]*circuitBreaker),
+	}
+}
+
+// Client returns an http.Client sending requests over http.DefaultTransport.
+func (c *HTTPClient) Client() *http.Client {
+	return c.WithTransport(http.DefaultTransport)
+}
+
+// WithTransport returns an http.Client sending requests over next.
+func (c *HTTPClient) WithTransport(next http.RoundTripper) *http.Client {
+	return &http.Client{Transport: c.Transport(next)}
+}
+
+// Transport wraps next with retries and circuit breaking, for libraries which
+// take a RoundTripper rather than an http.Client. Each attempt is traced as a
+// child of the request context's span.
+func (c *HTTPClient) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{client: c, next: traceTransport(next)}
+}
+
+func (c *HTTPClient) getBreaker(host string) *circuitBreaker {
+	c.Lock()
+	defer c.Unlock()
+	breaker, ok := c.breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{
+			threshold: c.opts.BreakerThreshold,
+			cooldown:  c.opts.BreakerCooldown,
+		}
+		c.breakers[host] = breaker
+	}
+	return breaker
+}
+
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	wait := c.opts.MinBackoff << uint(attempt)
+	if wait <= 0 || wait > c.opts.MaxBackoff {
+		wait = c.opts.MaxBackoff
+	}
+	// jitter so a burst of failed requests doesn't retry in lockstep
+	half := int64(wait / 2)
+	if half <= 0 {
+		return wait
+	}
+	return time.Duration(half + rand.Int63n(half))
+}
+
+// retryWait decides whether a request should be attempted again, and if so
+// how long to wait before doing so.
+func (c *HTTPClient) retryWait(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.opts.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// we can't send the body again
+		return 0, false
+	}
+	if err != nil {
+		if _, ok := err.(CircuitOpenError); ok {
+			return 0, false
+		}
+		return c.backoff(attempt), isIdempotent(req)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// the request was refused rather than processed, so it is safe to
+		// retry regardless of method
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= c.opts.MaxRetryAfter
+		}
+		return c.backoff(attempt), true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return c.backoff(attempt), isIdempotent(req)
+	default:
+		return 0, false
+	}
+}
+
+type retryTransport struct {
+	client *HTTPClient
+	next   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.client
+	host := req.URL.Host
+	breaker := c.getBreaker(host)
+	var lastResp *http.Response
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if !breaker.allow() {
+			c.stats.Count("circuit open")
+			if attempt > 0 {
+				// tell the caller what the host last said rather than that
+				// we stopped asking
+				return lastResp, lastErr
+			}
+			return nil, CircuitOpenError{Host: host}
+		}
+		start := time.Now()
+		resp, err := t.roundTrip(req, attempt)
+		c.stats.Value("request - duration - seconds", time.Since(start).Seconds())
+		switch {
+		case err != nil && req.Context().Err() != nil:
+			// the caller gave up, that says nothing about the host
+			breaker.release()
+		case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+			c.stats.Count("request - failure")
+			if breaker.record(false) {
+				c.Errorf("RoundTrip: opening circuit for %s after %d failures", host, c.opts.BreakerThreshold)
+			}
+		default:
+			c.stats.Count("request - success")
+			breaker.record(true)
+		}
+
+		wait, retry := c.retryWait(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if err != nil {
+			c.Debug("RoundTrip: %s %s attempt #%d failed, retrying in %v: %s",
+				req.Method, host, attempt+1, wait, err)
+		} else {
+			c.Debug("RoundTrip: %s %s attempt #%d got %d, retrying in %v",
+				req.Method, host, attempt+1, resp.StatusCode, wait)
+			// drain so the connection can be reused, keeping the start of the
+			// body in case this ends up being the response we return
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		lastResp, lastErr = resp, err
+		c.stats.Count("retry")
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func (t *retryTransport) roundTrip(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.client.opts.Timeout)
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose keeps the per attempt timeout alive until the caller is done
+// reading the response.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// parseRetryAfter handles both forms of the Retry-After header, a number of
+// seconds or an HTTP date.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// circuitBreaker opens after threshold consecutive failures. Once cooldown
+// has passed a single request is let through, closing the circuit again if it
+// succeeds.
+type circuitBreaker struct {
+	sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.Lock()
+	defer b.Unlock()
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record notes the result of a request, returning true if the failure opened
+// the circuit.
+func (b *circuitBreaker) record(success bool) bool {
+	b.Lock()
+	defer b.Unlock()
+	b.probing = false
+	if success {
+		b.failures = 0
+		return false
+	}
+	b.failures++
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	return b.failures == b.threshold
+}
+
+func (b *circuitBreaker) release() {
+	b.Lock()
+	defer b.Unlock()
+	b.probing = false
+}
@@ -0,0 +1,99 @@
+package base
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPClient() *HTTPClient {
+	stats := NewStatsRegistryWithBackend(nil, NewDummyStatsBackend(nil))
+	opts := DefaultHTTPClientOptions()
+	opts.MinBackoff = time.Millisecond
+	opts.MaxBackoff = 5 * time.Millisecond
+	opts.BreakerThreshold = 2
+	opts.BreakerCooldown = time.Hour
+	return NewHTTPClient(stats, nil, opts)
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	client := newTestHTTPClient().Client()
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("body"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, 2, calls)
+}
+
+func TestHTTPClientNoRetryNonIdempotent(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := newTestHTTPClient().Client()
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("body"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, 1, calls)
+}
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("upstream down"))
+	}))
+	defer srv.Close()
+
+	// the request which opens the circuit gets the last response
+	httpClient := newTestHTTPClient()
+	resp, err := httpClient.Client().Get(srv.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, "upstream down", string(body))
+	require.Equal(t, 2, calls)
+
+	// a new client from the same HTTPClient shares the breaker
+	_, err = httpClient.Client().Get(srv.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "circuit open")
+	require.Equal(t, 2, calls)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("120")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.True(t, wait > 59*time.Minute)
+
+	_, ok = parseRetryAfter("soon")
+	require.False(t, ok)
+}
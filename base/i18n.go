@@ -0,0 +1,149 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a lowercase language tag such as "en", "de" or "pt-br".
+type Locale string
+
+const DefaultLocale Locale = "en"
+
+// ParseLocale normalizes tags such as "de_DE" or "pt-BR", returning
+// DefaultLocale for an empty one.
+func ParseLocale(tag string) Locale {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if tag == "" {
+		return DefaultLocale
+	}
+	return Locale(tag)
+}
+
+// Language returns the locale without its region, "pt" for "pt-br".
+func (l Locale) Language() Locale {
+	if index := strings.Index(string(l), "-"); index >= 0 {
+		return l[:index]
+	}
+	return l
+}
+
+// Catalog holds a bot's user-facing messages by ID, in English and any
+// translations of them. Catalogs are built when the bot starts and are only
+// read afterwards.
+type Catalog struct {
+	messages map[Locale]map[string]string
+}
+
+// NewCatalog creates a catalog whose English messages are used for IDs that
+// aren't translated.
+func NewCatalog(english map[string]string) *Catalog {
+	return &Catalog{
+		messages: map[Locale]map[string]string{DefaultLocale: english},
+	}
+}
+
+// Add registers translations for the locale.
+func (c *Catalog) Add(locale Locale, messages map[string]string) *Catalog {
+	c.messages[ParseLocale(string(locale))] = messages
+	return c
+}
+
+// Locales returns the catalog's locales, English first.
+func (c *Catalog) Locales() (res []Locale) {
+	for locale := range c.messages {
+		if locale != DefaultLocale {
+			res = append(res, locale)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return append([]Locale{DefaultLocale}, res...)
+}
+
+// Match returns the catalog locale closest to locale, falling back from
+// "pt-br" to "pt" and then to English.
+func (c *Catalog) Match(locale Locale) Locale {
+	if _, ok := c.messages[locale]; ok {
+		return locale
+	}
+	if _, ok := c.messages[locale.Language()]; ok {
+		return locale.Language()
+	}
+	return DefaultLocale
+}
+
+// T returns the message in the locale, formatted with args if there are any.
+// Messages missing from a translation are given in English, unknown IDs are
+// returned as is so they stand out.
+func (c *Catalog) T(locale Locale, id string, args ...interface{}) string {
+	msg, ok := c.messages[c.Match(locale)][id]
+	if !ok {
+		if msg, ok = c.messages[DefaultLocale][id]; !ok {
+			msg = id
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+type dateFormat struct {
+	// weekdays and months are abbreviated, weekdays start on Sunday
+	weekdays [7]string
+	months   [12]string
+	// layout and yearLayout use {weekday}, {day}, {month} and {year}
+	layout     string
+	yearLayout string
+}
+
+var dateFormats = map[Locale]dateFormat{
+	"en": {
+		weekdays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		months:     [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		layout:     "{weekday} {month} {day}",
+		yearLayout: "{weekday} {month} {day}, {year}",
+	},
+	"de": {
+		weekdays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		months:     [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		layout:     "{weekday}, {day}. {month}",
+		yearLayout: "{weekday}, {day}. {month} {year}",
+	},
+	"es": {
+		weekdays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		months:     [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		layout:     "{weekday} {day} {month}",
+		yearLayout: "{weekday} {day} {month} {year}",
+	},
+	"fr": {
+		weekdays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months:     [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		layout:     "{weekday} {day} {month}",
+		yearLayout: "{weekday} {day} {month} {year}",
+	},
+}
+
+// FormatDate formats the day of t such as "Wed Jan 1, 2020" in English or
+// "Mi., 1. Jan. 2020" in German. Locales without a format use English.
+func FormatDate(locale Locale, t time.Time, withYear bool) string {
+	format, ok := dateFormats[locale]
+	if !ok {
+		if format, ok = dateFormats[locale.Language()]; !ok {
+			format = dateFormats[DefaultLocale]
+		}
+	}
+	layout := format.layout
+	if withYear {
+		layout = format.yearLayout
+	}
+	return strings.NewReplacer(
+		"{weekday}", format.weekdays[t.Weekday()],
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", format.months[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(layout)
+}
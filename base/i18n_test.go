@@ -0,0 +1,53 @@
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	require.Equal(t, DefaultLocale, ParseLocale(""))
+	require.Equal(t, Locale("pt-br"), ParseLocale(" pt_BR "))
+	require.Equal(t, Locale("pt"), ParseLocale("pt-BR").Language())
+	require.Equal(t, Locale("de"), ParseLocale("de").Language())
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := NewCatalog(map[string]string{
+		"hello": "Hello %s!",
+		"bye":   "Bye",
+	}).Add("de", map[string]string{
+		"hello": "Hallo %s!",
+	})
+	require.Equal(t, []Locale{"en", "de"}, catalog.Locales())
+	require.Equal(t, Locale("de"), catalog.Match("de-at"))
+	require.Equal(t, DefaultLocale, catalog.Match("fr"))
+
+	require.Equal(t, "Hallo alice!", catalog.T("de-at", "hello", "alice"))
+	require.Equal(t, "Hello alice!", catalog.T("fr", "hello", "alice"))
+	// untranslated messages are given in English
+	require.Equal(t, "Bye", catalog.T("de", "bye"))
+	require.Equal(t, "missing", catalog.T("de", "missing"))
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2020, time.March, 4, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, "Wed Mar 4, 2020", FormatDate(DefaultLocale, date, true))
+	require.Equal(t, "Wed Mar 4", FormatDate(DefaultLocale, date, false))
+	require.Equal(t, "Mi., 4. März 2020", FormatDate("de", date, true))
+	require.Equal(t, "mer. 4 mars", FormatDate("fr-ca", date, false))
+	require.Equal(t, "Wed Mar 4, 2020", FormatDate("xx", date, true))
+}
+
+func TestLanguageSetting(t *testing.T) {
+	catalog := NewCatalog(nil).Add("de", nil)
+	def := LanguageSetting(catalog, "de-ch")
+	require.Equal(t, "de", def.Default)
+	value, err := def.parse("DE")
+	require.NoError(t, err)
+	require.Equal(t, "de", value)
+	_, err = def.parse("fr")
+	require.IsType(t, SettingUserError(""), err)
+}
@@ -0,0 +1,192 @@
+package base
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// Bot is a runnable bot, as returned by the constructor a bot package
+// registers with RegisterBot.
+type Bot interface {
+	Name() string
+	Go() error
+}
+
+// BotEnv holds resources shared by bots running in the same process. A nil
+// BotEnv means the bot is running on its own and sets everything up itself.
+type BotEnv struct {
+	// DB is a connection pool shared by all bots, used in place of each bot's
+	// own DSN.
+	DB *sql.DB
+}
+
+// OpenDB returns the shared connection pool if there is one, otherwise it
+// opens dsn. The returned func closes the pool if it was opened here.
+func (e *BotEnv) OpenDB(dsn string) (*sql.DB, func(), error) {
+	if e != nil && e.DB != nil {
+		return e.DB, func() {}, nil
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() { db.Close() }, nil
+}
+
+// HasDB reports whether a shared connection pool is available, in which case
+// bots don't need a DSN of their own.
+func (e *BotEnv) HasDB() bool {
+	return e != nil && e.DB != nil
+}
+
+// BotConstructor parses argv (as os.Args, including the program name) and
+// returns a bot ready to Go.
+type BotConstructor func(argv []string, env *BotEnv) (Bot, error)
+
+var botRegistry = struct {
+	sync.Mutex
+	ctors map[string]BotConstructor
+}{ctors: make(map[string]BotConstructor)}
+
+// RegisterBot makes a bot available to the launcher, bot packages call this
+// from init.
+func RegisterBot(name string, ctor BotConstructor) {
+	botRegistry.Lock()
+	defer botRegistry.Unlock()
+	if _, ok := botRegistry.ctors[name]; ok {
+		panic(fmt.Sprintf("RegisterBot: %s registered twice", name))
+	}
+	botRegistry.ctors[name] = ctor
+}
+
+func RegisteredBots() (names []string) {
+	botRegistry.Lock()
+	defer botRegistry.Unlock()
+	for name := range botRegistry.ctors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func NewRegisteredBot(name string, argv []string, env *BotEnv) (Bot, error) {
+	botRegistry.Lock()
+	ctor, ok := botRegistry.ctors[name]
+	botRegistry.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown bot %q, have: %v", name, RegisteredBots())
+	}
+	return ctor(argv, env)
+}
+
+type LauncherOptions struct {
+	// Bots to run, by registered name.
+	Bots []string
+	// HomeRoot is the parent of each bot's keybase home directory, bot
+	// <name> runs with --home <HomeRoot>/<name>.
+	HomeRoot string
+	// DSN for the shared connection pool, if empty each bot uses its own
+	// --dsn.
+	DSN string
+	// HTTPAddr is where the shared HTTP server listens.
+	HTTPAddr string
+	// Args holds extra command line arguments for each bot, by name.
+	Args map[string][]string
+}
+
+// Launcher runs several registered bots in one process. Each bot gets its own
+// base.Server and keybase service, while the HTTP listener and database pool
+// are shared.
+type Launcher struct {
+	*DebugOutput
+	opts LauncherOptions
+}
+
+func NewLauncher(opts LauncherOptions) *Launcher {
+	return &Launcher{
+		DebugOutput: NewDebugOutput("Launcher", nil),
+		opts:        opts,
+	}
+}
+
+func (l *Launcher) botArgs(name string) []string {
+	argv := []string{name}
+	if l.opts.HomeRoot != "" {
+		argv = append(argv, "--home", filepath.Join(l.opts.HomeRoot, name))
+	}
+	// later flags win, so explicit args can override the defaults above
+	return append(argv, l.opts.Args[name]...)
+}
+
+func (l *Launcher) Run() (err error) {
+	defer l.Trace(&err, "Run")()
+	if len(l.opts.Bots) == 0 {
+		return fmt.Errorf("no bots to run, have: %v", RegisteredBots())
+	}
+
+	env := &BotEnv{}
+	if l.opts.DSN != "" {
+		if env.DB, err = sql.Open("mysql", l.opts.DSN); err != nil {
+			return err
+		}
+		defer env.DB.Close()
+	}
+
+	// bots register their routes on http.DefaultServeMux, so serving that once
+	// serves every bot
+	setSharedHTTP(true)
+	srv := &http.Server{Addr: l.opts.HTTPAddr, Handler: traceHandler(http.DefaultServeMux)}
+
+	var bots []Bot
+	for _, name := range l.opts.Bots {
+		bot, err := NewRegisteredBot(name, l.botArgs(name), env)
+		if err != nil {
+			return fmt.Errorf("unable to create %s: %s", name, err)
+		}
+		bots = append(bots, bot)
+	}
+
+	errCh := make(chan error, len(bots)+1)
+	for _, bot := range bots {
+		bot := bot
+		GoWithRecover(l.DebugOutput, func() {
+			if err := bot.Go(); err != nil {
+				errCh <- fmt.Errorf("%s: %s", bot.Name(), err)
+				return
+			}
+			errCh <- nil
+		})
+	}
+	GoWithRecover(l.DebugOutput, func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			errCh <- err
+		}
+	})
+	GoWithRecover(l.DebugOutput, func() {
+		// each bot handles the signal itself, we only need to stop serving
+		signalCh := make(chan os.Signal, 1)
+		signal.Notify(signalCh, os.Interrupt, os.Signal(syscall.SIGTERM))
+		sig := <-signalCh
+		signal.Stop(signalCh)
+		l.Debug("Run: received %q, shutting down HTTP server", sig)
+		if err := srv.Shutdown(context.Background()); err != nil {
+			l.Debug("Run: unable to shutdown HTTP server: %s", err)
+		}
+	})
+	// one bot failing takes the process down, rather than leaving the rest
+	// running without it
+	for range bots {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
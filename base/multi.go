@@ -15,6 +15,7 @@ type multi struct {
 	name           string
 	id             string
 	isLeader       bool
+	leaderID       string
 	timeoutSeconds int
 	interval       time.Duration
 }
@@ -34,7 +35,9 @@ func (m *multi) Heartbeat(shutdownCh chan struct{}) (err error) {
 		return nil
 	}
 	defer m.Trace(&err, "Heartbeat")()
+	m.Lock()
 	m.id = RandHexString(8)
+	m.Unlock()
 	m.Debug("Heartbeat: starting multi coordination heartbeat loop: id: %s", m.id)
 	for {
 		select {
@@ -57,6 +60,30 @@ func (m *multi) IsLeader() bool {
 	return m.isLeader
 }
 
+// LeaderStatus describes this instance's part in multi coordination.
+type LeaderStatus struct {
+	// Coordinated is false when the bot runs without a multi database, in
+	// which case the instance always leads.
+	Coordinated bool
+	ID          string
+	LeaderID    string
+	IsLeader    bool
+}
+
+func (m *multi) Status() LeaderStatus {
+	if m == nil {
+		return LeaderStatus{IsLeader: true}
+	}
+	m.Lock()
+	defer m.Unlock()
+	return LeaderStatus{
+		Coordinated: true,
+		ID:          m.id,
+		LeaderID:    m.leaderID,
+		IsLeader:    m.isLeader,
+	}
+}
+
 func (m *multi) heartbeat() {
 	// update ourselves first
 	err := m.db.RunTxn(func(tx *sql.Tx) error {
@@ -90,6 +117,7 @@ func (m *multi) heartbeat() {
 	defer m.Unlock()
 	lastLeader := m.isLeader
 	m.isLeader = id == m.id
+	m.leaderID = id
 	if lastLeader != m.isLeader {
 		if m.isLeader {
 			m.Errorf("heartbeat: leader change: isLeader: %v myid: %s", m.isLeader, m.id)
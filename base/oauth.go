@@ -23,12 +23,12 @@ func (e OAuthRequiredError) Error() string {
 
 type OAuthStorage interface {
 	GetToken(identifier string) (*oauth2.Token, error)
-	PutToken(identifier string, token *oauth2.Token) error
-	DeleteToken(identifier string) error
+	PutToken(ctx context.Context, identifier string, token *oauth2.Token) error
+	DeleteToken(ctx context.Context, identifier string) error
 
 	GetState(state string) (*OAuthRequest, error)
-	PutState(state string, req *OAuthRequest) error
-	CompleteState(state string) error
+	PutState(ctx context.Context, state string, req *OAuthRequest) error
+	CompleteState(ctx context.Context, state string) error
 }
 
 type OAuthHTTPSrv struct {
@@ -36,7 +36,7 @@ type OAuthHTTPSrv struct {
 	kbc         *kbchat.API
 	oauth       *oauth2.Config
 	storage     OAuthStorage
-	callback    func(msg chat1.MsgSummary, identifier string) error
+	callback    func(ctx context.Context, msg chat1.MsgSummary, identifier string) error
 	htmlTitle   string
 	htmlLogoB64 string
 	htmlLogoSrc string
@@ -48,7 +48,7 @@ func NewOAuthHTTPSrv(
 	debugConfig *ChatDebugOutputConfig,
 	oauth *oauth2.Config,
 	storage OAuthStorage,
-	callback func(msg chat1.MsgSummary, identifier string) error,
+	callback func(ctx context.Context, msg chat1.MsgSummary, identifier string) error,
 	htmlTitle string,
 	htmlLogoB64 string,
 	urlPrefix string,
@@ -128,15 +128,15 @@ func (o *OAuthHTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 		o.showOAuthError(w)
 		return
 	}
-	token, err := o.oauth.Exchange(context.TODO(), code)
+	token, err := o.oauth.Exchange(r.Context(), code)
 	if err != nil {
 		return
 	}
 
-	if err = o.storage.PutToken(req.TokenIdentifier, token); err != nil {
+	if err = o.storage.PutToken(r.Context(), req.TokenIdentifier, token); err != nil {
 		return
 	}
-	if err = o.storage.CompleteState(state); err != nil {
+	if err = o.storage.CompleteState(r.Context(), state); err != nil {
 		return
 	}
 	callbackMsg, err := o.getCallbackMsg(*req)
@@ -144,7 +144,7 @@ func (o *OAuthHTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = o.callback(callbackMsg, req.TokenIdentifier); err != nil {
+	if err = o.callback(r.Context(), callbackMsg, req.TokenIdentifier); err != nil {
 		return
 	}
 
@@ -186,9 +186,12 @@ type GetOAuthOpts struct {
 	AuthMessageTemplate string
 	// optional callback which constructs and sends auth URL (default: disabled)
 	AuthURLCallback func(authUrl string) error
+	// optional client used for token renewal and API requests (default: http.DefaultClient)
+	HTTPClient *http.Client
 }
 
 func GetOAuthClient(
+	ctx context.Context,
 	tokenIdentifier string,
 	callbackMsg chat1.MsgSummary,
 	kbc *kbchat.API,
@@ -216,7 +219,7 @@ func GetOAuthClient(
 		if err != nil {
 			return nil, err
 		}
-		if err := storage.PutState(state, &OAuthRequest{
+		if err := storage.PutState(ctx, state, &OAuthRequest{
 			TokenIdentifier: tokenIdentifier,
 			ConvID:          callbackMsg.ConvID,
 			MsgID:           callbackMsg.Id,
@@ -255,14 +258,19 @@ func GetOAuthClient(
 
 		return nil, OAuthRequiredError{}
 	}
+	// the client outlives ctx, so it doesn't use it
+	clientCtx := context.Background()
+	if opts.HTTPClient != nil {
+		clientCtx = context.WithValue(clientCtx, oauth2.HTTPClient, opts.HTTPClient)
+	}
 	// renew token
 	if token.Expiry.Before(time.Now()) {
-		newToken, err := config.TokenSource(context.Background(), token).Token()
+		newToken, err := config.TokenSource(clientCtx, token).Token()
 		if err != nil {
 			return nil, fmt.Errorf("unable to renew token: %s", err)
 		}
 		if newToken.AccessToken != token.AccessToken {
-			err = storage.PutToken(tokenIdentifier, newToken)
+			err = storage.PutToken(ctx, tokenIdentifier, newToken)
 			if err != nil {
 				return nil, fmt.Errorf("unable to update token: %s", err)
 			}
@@ -270,5 +278,5 @@ func GetOAuthClient(
 		}
 	}
 
-	return config.Client(context.Background(), token), nil
+	return config.Client(clientCtx, token), nil
 }
@@ -0,0 +1,455 @@
+package base
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// wizardTimeout is how long a setup wizard waits for an answer before giving
+// up.
+const wizardTimeout = 15 * time.Minute
+
+// WizardStep is one question of a bot's setup wizard.
+type WizardStep struct {
+	// Prompt asks the question.
+	Prompt string
+	// Commands turns the answers so far, the last being the answer to this
+	// step, into bot commands which are run as if the user had sent them. A
+	// WizardAnswerError asks the question again.
+	Commands func(answers []string) ([]string, error)
+	// Check reports whether the commands did what they were meant to, bot
+	// commands tell the user what went wrong themselves rather than returning
+	// an error. The question is asked again if they didn't, so the user can
+	// sort out the problem, such as logging in, and answer again. Steps
+	// without a Check trust their commands.
+	Check func(msg chat1.MsgSummary, answers []string) (bool, error)
+}
+
+// WizardAnswerError is returned by WizardStep.Commands for an answer which
+// doesn't make sense, the message is shown before asking again.
+type WizardAnswerError string
+
+func (e WizardAnswerError) Error() string {
+	return string(e)
+}
+
+type wizard struct {
+	username string
+	step     int
+	answers  []string
+	lastSeen time.Time
+}
+
+// OnboardingStatus is how far a team has got with the bot.
+type OnboardingStatus struct {
+	WelcomeMsg      string
+	WelcomeDisabled bool
+	Welcomed        bool
+	Completed       bool
+}
+
+// Onboarding welcomes teams the bot is added to and can walk them through
+// setting the bot up. Teams can customize or disable the welcome message with
+// `!<cmd> welcome` and start the wizard with `!<cmd> setup`.
+type Onboarding struct {
+	*DebugOutput
+	sync.Mutex
+
+	stats          *StatsRegistry
+	kbc            *kbchat.API
+	db             *DB
+	bot            string
+	cmd            string
+	defaultWelcome string
+
+	run     func(context.Context, chat1.MsgSummary) error
+	steps   []WizardStep
+	wizards map[chat1.ConvIDStr]*wizard
+}
+
+// NewOnboarding creates the onboarding for the bot named bot, whose commands
+// start with !cmd, welcoming teams with defaultWelcome unless they pick their
+// own. Bots without a database pass a nil db, their teams always get
+// defaultWelcome.
+func NewOnboarding(stats *StatsRegistry, kbc *kbchat.API, debugConfig *ChatDebugOutputConfig, db *DB,
+	bot, cmd, defaultWelcome string) *Onboarding {
+	return &Onboarding{
+		DebugOutput:    NewDebugOutput("Onboarding", debugConfig),
+		stats:          stats.SetPrefix("Onboarding"),
+		kbc:            kbc,
+		db:             db,
+		bot:            bot,
+		cmd:            cmd,
+		defaultWelcome: defaultWelcome,
+		wizards:        make(map[chat1.ConvIDStr]*wizard),
+	}
+}
+
+// SetWizard gives the bot a setup wizard, run being the handler the wizard's
+// commands are sent to.
+func (o *Onboarding) SetWizard(run func(context.Context, chat1.MsgSummary) error, steps ...WizardStep) {
+	o.Lock()
+	defer o.Unlock()
+	o.run = run
+	o.steps = steps
+}
+
+func (o *Onboarding) hasWizard() bool {
+	o.Lock()
+	defer o.Unlock()
+	return len(o.steps) > 0
+}
+
+// HandleNewConv welcomes a team the bot has been added to in its default
+// conversation, using the team's own welcome message if it has one.
+func (o *Onboarding) HandleNewConv(conv chat1.ConvSummary) error {
+	if conv.Channel.MembersType == "team" && !conv.IsDefaultConv {
+		o.Debug("HandleNewConv: skipping conversation %+v, not default team conv", conv)
+		o.stats.Count("HandleNewConv - skipped new conv")
+		return nil
+	} else if conv.CreatorInfo != nil && conv.CreatorInfo.Username == o.kbc.GetUsername() {
+		o.Debug("HandleNewConv: skipping conversation %+v, bot created conversation", conv)
+		o.stats.Count("HandleNewConv - skipped new conv")
+		return nil
+	}
+	status, err := o.Status(conv.Channel.Name)
+	if err != nil {
+		return err
+	}
+	if status.WelcomeDisabled {
+		o.stats.Count("HandleNewConv - welcome disabled")
+		return nil
+	}
+	welcomeMsg := status.WelcomeMsg
+	if o.hasWizard() && !status.Completed {
+		welcomeMsg += fmt.Sprintf("\n\nSend `!%s setup` and I'll walk you through getting set up.", o.cmd)
+	}
+	// Delay for a short time in case there is an ephemeral policy on this
+	// conversation and the bot is not yet keyed.
+	GoWithRecover(o.DebugOutput, func() {
+		time.Sleep(time.Second)
+		o.stats.Count("HandleNewConv - new conv")
+		if _, err := o.kbc.SendMessageByConvID(conv.Id, "%s", welcomeMsg); err != nil {
+			o.Errorf("HandleNewConv: unable to welcome: %v", err)
+			return
+		}
+		if err := o.setWelcomed(context.Background(), conv.Channel.Name, !o.hasWizard()); err != nil {
+			o.Errorf("HandleNewConv: unable to record welcome: %v", err)
+		}
+	})
+	return nil
+}
+
+// HandleCommand handles the onboarding commands and answers to the setup
+// wizard, returning whether msg was one of them.
+func (o *Onboarding) HandleCommand(ctx context.Context, msg chat1.MsgSummary) (handled bool, err error) {
+	if msg.Content.Text == nil || msg.Sender.Username == o.kbc.GetUsername() {
+		return false, nil
+	}
+	body := strings.TrimSpace(msg.Content.Text.Body)
+	toks := strings.Fields(body)
+	if len(toks) >= 2 && strings.EqualFold(toks[0], "!"+o.cmd) {
+		switch strings.ToLower(toks[1]) {
+		case "welcome":
+			return true, o.handleWelcome(ctx, msg, toks[2:], body)
+		case "setup":
+			if o.hasWizard() {
+				return true, o.startWizard(msg)
+			}
+		}
+	}
+	if strings.HasPrefix(body, "!") {
+		return false, nil
+	}
+	return o.handleAnswer(ctx, msg, body)
+}
+
+func (o *Onboarding) handleWelcome(ctx context.Context, msg chat1.MsgSummary, args []string, body string) error {
+	team := msg.Channel.Name
+	if len(args) == 0 {
+		status, err := o.Status(team)
+		if err != nil {
+			return err
+		}
+		if status.WelcomeDisabled {
+			o.ChatEcho(msg.ConvID, "I won't send a welcome message when I'm added to `%s`.", team)
+		} else {
+			o.ChatEcho(msg.ConvID, "When I'm added to `%s` I say:\n> %s", team,
+				strings.ReplaceAll(status.WelcomeMsg, "\n", "\n> "))
+		}
+		if o.hasWizard() && !status.Completed {
+			o.ChatEcho(msg.ConvID, "Setup hasn't been finished here yet, send `!%s setup` to start.", o.cmd)
+		}
+		return nil
+	}
+
+	if o.db == nil {
+		o.ChatEcho(msg.ConvID, "My welcome message can't be changed, I don't have anywhere to keep it.")
+		return nil
+	}
+	isAdmin, err := IsAtLeastAdmin(o.kbc, msg.Sender.Username, msg.Channel)
+	if err != nil {
+		return fmt.Errorf("handleWelcome: error getting role status: %s", err)
+	}
+	if !isAdmin {
+		o.ChatEcho(msg.ConvID, "You must be an admin to change my welcome message!")
+		return nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "set":
+		// keep the message's own formatting rather than the tokens
+		lower := strings.ToLower(body)
+		idx := strings.Index(lower, "welcome")
+		idx += strings.Index(lower[idx:], "set") + len("set")
+		welcomeMsg := strings.TrimSpace(body[idx:])
+		if welcomeMsg == "" {
+			o.ChatEcho(msg.ConvID, "I don't understand! Try `!%s welcome set <message>`", o.cmd)
+			return nil
+		}
+		if err := o.setWelcome(ctx, team, &welcomeMsg, false); err != nil {
+			return err
+		}
+		o.ChatEcho(msg.ConvID, "Okay, I'll say that when I'm added to `%s`.", team)
+	case "disable":
+		if err := o.setWelcome(ctx, team, nil, true); err != nil {
+			return err
+		}
+		o.ChatEcho(msg.ConvID, "Okay, I won't send a welcome message when I'm added to `%s`.", team)
+	case "enable", "reset":
+		if err := o.setWelcome(ctx, team, nil, false); err != nil {
+			return err
+		}
+		o.ChatEcho(msg.ConvID, "Okay, I'll send my usual welcome message when I'm added to `%s`.", team)
+	default:
+		o.ChatEcho(msg.ConvID, "I don't understand! Try `!%s welcome [set <message> | disable | enable | reset]`", o.cmd)
+	}
+	return nil
+}
+
+func (o *Onboarding) startWizard(msg chat1.MsgSummary) error {
+	o.Lock()
+	o.wizards[msg.ConvID] = &wizard{
+		username: msg.Sender.Username,
+		lastSeen: time.Now(),
+	}
+	prompt := o.steps[0].Prompt
+	o.Unlock()
+	o.stats.Count("wizard - start")
+	o.ChatEcho(msg.ConvID, "Let's get you set up, @%s! Reply `skip` to skip a question or `cancel` to stop.\n\n%s",
+		msg.Sender.Username, prompt)
+	return nil
+}
+
+// handleAnswer feeds msg to the conversation's wizard if it's waiting on the
+// sender.
+func (o *Onboarding) handleAnswer(ctx context.Context, msg chat1.MsgSummary, answer string) (handled bool, err error) {
+	o.Lock()
+	w, ok := o.wizards[msg.ConvID]
+	if !ok || w.username != msg.Sender.Username {
+		o.Unlock()
+		return false, nil
+	}
+	if time.Since(w.lastSeen) > wizardTimeout {
+		delete(o.wizards, msg.ConvID)
+		o.Unlock()
+		return false, nil
+	}
+	w.lastSeen = time.Now()
+	step := o.steps[w.step]
+	answers := append(append([]string{}, w.answers...), answer)
+	run := o.run
+	o.Unlock()
+
+	var cmds []string
+	switch strings.ToLower(answer) {
+	case "cancel":
+		o.endWizard(msg.ConvID)
+		o.stats.Count("wizard - cancel")
+		o.ChatEcho(msg.ConvID, "Okay, send `!%s setup` whenever you want to start again.", o.cmd)
+		return true, nil
+	case "skip":
+	default:
+		cmds, err = step.Commands(answers)
+		if answerErr, ok := err.(WizardAnswerError); ok {
+			o.ChatEcho(msg.ConvID, "%s\n\n%s", answerErr, step.Prompt)
+			return true, nil
+		} else if err != nil {
+			o.endWizard(msg.ConvID)
+			return true, err
+		}
+	}
+	for _, cmd := range cmds {
+		cmdMsg := msg
+		cmdMsg.Content.Text = &chat1.MsgTextContent{Body: cmd}
+		if err := run(ctx, cmdMsg); err != nil {
+			if _, ok := err.(OAuthRequiredError); ok {
+				o.retryStep(msg.ConvID, step)
+				return true, nil
+			}
+			o.endWizard(msg.ConvID)
+			o.ChatEcho(msg.ConvID, "Something went wrong setting that up, send `!%s setup` to try again.", o.cmd)
+			return true, err
+		}
+	}
+	if len(cmds) > 0 && step.Check != nil {
+		ok, err := step.Check(msg, answers)
+		if err != nil {
+			o.endWizard(msg.ConvID)
+			return true, err
+		}
+		if !ok {
+			o.retryStep(msg.ConvID, step)
+			return true, nil
+		}
+	}
+
+	o.Lock()
+	w.answers = answers
+	w.step++
+	done := w.step >= len(o.steps)
+	var prompt string
+	if !done {
+		prompt = o.steps[w.step].Prompt
+	}
+	o.Unlock()
+	if !done {
+		o.ChatEcho(msg.ConvID, "%s", prompt)
+		return true, nil
+	}
+	o.endWizard(msg.ConvID)
+	o.stats.Count("wizard - complete")
+	if err := o.setCompleted(ctx, msg.Channel.Name); err != nil {
+		return true, err
+	}
+	o.ChatEcho(msg.ConvID, "You're all set! Send `!%s help` to see what else I can do.", o.cmd)
+	return true, nil
+}
+
+// retryStep asks the question again after its commands didn't work out, the
+// wizard waits for the user to fix whatever went wrong.
+func (o *Onboarding) retryStep(convID chat1.ConvIDStr, step WizardStep) {
+	o.stats.Count("wizard - retry")
+	o.ChatEcho(convID, "That didn't work out yet. Once you've sorted it out, answer again, or reply `skip` to move on.\n\n%s",
+		step.Prompt)
+}
+
+func (o *Onboarding) endWizard(convID chat1.ConvIDStr) {
+	o.Lock()
+	defer o.Unlock()
+	delete(o.wizards, convID)
+}
+
+// Status returns the team's onboarding state.
+func (o *Onboarding) Status(team string) (status OnboardingStatus, err error) {
+	status.WelcomeMsg = o.defaultWelcome
+	if o.db == nil {
+		return status, nil
+	}
+	var welcomeMsg sql.NullString
+	var welcomedTime, completedTime sql.NullInt64
+	row := o.db.QueryRow(`SELECT welcome_msg, welcome_disabled,
+		ROUND(UNIX_TIMESTAMP(welcomed_time)), ROUND(UNIX_TIMESTAMP(completed_time))
+		FROM onboarding
+		WHERE bot = ? AND team = ?`, o.bot, team)
+	err = row.Scan(&welcomeMsg, &status.WelcomeDisabled, &welcomedTime, &completedTime)
+	switch err {
+	case nil, sql.ErrNoRows:
+	default:
+		return status, err
+	}
+	if welcomeMsg.Valid {
+		status.WelcomeMsg = welcomeMsg.String
+	}
+	status.Welcomed = welcomedTime.Valid
+	status.Completed = completedTime.Valid
+	return status, nil
+}
+
+func (o *Onboarding) setWelcome(ctx context.Context, team string, welcomeMsg *string, disabled bool) error {
+	return o.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO onboarding
+			(bot, team, welcome_msg, welcome_disabled)
+			VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE welcome_msg=VALUES(welcome_msg), welcome_disabled=VALUES(welcome_disabled)`,
+			o.bot, team, welcomeMsg, disabled)
+		return err
+	})
+}
+
+// setWelcomed records the team has been welcomed, which completes onboarding
+// for bots without a wizard.
+func (o *Onboarding) setWelcomed(ctx context.Context, team string, completed bool) error {
+	if o.db == nil {
+		return nil
+	}
+	return o.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO onboarding
+			(bot, team, welcomed_time, completed_time)
+			VALUES (?, ?, NOW(), IF(?, NOW(), NULL))
+			ON DUPLICATE KEY UPDATE welcomed_time=NOW(),
+			completed_time=COALESCE(completed_time, VALUES(completed_time))`,
+			o.bot, team, completed)
+		return err
+	})
+}
+
+func (o *Onboarding) setCompleted(ctx context.Context, team string) error {
+	if o.db == nil {
+		return nil
+	}
+	return o.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO onboarding
+			(bot, team, completed_time)
+			VALUES (?, ?, NOW())
+			ON DUPLICATE KEY UPDATE completed_time=NOW()`,
+			o.bot, team)
+		return err
+	})
+}
+
+// OnboardingAdvertisements describes the onboarding commands of the bot whose
+// commands start with !cmd, for it to include in its advertisement.
+func OnboardingAdvertisements(cmd string, hasWizard bool) []chat1.UserBotCommandInput {
+	welcomeExtended := fmt.Sprintf(`Show, change or turn off the message I send when I'm added to a team. Only team admins can change it.
+
+Examples:%s
+!%s welcome
+!%s welcome set Hi! Ask @alice if you need a hand setting me up.
+!%s welcome disable
+!%s welcome reset%s`, backs, cmd, cmd, cmd, cmd, backs)
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        fmt.Sprintf("%s welcome", cmd),
+			Description: "Customize my welcome message",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       fmt.Sprintf("*!%s welcome* [set <message> | disable | enable | reset]", cmd),
+				DesktopBody: welcomeExtended,
+				MobileBody:  welcomeExtended,
+			},
+		},
+	}
+	if hasWizard {
+		cmds = append(cmds, chat1.UserBotCommandInput{
+			Name:        fmt.Sprintf("%s setup", cmd),
+			Description: "Walk through setting me up",
+		})
+	}
+	return cmds
+}
+
+// OnboardingAdminTable describes the rows of the table used by the Onboarding
+// of the bot named bot, which it shares with the other bots.
+func OnboardingAdminTable(bot string) AdminTable {
+	return AdminTable{
+		Name:       "onboarding",
+		Where:      fmt.Sprintf("bot = '%s'", bot),
+		KeyColumns: []string{"team"},
+	}
+}
@@ -32,7 +32,13 @@ type Options struct {
 	StathatEZKey string
 	// Allow the bot to read it's own messages (default: false)
 	ReadSelf bool
-	AWSOpts  *AWSOptions
+	// File to record received chat events to, for replaying later
+	RecordFile string
+	// Language of advertisements and of replies where none is set
+	Locale        string
+	AWSOpts       *AWSOptions
+	TracingOpts   *TracingOptions
+	DashboardOpts *DashboardOptions
 }
 
 func NewOptions() *Options {
@@ -50,6 +56,10 @@ func (o *Options) Parse(fs *flag.FlagSet, argv []string) error {
 	fs.StringVar(&o.MultiDSN, "multi-dsn", os.Getenv("BOT_MULTI_DSN"), "Bot multi coordination database DSN")
 	fs.StringVar(&o.StathatEZKey, "stathat-ezkey", os.Getenv("BOT_STATHAT_EZKEY"), "Bot stathat ezkey")
 	fs.BoolVar(&o.ReadSelf, "read-self", false, "Allow the bot to read it's own messages")
+	fs.StringVar(&o.RecordFile, "record", os.Getenv("BOT_RECORD_FILE"),
+		"File to record received messages and conversations to as JSONL, optional. The recording holds message contents.")
+	fs.StringVar(&o.Locale, "locale", os.Getenv("BOT_LOCALE"),
+		"Language of advertisements and the default language of replies, for bots with translations (default: en)")
 
 	awsOpts := &AWSOptions{}
 	fs.StringVar(&awsOpts.AWSRegion, "aws-region", os.Getenv("BOT_AWS_REGION"), "AWS region for cloudwatch logs, optional")
@@ -57,9 +67,28 @@ func (o *Options) Parse(fs *flag.FlagSet, argv []string) error {
 	if o.AWSOpts.IsEmpty() && !awsOpts.IsEmpty() {
 		o.AWSOpts = awsOpts
 	}
+
+	tracingOpts := &TracingOptions{}
+	fs.StringVar(&tracingOpts.Exporter, "trace-exporter", os.Getenv("BOT_TRACE_EXPORTER"),
+		"Export OpenTelemetry traces to 'otlp' or 'stdout', optional")
+	fs.StringVar(&tracingOpts.OTLPEndpoint, "otlp-endpoint", os.Getenv("BOT_OTLP_ENDPOINT"),
+		"OTLP/HTTP collector URL for traces, optional")
+
+	dashboardOpts := &DashboardOptions{}
+	fs.StringVar(&dashboardOpts.URL, "dashboard-url", os.Getenv("BOT_DASHBOARD_URL"),
+		"Public URL of the bot's HTTP server, enables the admin dashboard at /<bot>/admin, optional")
+	fs.StringVar(&dashboardOpts.Secret, "dashboard-secret", os.Getenv("BOT_DASHBOARD_SECRET"),
+		"Secret signing admin dashboard logins, shared by every instance of the bot, optional")
 	if err := fs.Parse(argv[1:]); err != nil {
 		return err
 	}
+	// checked after parsing so the flags, not just the environment, count
+	if o.TracingOpts.IsEmpty() && !tracingOpts.IsEmpty() {
+		o.TracingOpts = tracingOpts
+	}
+	if o.DashboardOpts.IsEmpty() && !dashboardOpts.IsEmpty() {
+		o.DashboardOpts = dashboardOpts
+	}
 	return nil
 }
 
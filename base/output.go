@@ -3,6 +3,7 @@ package base
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
@@ -12,13 +13,73 @@ import (
 type ChatDebugOutputConfig struct {
 	KBC           *kbchat.API
 	ErrReportConv string
+	// Cleaner, if set, cleans up after conversations found to be deleted
+	Cleaner *ConvCleaner
+
+	errors *errorLog
 }
 
 func NewChatDebugOutputConfig(kbc *kbchat.API, errReportConv string) *ChatDebugOutputConfig {
 	return &ChatDebugOutputConfig{
 		KBC:           kbc,
 		ErrReportConv: errReportConv,
+		errors:        newErrorLog(maxRecentErrors),
+	}
+}
+
+// RecentErrors returns the latest errors reported with Errorf, newest first.
+func (c *ChatDebugOutputConfig) RecentErrors() []RecentError {
+	if c == nil {
+		return nil
+	}
+	return c.errors.list()
+}
+
+const maxRecentErrors = 100
+
+// RecentError is an error reported with Errorf.
+type RecentError struct {
+	Time   time.Time
+	Source string
+	Msg    string
+}
+
+// errorLog keeps the last few errors in a ring buffer. A nil errorLog keeps
+// nothing.
+type errorLog struct {
+	sync.Mutex
+	errors []RecentError
+	next   int
+}
+
+func newErrorLog(size int) *errorLog {
+	return &errorLog{errors: make([]RecentError, 0, size)}
+}
+
+func (l *errorLog) add(e RecentError) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	if len(l.errors) < cap(l.errors) {
+		l.errors = append(l.errors, e)
+		return
+	}
+	l.errors[l.next] = e
+	l.next = (l.next + 1) % len(l.errors)
+}
+
+func (l *errorLog) list() (res []RecentError) {
+	if l == nil {
+		return nil
 	}
+	l.Lock()
+	defer l.Unlock()
+	for i := len(l.errors) - 1; i >= 0; i-- {
+		res = append(res, l.errors[(l.next+i)%len(l.errors)])
+	}
+	return res
 }
 
 type DebugOutput struct {
@@ -44,6 +105,13 @@ func (d *DebugOutput) Debug(format string, args ...interface{}) {
 
 func (d *DebugOutput) Errorf(msg string, args ...interface{}) {
 	d.Debug(msg, args...)
+	if d.config != nil {
+		d.config.errors.add(RecentError{
+			Time:   time.Now(),
+			Source: d.name,
+			Msg:    fmt.Sprintf(msg, args...),
+		})
+	}
 	msg = fmt.Sprintf("```%s```", msg)
 	d.Report(msg, args...)
 }
@@ -61,22 +129,35 @@ func (d *DebugOutput) Report(msg string, args ...interface{}) {
 	}
 }
 
+// CheckDeletedConv reports whether err says the conversation has been deleted,
+// in which case the conversation's state is cleaned up.
+func (d *DebugOutput) CheckDeletedConv(convID chat1.ConvIDStr, err error) bool {
+	if err == nil || !IsDeletedConvError(err) {
+		return false
+	}
+	if d.config != nil {
+		d.config.Cleaner.ConvGone(convID, ConvGoneDeleted)
+	}
+	return true
+}
+
 func (d *DebugOutput) ChatDebug(convID chat1.ConvIDStr, msg string, args ...interface{}) {
 	d.Debug(msg, args...)
-	if _, err := d.config.KBC.SendMessageByConvID(convID, "Something went wrong!"); err != nil && !IsDeletedConvError(err) {
+	if _, err := d.config.KBC.SendMessageByConvID(convID, "Something went wrong!"); err != nil && !d.CheckDeletedConv(convID, err) {
 		d.Errorf("ChatDebug: failed to send error message: %s", err)
 	}
 }
 
 func (d *DebugOutput) ChatErrorf(convID chat1.ConvIDStr, msg string, args ...interface{}) {
 	d.Errorf(msg, args...)
-	if _, err := d.config.KBC.SendMessageByConvID(convID, "Something went wrong!"); err != nil && !IsDeletedConvError(err) {
+	if _, err := d.config.KBC.SendMessageByConvID(convID, "Something went wrong!"); err != nil && !d.CheckDeletedConv(convID, err) {
 		d.Errorf("ChatErrorf: failed to send error message: %s", err)
 	}
 }
 
 func (d *DebugOutput) ChatEcho(convID chat1.ConvIDStr, msg string, args ...interface{}) {
-	if _, err := d.config.KBC.SendMessageByConvID(convID, msg, args...); err != nil && !IsDeletedConvError(err) {
+	body := fmt.Sprintf(msg, args...)
+	if err := SendLongMessageByConvID(d.config.KBC, d, convID, "", body); err != nil && !d.CheckDeletedConv(convID, err) {
 		d.Errorf("ChatEcho: failed to send echo message: %s", err)
 	}
 }
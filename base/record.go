@@ -0,0 +1,105 @@
+package base
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// RecordedEvent is one line of a recording. It uses the same format as
+// `keybase chat api-listen`, so a recording can be fed back through kbchat
+// unchanged.
+type RecordedEvent struct {
+	Type string `json:"type"`
+	// Time the event was received, in unix milliseconds
+	Time int64              `json:"time"`
+	Msg  *chat1.MsgSummary  `json:"msg,omitempty"`
+	Conv *chat1.ConvSummary `json:"conv,omitempty"`
+}
+
+const (
+	recordedMsgType  = "chat"
+	recordedConvType = "chat_conv"
+)
+
+// Recorder appends the chat events a Server receives to a JSONL file. A nil
+// Recorder records nothing.
+type Recorder struct {
+	sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+func (r *Recorder) record(event RecordedEvent) error {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	event.Time = time.Now().UnixMilli()
+	return r.enc.Encode(event)
+}
+
+func (r *Recorder) RecordMsg(msg chat1.MsgSummary) error {
+	return r.record(RecordedEvent{Type: recordedMsgType, Msg: &msg})
+}
+
+func (r *Recorder) RecordConv(conv chat1.ConvSummary) error {
+	return r.record(RecordedEvent{Type: recordedConvType, Conv: &conv})
+}
+
+func (r *Recorder) Shutdown() error {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// ReadRecording parses a recording written by a Recorder.
+func ReadRecording(rd io.Reader) (events []RecordedEvent, err error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("invalid event on line %d: %s", line, err)
+		}
+		switch event.Type {
+		case recordedMsgType, recordedConvType:
+		default:
+			return nil, fmt.Errorf("unknown event type %q on line %d", event.Type, line)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
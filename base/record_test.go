@@ -0,0 +1,45 @@
+package base
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	msg := chat1.MsgSummary{
+		ConvID: "abc",
+		Sender: chat1.MsgSender{Username: "alice"},
+		Content: chat1.MsgContent{
+			TypeName: "text",
+			Text:     &chat1.MsgTextContent{Body: "!webhook list"},
+		},
+	}
+	require.NoError(t, recorder.RecordMsg(msg))
+	require.NoError(t, recorder.RecordConv(chat1.ConvSummary{Id: "def"}))
+	require.NoError(t, recorder.Shutdown())
+	// recording after shutdown is a no-op, as is a nil recorder
+	require.NoError(t, recorder.RecordMsg(msg))
+	require.NoError(t, (*Recorder)(nil).RecordMsg(msg))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	events, err := ReadRecording(f)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "chat", events[0].Type)
+	require.Equal(t, "!webhook list", events[0].Msg.Content.Text.Body)
+	require.Equal(t, "chat_conv", events[1].Type)
+	require.Equal(t, chat1.ConvIDStr("def"), events[1].Conv.Id)
+
+	_, err = ReadRecording(strings.NewReader(`{"type":"wallet"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,294 @@
+package base
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/keybase1"
+)
+
+// Replaying runs a registered bot against a fake keybase service. The program
+// doing the replay re-executes itself as the bot's keybase binary, with the
+// environment variables below telling the child which part to play:
+// `chat api-listen` plays back the recording and `chat api` writes down what
+// the bot sends instead of sending it.
+const (
+	replayDirEnv       = "BOT_REPLAY_DIR"
+	replayRecordingEnv = "BOT_REPLAY_RECORDING"
+	replayUsernameEnv  = "BOT_REPLAY_USERNAME"
+
+	replaySentFile       = "sent.jsonl"
+	replayListenDoneFile = "listen.done"
+)
+
+type ReplayOptions struct {
+	// Bot is the registered name of the bot to run.
+	Bot string
+	// Recording is the path of a recording written with --record.
+	Recording string
+	// Username the fake service reports for the bot.
+	Username string
+	// Args are extra command line arguments for the bot, such as the --dsn
+	// of a scratch database.
+	Args []string
+	// Settle is how long the bot must go without sending anything, once the
+	// whole recording has been delivered, for the replay to be done.
+	Settle time.Duration
+	// Timeout bounds the whole replay.
+	Timeout time.Duration
+}
+
+// SentMessage is a request the bot made of the fake chat API.
+type SentMessage struct {
+	Method         string          `json:"method"`
+	ConversationID chat1.ConvIDStr `json:"conversation_id,omitempty"`
+	Channel        string          `json:"channel,omitempty"`
+	Body           string          `json:"body,omitempty"`
+	Filename       string          `json:"filename,omitempty"`
+	Title          string          `json:"title,omitempty"`
+}
+
+func (m SentMessage) String() string {
+	dest := m.Channel
+	if dest == "" {
+		dest = string(m.ConversationID)
+	}
+	text := m.Body
+	if m.Filename != "" {
+		text = fmt.Sprintf("<attachment %s> %s", filepath.Base(m.Filename), m.Title)
+	}
+	return fmt.Sprintf("[%s %s] %s", m.Method, dest, text)
+}
+
+// IsFakeKeybase reports whether this process was started by Replay to act as
+// the bot's keybase binary, in which case main should hand over to
+// RunFakeKeybase straight away.
+func IsFakeKeybase() bool {
+	return os.Getenv(replayDirEnv) != ""
+}
+
+// Replay runs the bot over the recording and returns what it sent. The bot is
+// left running, so a process should only replay once.
+func Replay(opts ReplayOptions) (sent []SentMessage, err error) {
+	recording, err := os.Open(opts.Recording)
+	if err != nil {
+		return nil, err
+	}
+	_, err = ReadRecording(recording)
+	recording.Close()
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording: %s", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "replay-"+opts.Bot)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	recordingPath, err := filepath.Abs(opts.Recording)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range map[string]string{
+		replayDirEnv:       dir,
+		replayRecordingEnv: recordingPath,
+		replayUsernameEnv:  opts.Username,
+	} {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// don't grab a port, no one is going to make requests
+	setSharedHTTP(true)
+	argv := append([]string{opts.Bot, "--keybase", exe, "--home", dir}, opts.Args...)
+	bot, err := NewRegisteredBot(opts.Bot, argv, nil)
+	if err != nil {
+		return nil, err
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- bot.Go() }()
+
+	sentPath := filepath.Join(dir, replaySentFile)
+	deadline := time.After(opts.Timeout)
+	var lastSize int64 = -1
+	lastChange := time.Now()
+	for {
+		select {
+		case err := <-errCh:
+			if err == nil {
+				err = fmt.Errorf("bot exited")
+			}
+			return nil, fmt.Errorf("%s stopped before the replay finished: %s", opts.Bot, err)
+		case <-deadline:
+			return nil, fmt.Errorf("replay timed out after %v", opts.Timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+		var size int64
+		if fi, err := os.Stat(sentPath); err == nil {
+			size = fi.Size()
+		}
+		if size != lastSize {
+			lastSize = size
+			lastChange = time.Now()
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, replayListenDoneFile)); err != nil {
+			continue
+		}
+		if time.Since(lastChange) >= opts.Settle {
+			break
+		}
+	}
+	return readSentMessages(sentPath)
+}
+
+func readSentMessages(path string) (sent []SentMessage, err error) {
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	for {
+		var msg SentMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return sent, nil
+		} else if err != nil {
+			return nil, err
+		}
+		sent = append(sent, msg)
+	}
+}
+
+// RunFakeKeybase implements the parts of the keybase CLI kbchat uses, args
+// being os.Args[1:]. It returns the exit code.
+func RunFakeKeybase(args []string) int {
+	// kbchat puts --home first
+	if len(args) >= 2 && args[0] == "--home" {
+		args = args[2:]
+	}
+	var err error
+	switch strings.Join(args[:min(len(args), 2)], " ") {
+	case "whoami -json":
+		err = json.NewEncoder(os.Stdout).Encode(keybase1.CurrentStatus{
+			LoggedIn: true,
+			User:     &keybase1.User{Username: os.Getenv(replayUsernameEnv)},
+		})
+	case "chat api":
+		err = fakeChatAPI(os.Stdin, os.Stdout)
+	case "chat api-listen":
+		err = fakeChatAPIListen(os.Stdout)
+	default:
+		// everything else (notification settings, log sends, ...) succeeds
+		// without doing anything
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fake keybase %v: %s\n", args, err)
+		return 1
+	}
+	return 0
+}
+
+type fakeAPIRequest struct {
+	Method string
+	Params struct {
+		Options struct {
+			Channel        chat1.ChatChannel
+			ConversationID chat1.ConvIDStr `json:"conversation_id"`
+			Message        struct {
+				Body string
+			}
+			Filename string
+			Title    string
+		}
+	}
+}
+
+func fakeChatAPI(in io.Reader, out io.Writer) error {
+	sent, err := os.OpenFile(filepath.Join(os.Getenv(replayDirEnv), replaySentFile),
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer sent.Close()
+	dec := json.NewDecoder(in)
+	for msgID := chat1.MessageID(1); ; msgID++ {
+		var req fakeAPIRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		opts := req.Params.Options
+		if opts.Message.Body != "" || opts.Filename != "" {
+			data, err := json.Marshal(SentMessage{
+				Method:         req.Method,
+				ConversationID: opts.ConversationID,
+				Channel:        opts.Channel.Name,
+				Body:           opts.Message.Body,
+				Filename:       opts.Filename,
+				Title:          opts.Title,
+			})
+			if err != nil {
+				return err
+			}
+			// a single write so concurrent pipes don't interleave lines
+			if _, err := sent.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+		if err := json.NewEncoder(out).Encode(map[string]interface{}{
+			"result": chat1.SendRes{Message: "message sent", MessageID: &msgID},
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func fakeChatAPIListen(out io.Writer) error {
+	f, err := os.Open(os.Getenv(replayRecordingEnv))
+	if err != nil {
+		return err
+	}
+	events, err := ReadRecording(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	done, err := os.Create(filepath.Join(os.Getenv(replayDirEnv), replayListenDoneFile))
+	if err != nil {
+		return err
+	}
+	done.Close()
+	// kbchat restarts listening whenever we exit, which would deliver the
+	// recording again, so hang around until the replay does
+	ppid := os.Getppid()
+	for os.Getppid() == ppid {
+		time.Sleep(500 * time.Millisecond)
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package base
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/robfig/cron/v3"
+)
+
+// Job is a unit of scheduled work as handed to a JobHandler.
+type Job struct {
+	ID   string
+	Name string
+	// Cron is the schedule for recurring jobs, empty for one-shot jobs.
+	Cron string
+	// RunAt is when the job was scheduled to run. After downtime this can be
+	// well in the past, handlers should use it rather than time.Now() when
+	// deciding what work is due.
+	RunAt    time.Time
+	Attempts int
+	Payload  []byte
+}
+
+// Decode unmarshals the job's payload into v.
+func (j Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// JobHandler runs a job. Jobs are delivered at least once, if the handler
+// returns an error (or the bot dies while it is running) the job is retried,
+// so handlers should be idempotent.
+type JobHandler func(job Job) error
+
+type SchedulerOptions struct {
+	// PollInterval is how often we check for due jobs.
+	PollInterval time.Duration
+	// Lease is how long a claimed job is hidden from other pollers. A job
+	// whose handler runs longer than this may be delivered twice.
+	Lease time.Duration
+	// BatchSize is the most jobs claimed per poll.
+	BatchSize int
+	// MaxAttempts is how many times a failing job is retried before it is
+	// dropped, for cron jobs the failing occurrence is skipped instead.
+	MaxAttempts int
+	// CatchUpWindow bounds how far back missed cron occurrences are replayed
+	// after downtime, older occurrences are skipped.
+	CatchUpWindow time.Duration
+}
+
+func DefaultSchedulerOptions() SchedulerOptions {
+	return SchedulerOptions{
+		PollInterval:  5 * time.Second,
+		Lease:         5 * time.Minute,
+		BatchSize:     50,
+		MaxAttempts:   10,
+		CatchUpWindow: 24 * time.Hour,
+	}
+}
+
+// Scheduler runs one-shot and cron jobs stored in the jobs table (see
+// jobs.sql). Jobs survive restarts and are only run by the leader when
+// multiple instances of a bot are running.
+type Scheduler struct {
+	*DebugOutput
+	sync.Mutex
+
+	db       *DB
+	kbc      *kbchat.API
+	stats    *StatsRegistry
+	opts     SchedulerOptions
+	isLeader func() bool
+	id       string
+
+	handlers   map[string]JobHandler
+	shutdownCh chan struct{}
+}
+
+// NewScheduler creates a scheduler. isLeader is consulted before each poll,
+// pass Server.IsLeader to only run jobs on the leader.
+func NewScheduler(stats *StatsRegistry, kbc *kbchat.API, debugConfig *ChatDebugOutputConfig, db *DB,
+	isLeader func() bool, opts SchedulerOptions) *Scheduler {
+	s := &Scheduler{
+		DebugOutput: NewDebugOutput("Scheduler", debugConfig),
+		db:          db,
+		kbc:         kbc,
+		stats:       stats.SetPrefix("Scheduler"),
+		opts:        opts,
+		isLeader:    isLeader,
+		id:          RandHexString(8),
+		handlers:    make(map[string]JobHandler),
+		shutdownCh:  make(chan struct{}),
+	}
+	s.Register(sendMessageJobName, s.sendMessageJob)
+	return s
+}
+
+// Register sets the handler for jobs with the given name. Only jobs with a
+// registered handler are claimed, so several bots may share a jobs table.
+func (s *Scheduler) Register(name string, handler JobHandler) {
+	s.Lock()
+	defer s.Unlock()
+	s.handlers[name] = handler
+}
+
+// ScheduleOnce schedules a job to run once at runAt. Scheduling an existing id
+// replaces the job. If id is empty a random one is generated.
+func (s *Scheduler) ScheduleOnce(id, name string, runAt time.Time, payload interface{}) (string, error) {
+	return s.schedule(id, name, "", runAt, payload)
+}
+
+// ScheduleCron schedules a recurring job using a standard five field cron
+// spec. Prefix the spec with CRON_TZ=<zone> to run in a timezone other than
+// UTC. Scheduling an existing id with the same spec keeps its next run time,
+// so it is safe to call on every startup without losing missed runs.
+func (s *Scheduler) ScheduleCron(id, name, spec string, payload interface{}) (string, error) {
+	sched, err := parseCron(spec)
+	if err != nil {
+		return "", err
+	}
+	return s.schedule(id, name, spec, sched.Next(time.Now()), payload)
+}
+
+// Cancel removes a job, it is not an error if the job does not exist.
+func (s *Scheduler) Cancel(id string) error {
+	return s.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM jobs WHERE id = ?`, id)
+		return err
+	})
+}
+
+// CancelByPrefix removes all jobs whose id starts with prefix, handy for
+// dropping everything scheduled for a conversation.
+func (s *Scheduler) CancelByPrefix(prefix string) error {
+	return s.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM jobs WHERE id LIKE ?`, escapeLike(prefix)+"%")
+		return err
+	})
+}
+
+// ConvJobID returns the id of one of the bot's jobs for the conversation,
+// key tells the conversation's jobs apart. The bot's ConvCleaner cancels
+// them once the conversation is gone.
+func ConvJobID(bot string, convID chat1.ConvIDStr, key string) string {
+	return convJobPrefix(bot, convID) + key
+}
+
+func convJobPrefix(bot string, convID chat1.ConvIDStr) string {
+	return fmt.Sprintf("%s:%s:", bot, convID)
+}
+
+const sendMessageJobName = "base.sendMessage"
+
+type sendMessagePayload struct {
+	ConvID chat1.ConvIDStr
+	Msg    string
+}
+
+// ScheduleMessage sends msg to the conversation at runAt.
+func (s *Scheduler) ScheduleMessage(id string, convID chat1.ConvIDStr, runAt time.Time, msg string) (string, error) {
+	return s.ScheduleOnce(id, sendMessageJobName, runAt, sendMessagePayload{
+		ConvID: convID,
+		Msg:    msg,
+	})
+}
+
+func (s *Scheduler) sendMessageJob(job Job) error {
+	var payload sendMessagePayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	err := SendLongMessageByConvID(s.kbc, s.DebugOutput, payload.ConvID, "", payload.Msg)
+	if s.CheckDeletedConv(payload.ConvID, err) {
+		s.Debug("sendMessageJob: dropping message for deleted conv: %s", payload.ConvID)
+		return nil
+	}
+	return err
+}
+
+func (s *Scheduler) schedule(id, name, spec string, runAt time.Time, payload interface{}) (string, error) {
+	if id == "" {
+		id = RandHexString(16)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	// cron must be assigned last, the other columns compare against its old
+	// value to tell whether the schedule changed
+	err = s.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO jobs (id, name, payload, cron, run_at, attempts, ctime)
+			VALUES (?, ?, ?, ?, FROM_UNIXTIME(?), 0, NOW())
+			ON DUPLICATE KEY UPDATE
+			run_at=IF(cron != '' AND cron = VALUES(cron), run_at, VALUES(run_at)),
+			attempts=IF(cron != '' AND cron = VALUES(cron), attempts, 0),
+			locked_by=IF(cron != '' AND cron = VALUES(cron), locked_by, ''),
+			locked_until=IF(cron != '' AND cron = VALUES(cron), locked_until, NULL),
+			name=VALUES(name),
+			payload=VALUES(payload),
+			cron=VALUES(cron)
+		`, id, name, data, spec, runAt.Unix())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *Scheduler) Run() (err error) {
+	defer s.Trace(&err, "Run")()
+	s.Lock()
+	shutdownCh := s.shutdownCh
+	s.Unlock()
+	for {
+		if s.isLeader == nil || s.isLeader() {
+			s.runDue(shutdownCh)
+		}
+		select {
+		case <-shutdownCh:
+			return nil
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+func (s *Scheduler) Shutdown() (err error) {
+	defer s.Trace(&err, "Shutdown")()
+	s.Lock()
+	defer s.Unlock()
+	if s.shutdownCh != nil {
+		close(s.shutdownCh)
+		s.shutdownCh = nil
+	}
+	return nil
+}
+
+// runDue runs claimed batches until no due jobs remain, so a backlog built up
+// during downtime is worked through without waiting for the next poll.
+func (s *Scheduler) runDue(shutdownCh chan struct{}) {
+	for {
+		jobs, err := s.claim()
+		if err != nil {
+			s.Errorf("runDue: failed to claim jobs: %s", err)
+			return
+		}
+		for _, job := range jobs {
+			s.runJob(job)
+		}
+		if len(jobs) < s.opts.BatchSize {
+			return
+		}
+		select {
+		case <-shutdownCh:
+			return
+		default:
+		}
+	}
+}
+
+func (s *Scheduler) handlerNames() (names []string) {
+	s.Lock()
+	defer s.Unlock()
+	for name := range s.handlers {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *Scheduler) getHandler(name string) JobHandler {
+	s.Lock()
+	defer s.Unlock()
+	return s.handlers[name]
+}
+
+// claim leases due jobs to this scheduler so concurrent pollers (e.g. during
+// a leader change) don't run the same job at the same time.
+func (s *Scheduler) claim() (jobs []Job, err error) {
+	names := s.handlerNames()
+	if len(names) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	args = append(args, s.opts.BatchSize)
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")
+	err = s.db.RunTxn(func(tx *sql.Tx) error {
+		rows, err := tx.Query(fmt.Sprintf(`
+			SELECT id, name, cron, ROUND(UNIX_TIMESTAMP(run_at)), attempts, payload
+			FROM jobs
+			WHERE run_at <= NOW()
+			AND (locked_until IS NULL OR locked_until < NOW())
+			AND name IN (%s)
+			ORDER BY run_at
+			LIMIT ?
+			FOR UPDATE
+		`, placeholders), args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var job Job
+			var runAt int64
+			if err := rows.Scan(&job.ID, &job.Name, &job.Cron, &runAt, &job.Attempts, &job.Payload); err != nil {
+				return err
+			}
+			job.RunAt = time.Unix(runAt, 0)
+			jobs = append(jobs, job)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if _, err := tx.Exec(`
+				UPDATE jobs
+				SET locked_by = ?, locked_until = NOW() + INTERVAL ? SECOND
+				WHERE id = ?
+			`, s.id, int(s.opts.Lease.Seconds()), job.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *Scheduler) runJob(job Job) {
+	handler := s.getHandler(job.Name)
+	if handler == nil {
+		s.Errorf("runJob: no handler for job %s (%s)", job.ID, job.Name)
+		return
+	}
+	start := time.Now()
+	err := s.safeRun(handler, job)
+	s.stats.Value("runJob - duration - seconds", time.Since(start).Seconds())
+	if err != nil {
+		s.stats.Count("runJob - error")
+		job.Attempts++
+		if job.Attempts < s.opts.MaxAttempts {
+			s.Debug("runJob: job %s (%s) failed, attempt %d: %s", job.ID, job.Name, job.Attempts, err)
+			if err := s.retry(job, err); err != nil {
+				s.Errorf("runJob: failed to reschedule job %s: %s", job.ID, err)
+			}
+			return
+		}
+		s.Errorf("runJob: giving up on job %s (%s) after %d attempts: %s", job.ID, job.Name, job.Attempts, err)
+	} else {
+		s.stats.Count("runJob - success")
+	}
+	if err := s.complete(job); err != nil {
+		s.Errorf("runJob: failed to complete job %s: %s", job.ID, err)
+	}
+}
+
+func (s *Scheduler) safeRun(handler JobHandler, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(job)
+}
+
+// retry releases the lease and backs the job off exponentially, capped at an
+// hour.
+func (s *Scheduler) retry(job Job, jobErr error) error {
+	backoff := time.Duration(1<<uint(job.Attempts)) * time.Second
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+	return s.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE jobs
+			SET attempts = ?, last_error = ?, locked_by = '', locked_until = NULL,
+			run_at = NOW() + INTERVAL ? SECOND
+			WHERE id = ? AND locked_by = ?
+		`, job.Attempts, jobErr.Error(), int(backoff.Seconds()), job.ID, s.id)
+		return err
+	})
+}
+
+// complete deletes one-shot jobs and moves cron jobs to their next
+// occurrence. The locked_by check keeps us from clobbering a job which was
+// rescheduled while it ran.
+func (s *Scheduler) complete(job Job) error {
+	if job.Cron == "" {
+		return s.db.RunTxn(func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM jobs WHERE id = ? AND locked_by = ?`, job.ID, s.id)
+			return err
+		})
+	}
+	sched, err := parseCron(job.Cron)
+	if err != nil {
+		return err
+	}
+	next := nextCronRun(sched, job.RunAt, time.Now(), s.opts.CatchUpWindow)
+	return s.db.RunTxn(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE jobs
+			SET attempts = 0, last_error = NULL, locked_by = '', locked_until = NULL,
+			run_at = FROM_UNIXTIME(?)
+			WHERE id = ? AND locked_by = ?
+		`, next.Unix(), job.ID, s.id)
+		return err
+	})
+}
+
+func parseCron(spec string) (cron.Schedule, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: %s", spec, err)
+	}
+	return sched, nil
+}
+
+// nextCronRun returns the occurrence after last. Missed occurrences are
+// returned one at a time so they are each run, unless they are older than
+// window.
+func nextCronRun(sched cron.Schedule, last, now time.Time, window time.Duration) time.Time {
+	from := last
+	if earliest := now.Add(-window); from.Before(earliest) {
+		from = earliest
+	}
+	return sched.Next(from)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
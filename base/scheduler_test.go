@@ -0,0 +1,42 @@
+package base
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCronRun(t *testing.T) {
+	sched, err := parseCron("*/15 * * * *")
+	require.NoError(t, err)
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// on time, the next occurrence is in the future
+	next := nextCronRun(sched, now, now, time.Hour)
+	require.Equal(t, now.Add(15*time.Minute), next)
+
+	// missed occurrences within the window are replayed one at a time
+	next = nextCronRun(sched, now.Add(-45*time.Minute), now, time.Hour)
+	require.Equal(t, now.Add(-30*time.Minute), next)
+
+	// older occurrences are skipped
+	next = nextCronRun(sched, now.Add(-3*time.Hour), now, time.Hour)
+	require.Equal(t, now.Add(-45*time.Minute), next)
+}
+
+func TestParseCron(t *testing.T) {
+	_, err := parseCron("CRON_TZ=America/New_York 0 10 * * 1-5")
+	require.NoError(t, err)
+	_, err = parseCron("every tuesday")
+	require.Error(t, err)
+}
+
+func TestConvJobID(t *testing.T) {
+	id := ConvJobID("githubbot", "abc", "digest:1234")
+	require.Equal(t, "githubbot:abc:digest:1234", id)
+	require.True(t, strings.HasPrefix(id, convJobPrefix("githubbot", "abc")))
+	require.False(t, strings.HasPrefix(id, convJobPrefix("githubbot", "ab")))
+	require.False(t, strings.HasPrefix(id, convJobPrefix("gitlabbot", "abc")))
+}
@@ -0,0 +1,148 @@
+package base
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+// MaxMessageLength is the longest text body the chat service accepts.
+const MaxMessageLength = 10000
+
+// maxMessagePages is how many messages we are willing to split a long body
+// into before we give up and send it as an attachment instead.
+const maxMessagePages = 3
+
+func IsMaxLengthError(err error) bool {
+	// error created in https://github.com/keybase/client/blob/7d6aa64f3fba66adba7a5dd1cc7c523d5086a548/go/chat/msgchecker/plaintext_checker.go#L50
+	return err != nil && strings.Contains(err.Error(), "exceeds the maximum length")
+}
+
+// SplitMessage breaks body into pages of at most maxLen bytes. Pages are split
+// on line boundaries where possible, and code blocks which span a page break
+// are closed and reopened so each page renders on its own.
+func SplitMessage(body string, maxLen int) (pages []string) {
+	pages, _ = splitMessage(body, maxLen)
+	return pages
+}
+
+// splitMessage is SplitMessage, also returning the offset in body at which
+// each page's text ends.
+func splitMessage(body string, maxLen int) (pages []string, ends []int) {
+	if len(body) <= maxLen {
+		return []string{body}, []int{len(body)}
+	}
+	// leave room to close and reopen a code block around the page break
+	limit := maxLen - 2*len(backs) - 2
+	if limit <= 0 {
+		limit = maxLen
+	}
+	var cur strings.Builder
+	var pos int
+	inCode := false
+	flush := func() {
+		page := cur.String()
+		if inCode {
+			page += "\n" + backs
+		}
+		pages = append(pages, page)
+		ends = append(ends, pos)
+		cur.Reset()
+		if inCode {
+			cur.WriteString(backs + "\n")
+		}
+	}
+	for _, line := range strings.SplitAfter(body, "\n") {
+		for len(line) > 0 {
+			room := limit - cur.Len()
+			if len(line) <= room {
+				cur.WriteString(line)
+				pos += len(line)
+				if strings.Count(line, backs)%2 == 1 {
+					inCode = !inCode
+				}
+				break
+			}
+			if cur.Len() > len(backs)+1 || room <= 0 {
+				// start this line on a fresh page
+				flush()
+				continue
+			}
+			// a single line longer than a whole page, cut it at a rune boundary
+			cut := room
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				cut = room
+			}
+			cur.WriteString(line[:cut])
+			pos += cut
+			if strings.Count(line[:cut], backs)%2 == 1 {
+				inCode = !inCode
+			}
+			line = line[cut:]
+			flush()
+		}
+	}
+	if cur.Len() > 0 {
+		pages = append(pages, cur.String())
+		ends = append(ends, pos)
+	}
+	return pages, ends
+}
+
+// SendLongMessageByConvID sends body to the conversation, splitting it over a
+// few messages if it is too long for one. Bodies which would take more than
+// maxMessagePages messages are uploaded as a text attachment instead, as is
+// the rest of the body if a page is rejected as too long. If title is set it
+// is sent as the first line of the message, or as the attachment title.
+func SendLongMessageByConvID(kbc *kbchat.API, debugOutput *DebugOutput, convID chat1.ConvIDStr,
+	title, body string) (err error) {
+	msg := body
+	if title != "" {
+		msg = fmt.Sprintf("%s\n\n%s", title, body)
+	}
+	pages, ends := splitMessage(msg, MaxMessageLength)
+	if len(pages) <= maxMessagePages {
+		sent := 0
+		for i, page := range pages {
+			if _, err = kbc.SendMessageByConvID(convID, "%s", page); err != nil {
+				break
+			}
+			sent = ends[i]
+		}
+		if !IsMaxLengthError(err) {
+			return err
+		}
+		debugOutput.Debug("SendLongMessageByConvID: message rejected as too long, sending attachment: %v", err)
+		if sent > 0 {
+			// don't repeat the pages which made it
+			body = msg[sent:]
+		}
+	}
+	return sendMessageAttachment(kbc, convID, title, body)
+}
+
+func sendMessageAttachment(kbc *kbchat.API, convID chat1.ConvIDStr, title, body string) (err error) {
+	file, err := os.CreateTemp("", fmt.Sprintf("%s-*.txt", kbc.GetUsername()))
+	if err != nil {
+		return err
+	}
+	// SendAttachmentByConvID blocks until the upload is complete so the file
+	// can be cleaned up as soon as we return.
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	_, err = kbc.SendAttachmentByConvID(convID, file.Name(), title)
+	return err
+}
@@ -0,0 +1,60 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMessageShort(t *testing.T) {
+	require.Equal(t, []string{"hello"}, SplitMessage("hello", 100))
+}
+
+func TestSplitMessageLines(t *testing.T) {
+	line := strings.Repeat("a", 30) + "\n"
+	body := strings.Repeat(line, 10)
+	pages := SplitMessage(body, 100)
+	require.Len(t, pages, 5)
+	for _, page := range pages {
+		require.LessOrEqual(t, len(page), 100)
+		require.True(t, strings.HasSuffix(page, "\n"))
+	}
+	require.Equal(t, body, strings.Join(pages, ""))
+}
+
+func TestSplitMessageLongLine(t *testing.T) {
+	body := strings.Repeat("é", 200)
+	pages := SplitMessage(body, 100)
+	for _, page := range pages {
+		require.LessOrEqual(t, len(page), 100)
+		require.True(t, strings.HasPrefix(page, "é"))
+	}
+	require.Equal(t, body, strings.Join(pages, ""))
+}
+
+func TestSplitMessageCodeBlock(t *testing.T) {
+	line := strings.Repeat("a", 30) + "\n"
+	body := "```\n" + strings.Repeat(line, 10) + "```"
+	pages := SplitMessage(body, 100)
+	require.True(t, len(pages) > 1)
+	for _, page := range pages {
+		require.LessOrEqual(t, len(page), 100)
+		require.True(t, strings.HasPrefix(page, "```"))
+		require.True(t, strings.HasSuffix(page, "```"))
+	}
+}
+
+func TestSplitMessageEnds(t *testing.T) {
+	line := strings.Repeat("a", 30) + "\n"
+	body := "intro\n```\n" + strings.Repeat(line, 10) + "```\noutro"
+	pages, ends := splitMessage(body, 100)
+	require.Len(t, ends, len(pages))
+	require.Equal(t, len(body), ends[len(ends)-1])
+	start := 0
+	for i, page := range pages {
+		// pages only add code fences around the text up to their end
+		require.Contains(t, page, body[start:ends[i]])
+		start = ends[i]
+	}
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -13,11 +14,12 @@ import (
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
 type Handler interface {
-	HandleCommand(chat1.MsgSummary) error
+	HandleCommand(context.Context, chat1.MsgSummary) error
 	HandleNewConv(chat1.ConvSummary) error
 }
 
@@ -34,27 +36,35 @@ type Server struct {
 	name         string
 	announcement string
 	awsOpts      *AWSOptions
+	tracingOpts  *TracingOptions
 	kbc          *kbchat.API
 	botAdmins    []string
 	multiDBDSN   string
 	multi        *multi
 	readSelf     bool
+	recordFile   string
+	recorder     *Recorder
+	cleaner      *ConvCleaner
+	dashboard    *Dashboard
 
-	runOptions kbchat.RunOptions
+	runOptions     kbchat.RunOptions
+	advertisedCmds []chat1.UserBotCommandInput
 }
 
 func NewServer(
-	name, announcement string, awsOpts *AWSOptions, multiDBDSN string, readSelf bool,
-	runOptions kbchat.RunOptions,
+	name, announcement string, awsOpts *AWSOptions, tracingOpts *TracingOptions, multiDBDSN string, readSelf bool,
+	recordFile string, runOptions kbchat.RunOptions,
 ) *Server {
 	return &Server{
 		name:         name,
 		announcement: announcement,
 		awsOpts:      awsOpts,
+		tracingOpts:  tracingOpts,
 		botAdmins:    DefaultBotAdmins,
 		shutdownCh:   make(chan struct{}),
 		multiDBDSN:   multiDBDSN,
 		readSelf:     readSelf,
+		recordFile:   recordFile,
 		runOptions:   runOptions,
 	}
 }
@@ -63,10 +73,50 @@ func (s *Server) Name() string {
 	return s.name
 }
 
+// IsLeader reports whether this instance should do work which must only
+// happen once across all running instances of the bot.
+func (s *Server) IsLeader() bool {
+	return s.multi.IsLeader()
+}
+
+func (s *Server) LeaderStatus() LeaderStatus {
+	return s.multi.Status()
+}
+
 func (s *Server) SetBotAdmins(admins []string) {
 	s.botAdmins = admins
 }
 
+// SetConvCleaner has the server clean up after conversations the bot is
+// removed from, and after deleted conversations it fails to reply in.
+func (s *Server) SetConvCleaner(cleaner *ConvCleaner) {
+	s.Lock()
+	defer s.Unlock()
+	s.cleaner = cleaner
+	cleaner.setServer(s.kbc, s.IsLeader)
+	if s.DebugOutput != nil && s.Config() != nil {
+		s.Config().Cleaner = cleaner
+	}
+}
+
+func (s *Server) setDashboard(dashboard *Dashboard) {
+	s.Lock()
+	defer s.Unlock()
+	s.dashboard = dashboard
+}
+
+func (s *Server) getDashboard() *Dashboard {
+	s.Lock()
+	defer s.Unlock()
+	return s.dashboard
+}
+
+func (s *Server) getConvCleaner() *ConvCleaner {
+	s.Lock()
+	defer s.Unlock()
+	return s.cleaner
+}
+
 func (s *Server) GoWithRecover(eg *errgroup.Group, f func() error) {
 	GoWithRecoverErrGroup(eg, s.DebugOutput, f)
 }
@@ -81,6 +131,12 @@ func (s *Server) Shutdown() (err error) {
 		if err := s.kbc.Shutdown(); err != nil {
 			return err
 		}
+		if err := s.recorder.Shutdown(); err != nil {
+			s.Debug("Shutdown: unable to close recording: %v", err)
+		}
+		if err := ShutdownTracing(); err != nil {
+			s.Debug("Shutdown: unable to flush traces: %v", err)
+		}
 	}
 	return nil
 }
@@ -121,6 +177,10 @@ func (s *Server) Start(errReportConv string) (kbc *kbchat.API, err error) {
 	}
 	debugConfig := NewChatDebugOutputConfig(s.kbc, errReportConv)
 	s.DebugOutput = NewDebugOutput("Server", debugConfig)
+	if err := InitTracing(s.name, s.tracingOpts); err != nil {
+		s.Errorf("failed to set up tracing: %s", err)
+		return nil, err
+	}
 	if s.multiDBDSN != "" {
 		db, err := sql.Open("mysql", s.multiDBDSN)
 		if err != nil {
@@ -129,11 +189,18 @@ func (s *Server) Start(errReportConv string) (kbc *kbchat.API, err error) {
 		}
 		s.multi = newMulti(s.name, NewDB(db), debugConfig)
 	}
+	if s.recordFile != "" {
+		if s.recorder, err = NewRecorder(s.recordFile); err != nil {
+			s.Errorf("failed to open recording: %s", err)
+			return nil, err
+		}
+	}
 	return s.kbc, nil
 }
 
 func (s *Server) AnnounceAndAdvertise(advert kbchat.Advertisement, running string) (err error) {
-	if _, err := s.kbc.AdvertiseCommands(advert); err != nil {
+	s.setAdvertisement(advert)
+	if _, err := s.kbc.AdvertiseCommands(withHelpCommands(advert)); err != nil {
 		s.Errorf("advertise error: %s", err)
 		return err
 	}
@@ -190,48 +257,101 @@ func (s *Server) listenForMsgs(shutdownCh chan struct{}, sub *kbchat.Subscriptio
 		}
 
 		msg := m.Message
-		if msg.Sender.Username == s.kbc.GetUsername() && !s.readSelf {
+		if err := s.recorder.RecordMsg(msg); err != nil {
+			s.Debug("listenForMsgs: unable to record message: %v", err)
+		}
+		if IsConvGoneStatus(m.Conversation.MemberStatus) {
+			// removed from the conversation or the team
+			s.getConvCleaner().ConvGone(msg.ConvID, ConvGoneRemoved)
 			continue
 		}
-
-		if msg.Content.Text != nil {
-			cmd := strings.TrimSpace(msg.Content.Text.Body)
-			switch {
-			case strings.HasPrefix(cmd, "!logsend"):
-				if err := s.handleLogSend(msg); err != nil {
-					s.Errorf("listenForMsgs: unable to handleLogSend: %v", err)
-				}
-				continue
-			case strings.HasPrefix(cmd, "!botlog"):
-				if err := s.handleBotLogs(msg); err != nil {
-					s.Errorf("listenForMsgs: unable to handleBotLogs: %v", err)
-				}
-				continue
-			case strings.HasPrefix(cmd, "!pprof"):
-				if err := s.handlePProf(msg); err != nil {
-					s.Errorf("listenForMsgs: unable to handlePProf: %v", err)
-				}
-				continue
-			case strings.HasPrefix(cmd, "!stack"):
-				if err := s.handleStack(msg); err != nil {
-					s.Errorf("listenForMsgs: unable to handleStack: %v", err)
-				}
+		if msg.Sender.Username == s.kbc.GetUsername() {
+			// we see our own leave message when we're removed from the
+			// conversation, or from the team
+			if msg.Content.TypeName == "leave" {
+				s.getConvCleaner().ConvGone(msg.ConvID, ConvGoneLeft)
 				continue
-			case strings.HasPrefix(cmd, fmt.Sprintf("!%s", feedbackCmd(s.kbc.GetUsername()))):
-				if err := s.handleFeedback(msg); err != nil {
-					s.Errorf("listenForMsgs: unable to handleFeedback: %v", err)
-				}
+			}
+			if !s.readSelf {
 				continue
 			}
 		}
+		s.handleMsg(msg, handler)
+	}
+}
 
-		err = handler.HandleCommand(msg)
-		switch err := err.(type) {
-		case nil, OAuthRequiredError:
-		default:
-			s.ChatErrorf(msg.ConvID, "listenForMsgs: unable to HandleCommand: %v", err)
+// spanName names a message's span after the command it invokes, without any
+// arguments.
+func (s *Server) spanName(msg chat1.MsgSummary) string {
+	if msg.Content.Text == nil {
+		return "message"
+	}
+	toks := strings.Fields(strings.ToLower(msg.Content.Text.Body))
+	if len(toks) == 0 || !strings.HasPrefix(toks[0], "!") {
+		return "message"
+	}
+	toks[0] = strings.TrimPrefix(toks[0], "!")
+	if cmd := matchCommand(s.getAdvertisedCmds(), toks); cmd != nil {
+		return "!" + cmd.Name
+	}
+	return "!" + toks[0]
+}
+
+func (s *Server) handleMsg(msg chat1.MsgSummary, handler Handler) {
+	var err error
+	ctx, end := StartSpan(context.Background(), s.spanName(msg),
+		attribute.String("bot", s.name),
+		attribute.String("conv_id", string(msg.ConvID)),
+		attribute.String("sender", msg.Sender.Username))
+	defer end(&err)
+
+	if msg.Content.Text != nil {
+		cmd := strings.TrimSpace(msg.Content.Text.Body)
+		switch {
+		case strings.HasPrefix(cmd, "!logsend"):
+			if err = s.handleLogSend(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handleLogSend: %v", err)
+			}
+			return
+		case strings.HasPrefix(cmd, "!botlog"):
+			if err = s.handleBotLogs(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handleBotLogs: %v", err)
+			}
+			return
+		case strings.HasPrefix(cmd, "!pprof"):
+			if err = s.handlePProf(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handlePProf: %v", err)
+			}
+			return
+		case strings.HasPrefix(cmd, "!stack"):
+			if err = s.handleStack(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handleStack: %v", err)
+			}
+			return
+		case strings.HasPrefix(cmd, "!dashboard"):
+			if err = s.handleDashboard(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handleDashboard: %v", err)
+			}
+			return
+		case strings.HasPrefix(cmd, fmt.Sprintf("!%s", feedbackCmd(s.kbc.GetUsername()))):
+			if err = s.handleFeedback(msg); err != nil {
+				s.Errorf("listenForMsgs: unable to handleFeedback: %v", err)
+			}
+			return
+		}
+		if s.handleHelp(msg, cmd) {
+			return
 		}
 	}
+
+	err = handler.HandleCommand(ctx, msg)
+	switch typedErr := err.(type) {
+	case nil:
+	case OAuthRequiredError:
+		err = nil
+	default:
+		s.ChatErrorf(msg.ConvID, "listenForMsgs: unable to HandleCommand: %v", typedErr)
+	}
 }
 
 func (s *Server) listenForConvs(shutdownCh chan struct{}, sub *kbchat.Subscription, handler Handler) error {
@@ -254,6 +374,9 @@ func (s *Server) listenForConvs(shutdownCh chan struct{}, sub *kbchat.Subscripti
 			continue
 		}
 
+		if err := s.recorder.RecordConv(c.Conversation); err != nil {
+			s.Debug("listenForConvs: unable to record conv: %v", err)
+		}
 		if err := handler.HandleNewConv(c.Conversation); err != nil {
 			s.Errorf("listenForConvs: unable to HandleNewConv: %v", err)
 		}
@@ -261,8 +384,12 @@ func (s *Server) listenForConvs(shutdownCh chan struct{}, sub *kbchat.Subscripti
 }
 
 func (s *Server) allowHiddenCommand(msg chat1.MsgSummary) bool {
-	for _, username := range s.botAdmins {
-		if username == msg.Sender.Username {
+	return s.isBotAdmin(msg.Sender.Username)
+}
+
+func (s *Server) isBotAdmin(username string) bool {
+	for _, admin := range s.botAdmins {
+		if admin == username {
 			return true
 		}
 	}
@@ -389,6 +516,30 @@ func (s *Server) handleStack(msg chat1.MsgSummary) error {
 	return s.kbfsDebugOutput(msg, stack, "stack")
 }
 
+func (s *Server) handleDashboard(msg chat1.MsgSummary) error {
+	if !s.allowHiddenCommand(msg) {
+		s.Debug("ignoring dashboard from @%s, botAdmins: %v",
+			msg.Sender.Username, s.botAdmins)
+		return nil
+	}
+	dashboard := s.getDashboard()
+	if dashboard == nil {
+		s.ChatEcho(msg.ConvID, "The dashboard isn't enabled, run me with `--dashboard-url`.")
+		return nil
+	}
+	// the link logs in whoever has it, so only send it privately
+	if _, err := s.kbc.SendMessageByTlfName(msg.Sender.Username,
+		"Log in to the %s dashboard within the next %v: %s", s.name, dashboardLoginTTL,
+		dashboard.LoginURL(msg.Sender.Username)); err != nil {
+		return err
+	}
+	if msg.Channel.Name != fmt.Sprintf("%s,%s", msg.Sender.Username, s.kbc.GetUsername()) &&
+		msg.Channel.Name != fmt.Sprintf("%s,%s", s.kbc.GetUsername(), msg.Sender.Username) {
+		s.ChatEcho(msg.ConvID, "I've sent you a login link privately.")
+	}
+	return nil
+}
+
 func (s *Server) handleFeedback(msg chat1.MsgSummary) error {
 	toks := strings.Split(strings.TrimSpace(msg.Content.Text.Body), " ")
 	if len(toks) < 3 {
@@ -0,0 +1,542 @@
+package base
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+type SettingType int
+
+const (
+	SettingString SettingType = iota
+	SettingBool
+	SettingInt
+	SettingDuration
+)
+
+func (t SettingType) String() string {
+	switch t {
+	case SettingBool:
+		return "on/off"
+	case SettingInt:
+		return "number"
+	case SettingDuration:
+		return "duration"
+	default:
+		return "text"
+	}
+}
+
+// SettingUserError is returned for names, scopes and values that don't make
+// sense, as opposed to failures of the store.
+type SettingUserError string
+
+func (e SettingUserError) Error() string {
+	return string(e)
+}
+
+func settingUserErrorf(format string, args ...interface{}) error {
+	return SettingUserError(fmt.Sprintf(format, args...))
+}
+
+// parse checks value is valid for the type, returning it in a canonical form.
+func (t SettingType) parse(value string) (string, error) {
+	switch t {
+	case SettingBool:
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			return "true", nil
+		case "off", "false", "no", "0":
+			return "false", nil
+		}
+		return "", settingUserErrorf("`%s` isn't on or off", value)
+	case SettingInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", settingUserErrorf("`%s` isn't a number", value)
+		}
+	case SettingDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "", settingUserErrorf("`%s` isn't a duration such as `90m` or `2h`", value)
+		}
+	}
+	return value, nil
+}
+
+// SettingScope is what a setting's value applies to. A value set for a user
+// wins over one set for the conversation, which wins over one for the team.
+type SettingScope string
+
+const (
+	SettingScopeTeam SettingScope = "team"
+	SettingScopeConv SettingScope = "conv"
+	SettingScopeUser SettingScope = "user"
+)
+
+// settingScopes is ordered from most to least specific.
+var settingScopes = []SettingScope{SettingScopeUser, SettingScopeConv, SettingScopeTeam}
+
+// SettingDef declares one of a bot's settings.
+type SettingDef struct {
+	Name        string
+	Description string
+	Type        SettingType
+	// Default is used when no scope has a value.
+	Default string
+	// Scopes the setting can be set for, all of them if empty.
+	Scopes []SettingScope
+	// Choices restricts the values, any value of the type is allowed if empty.
+	Choices []string
+}
+
+// parse checks value is valid for the setting, returning it in a canonical
+// form.
+func (d SettingDef) parse(value string) (string, error) {
+	value, err := d.Type.parse(value)
+	if err != nil || len(d.Choices) == 0 {
+		return value, err
+	}
+	for _, choice := range d.Choices {
+		if strings.EqualFold(value, choice) {
+			return choice, nil
+		}
+	}
+	return "", settingUserErrorf("`%s` isn't one of: %s", value, strings.Join(d.Choices, ", "))
+}
+
+func (d SettingDef) describeType() string {
+	if len(d.Choices) > 0 {
+		return fmt.Sprintf("one of: %s", strings.Join(d.Choices, ", "))
+	}
+	return d.Type.String()
+}
+
+func (d SettingDef) allows(scope SettingScope) bool {
+	if len(d.Scopes) == 0 {
+		return true
+	}
+	for _, s := range d.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingsTarget identifies where a setting is read from.
+type SettingsTarget struct {
+	// Team is empty outside of team conversations.
+	Team     string
+	ConvID   chat1.ConvIDStr
+	Username string
+}
+
+func SettingsTargetFromMsg(msg chat1.MsgSummary) SettingsTarget {
+	target := SettingsTarget{
+		ConvID:   msg.ConvID,
+		Username: msg.Sender.Username,
+	}
+	if msg.Channel.MembersType == "team" {
+		target.Team = msg.Channel.Name
+	}
+	return target
+}
+
+func (t SettingsTarget) scopeID(scope SettingScope) string {
+	switch scope {
+	case SettingScopeTeam:
+		return t.Team
+	case SettingScopeConv:
+		return string(t.ConvID)
+	case SettingScopeUser:
+		return t.Username
+	}
+	return ""
+}
+
+// SettingValue is a setting's effective value and the scope it comes from,
+// empty for the default.
+type SettingValue struct {
+	Def   SettingDef
+	Value string
+	Scope SettingScope
+}
+
+// Settings is a key/value store of typed per-team, per-conversation and
+// per-user settings, so bots can add options without schema changes. Users
+// manage them with `!<cmd> settings`.
+type Settings struct {
+	*DebugOutput
+	kbc  *kbchat.API
+	db   *DB
+	bot  string
+	cmd  string
+	defs []SettingDef
+}
+
+// NewSettings creates the store for the bot named bot, whose commands start
+// with !cmd.
+func NewSettings(kbc *kbchat.API, debugConfig *ChatDebugOutputConfig, db *DB, bot, cmd string,
+	defs ...SettingDef) *Settings {
+	return &Settings{
+		DebugOutput: NewDebugOutput("Settings", debugConfig),
+		kbc:         kbc,
+		db:          db,
+		bot:         bot,
+		cmd:         cmd,
+		defs:        defs,
+	}
+}
+
+func (s *Settings) getDef(name string) (SettingDef, error) {
+	var names []string
+	for _, def := range s.defs {
+		if def.Name == name {
+			return def, nil
+		}
+		names = append(names, def.Name)
+	}
+	return SettingDef{}, settingUserErrorf("there's no setting `%s`, try one of: %s", name, strings.Join(names, ", "))
+}
+
+// Lookup returns the setting's value for the most specific scope which has
+// one.
+func (s *Settings) Lookup(target SettingsTarget, name string) (res SettingValue, err error) {
+	def, err := s.getDef(name)
+	if err != nil {
+		return res, err
+	}
+	values, err := s.getValues(target, name)
+	if err != nil {
+		return res, err
+	}
+	res = SettingValue{Def: def, Value: def.Default}
+	for _, scope := range settingScopes {
+		if value, ok := values[scope]; ok && def.allows(scope) {
+			res.Value = value
+			res.Scope = scope
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s *Settings) GetString(target SettingsTarget, name string) (string, error) {
+	value, err := s.Lookup(target, name)
+	return value.Value, err
+}
+
+func (s *Settings) GetBool(target SettingsTarget, name string) (bool, error) {
+	value, err := s.Lookup(target, name)
+	if err != nil {
+		return false, err
+	}
+	return value.Value == "true", nil
+}
+
+func (s *Settings) GetInt(target SettingsTarget, name string) (int, error) {
+	value, err := s.Lookup(target, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value.Value)
+}
+
+func (s *Settings) GetDuration(target SettingsTarget, name string) (time.Duration, error) {
+	value, err := s.Lookup(target, name)
+	if err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(value.Value)
+}
+
+// List returns the effective value of every setting.
+func (s *Settings) List(target SettingsTarget) (res []SettingValue, err error) {
+	for _, def := range s.defs {
+		value, err := s.Lookup(target, def.Name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, value)
+	}
+	return res, nil
+}
+
+func (s *Settings) getValues(target SettingsTarget, name string) (values map[SettingScope]string, err error) {
+	rows, err := s.db.Query(`SELECT scope, value
+		FROM settings
+		WHERE bot = ? AND name = ? AND (
+			(scope = ? AND scope_id = ?) OR
+			(scope = ? AND scope_id = ?) OR
+			(scope = ? AND scope_id = ?))`,
+		s.bot, name,
+		SettingScopeTeam, target.Team,
+		SettingScopeConv, target.ConvID,
+		SettingScopeUser, target.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values = make(map[SettingScope]string)
+	for rows.Next() {
+		var scope SettingScope
+		var value string
+		if err := rows.Scan(&scope, &value); err != nil {
+			return nil, err
+		}
+		values[scope] = value
+	}
+	return values, rows.Err()
+}
+
+func (s *Settings) checkScope(def SettingDef, scope SettingScope, target SettingsTarget) error {
+	if !def.allows(scope) {
+		return settingUserErrorf("`%s` can't be set for a %s", def.Name, scope)
+	}
+	if target.scopeID(scope) == "" {
+		return settingUserErrorf("there's no %s to set `%s` for here", scope, def.Name)
+	}
+	return nil
+}
+
+// Set stores the setting's value for the scope, after checking it's valid.
+func (s *Settings) Set(ctx context.Context, scope SettingScope, target SettingsTarget, name, value string) error {
+	def, err := s.getDef(name)
+	if err != nil {
+		return err
+	}
+	if err := s.checkScope(def, scope, target); err != nil {
+		return err
+	}
+	if value, err = def.parse(value); err != nil {
+		return err
+	}
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO settings
+			(bot, scope, scope_id, name, value)
+			VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE value=VALUES(value)`,
+			s.bot, scope, target.scopeID(scope), name, value)
+		return err
+	})
+}
+
+// Unset removes the setting's value for the scope, so it's inherited again.
+func (s *Settings) Unset(ctx context.Context, scope SettingScope, target SettingsTarget, name string) error {
+	def, err := s.getDef(name)
+	if err != nil {
+		return err
+	}
+	if err := s.checkScope(def, scope, target); err != nil {
+		return err
+	}
+	return s.db.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM settings
+			WHERE bot = ? AND scope = ? AND scope_id = ? AND name = ?`,
+			s.bot, scope, target.scopeID(scope), name)
+		return err
+	})
+}
+
+// HandleCommand handles `!<cmd> settings`, returning whether msg was one.
+func (s *Settings) HandleCommand(ctx context.Context, msg chat1.MsgSummary) (handled bool, err error) {
+	if msg.Content.Text == nil {
+		return false, nil
+	}
+	toks, userErr, err := SplitTokens(strings.TrimSpace(msg.Content.Text.Body))
+	if err != nil {
+		return false, err
+	}
+	if len(toks) < 2 || !strings.EqualFold(toks[0], "!"+s.cmd) || strings.ToLower(toks[1]) != "settings" {
+		return false, nil
+	}
+	if userErr != "" {
+		s.ChatEcho(msg.ConvID, "%s", userErr)
+		return true, nil
+	}
+	target := SettingsTargetFromMsg(msg)
+	args := toks[2:]
+	if len(args) == 0 {
+		return true, s.handleList(msg, target)
+	}
+	switch strings.ToLower(args[0]) {
+	case "list":
+		return true, s.handleList(msg, target)
+	case "get":
+		if len(args) != 2 {
+			break
+		}
+		value, err := s.Lookup(target, args[1])
+		if userErr, ok := err.(SettingUserError); ok {
+			s.ChatEcho(msg.ConvID, "%s", userErr)
+			return true, nil
+		} else if err != nil {
+			return true, err
+		}
+		s.ChatEcho(msg.ConvID, "%s", formatSettingValue(value))
+		return true, nil
+	case "set", "unset":
+		unset := strings.ToLower(args[0]) == "unset"
+		args = args[1:]
+		scope := SettingScopeConv
+		if len(args) > 0 {
+			for _, candidate := range settingScopes {
+				if strings.ToLower(args[0]) == string(candidate) {
+					scope = candidate
+					args = args[1:]
+					break
+				}
+			}
+		}
+		if (unset && len(args) != 1) || (!unset && len(args) < 2) {
+			break
+		}
+		allowed, err := s.isAllowed(msg, scope)
+		if err != nil {
+			return true, err
+		}
+		if !allowed {
+			s.ChatEcho(msg.ConvID, "You must be at least a writer to change settings for the conversation, or an admin for the team.")
+			return true, nil
+		}
+		name := args[0]
+		if unset {
+			err = s.Unset(ctx, scope, target, name)
+		} else {
+			err = s.Set(ctx, scope, target, name, strings.Join(args[1:], " "))
+		}
+		if userErr, ok := err.(SettingUserError); ok {
+			s.ChatEcho(msg.ConvID, "%s", userErr)
+			return true, nil
+		} else if err != nil {
+			return true, err
+		}
+		value, err := s.Lookup(target, name)
+		if err != nil {
+			return true, err
+		}
+		s.ChatEcho(msg.ConvID, "Okay! %s", formatSettingValue(value))
+		return true, nil
+	}
+	s.ChatEcho(msg.ConvID, "I don't understand! Try `!%s settings [list | get <name> | set [team|conv|user] <name> <value> | unset [team|conv|user] <name>]`", s.cmd)
+	return true, nil
+}
+
+func (s *Settings) isAllowed(msg chat1.MsgSummary, scope SettingScope) (bool, error) {
+	switch scope {
+	case SettingScopeTeam:
+		return IsAtLeastAdmin(s.kbc, msg.Sender.Username, msg.Channel)
+	case SettingScopeConv:
+		return IsAtLeastWriter(s.kbc, msg.Sender.Username, msg.Channel)
+	default:
+		// users only ever change their own settings
+		return true, nil
+	}
+}
+
+func (s *Settings) handleList(msg chat1.MsgSummary, target SettingsTarget) error {
+	values, err := s.List(target)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		s.ChatEcho(msg.ConvID, "I don't have any settings.")
+		return nil
+	}
+	lines := []string{"Settings here:"}
+	for _, value := range values {
+		lines = append(lines, fmt.Sprintf("• %s\n  %s (%s)", formatSettingValue(value),
+			value.Def.Description, value.Def.Type))
+	}
+	s.ChatEcho(msg.ConvID, "%s", strings.Join(lines, "\n"))
+	return nil
+}
+
+func formatSettingValue(value SettingValue) string {
+	from := "default"
+	if value.Scope != "" {
+		from = fmt.Sprintf("set for the %s", value.Scope)
+	}
+	shown := value.Value
+	if value.Def.Type == SettingBool {
+		shown = "off"
+		if value.Value == "true" {
+			shown = "on"
+		}
+	}
+	return fmt.Sprintf("`%s` is `%s` (%s)", value.Def.Name, shown, from)
+}
+
+// SettingLanguage is the name of the setting declared by LanguageSetting.
+const SettingLanguage = "language"
+
+// LanguageSetting declares a `language` setting offering the catalog's
+// locales, for bots replying through a Catalog.
+func LanguageSetting(catalog *Catalog, defaultLocale Locale) SettingDef {
+	var choices []string
+	for _, locale := range catalog.Locales() {
+		choices = append(choices, string(locale))
+	}
+	return SettingDef{
+		Name:        SettingLanguage,
+		Description: "Language I reply in",
+		Type:        SettingString,
+		Default:     string(catalog.Match(defaultLocale)),
+		Choices:     choices,
+	}
+}
+
+// Locale returns the target's language, DefaultLocale if the bot doesn't
+// have a LanguageSetting or it can't be read.
+func (s *Settings) Locale(target SettingsTarget) Locale {
+	if _, err := s.getDef(SettingLanguage); err != nil {
+		return DefaultLocale
+	}
+	value, err := s.GetString(target, SettingLanguage)
+	if err != nil {
+		s.Debug("Locale: unable to get language: %s", err)
+		return DefaultLocale
+	}
+	return ParseLocale(value)
+}
+
+// SettingsAdvertisement describes `!<cmd> settings`, for the bot to include
+// in its advertisement.
+func SettingsAdvertisement(cmd string) chat1.UserBotCommandInput {
+	settingsExtended := fmt.Sprintf(`List or change my settings. Values set for you win over ones set for the conversation, which win over ones set for the team. Writers can change the conversation's settings and admins the team's.
+
+Examples:%s
+!%s settings
+!%s settings get <name>
+!%s settings set <name> <value>
+!%s settings set team <name> <value>
+!%s settings unset <name>%s`, backs, cmd, cmd, cmd, cmd, cmd, backs)
+	return chat1.UserBotCommandInput{
+		Name:        fmt.Sprintf("%s settings", cmd),
+		Description: "List or change my settings",
+		ExtendedDescription: &chat1.UserBotExtendedDescription{
+			Title:       fmt.Sprintf("*!%s settings* [list | get | set | unset]", cmd),
+			DesktopBody: settingsExtended,
+			MobileBody:  settingsExtended,
+		},
+	}
+}
+
+// SettingsAdminTable describes the rows of the table used by the Settings of
+// the bot named bot. Bots share the table, so the admin tool and the
+// ConvCleaner only see the bot's own rows.
+func SettingsAdminTable(bot string) AdminTable {
+	return AdminTable{
+		Name:       "settings",
+		ConvColumn: "scope_id",
+		ConvWhere:  "scope = 'conv'",
+		Where:      fmt.Sprintf("bot = '%s'", bot),
+		KeyColumns: []string{"scope", "name"},
+	}
+}
@@ -0,0 +1,95 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingTypeParse(t *testing.T) {
+	for _, test := range []struct {
+		typ      SettingType
+		value    string
+		expected string
+		valid    bool
+	}{
+		{SettingString, "anything goes", "anything goes", true},
+		{SettingBool, "On", "true", true},
+		{SettingBool, "no", "false", true},
+		{SettingBool, "maybe", "", false},
+		{SettingInt, "42", "42", true},
+		{SettingInt, "4.2", "", false},
+		{SettingDuration, "90m", "90m", true},
+		{SettingDuration, "soon", "", false},
+	} {
+		value, err := test.typ.parse(test.value)
+		if !test.valid {
+			require.IsType(t, SettingUserError(""), err, test.value)
+			continue
+		}
+		require.NoError(t, err, test.value)
+		require.Equal(t, test.expected, value)
+	}
+}
+
+func TestSettingsTarget(t *testing.T) {
+	target := SettingsTarget{ConvID: "abc", Username: "alice"}
+	require.Equal(t, "", target.scopeID(SettingScopeTeam))
+	require.Equal(t, "abc", target.scopeID(SettingScopeConv))
+	require.Equal(t, "alice", target.scopeID(SettingScopeUser))
+
+	s := &Settings{}
+	def := SettingDef{Name: "questions", Scopes: []SettingScope{SettingScopeConv, SettingScopeTeam}}
+	require.NoError(t, s.checkScope(def, SettingScopeConv, target))
+	require.Error(t, s.checkScope(def, SettingScopeUser, target))
+	require.Error(t, s.checkScope(def, SettingScopeTeam, target))
+}
+
+func TestFormatSettingValue(t *testing.T) {
+	require.Equal(t, "`digest` is `on` (set for the team)", formatSettingValue(SettingValue{
+		Def:   SettingDef{Name: "digest", Type: SettingBool},
+		Value: "true",
+		Scope: SettingScopeTeam,
+	}))
+	require.Equal(t, "`questions` is `10` (default)", formatSettingValue(SettingValue{
+		Def:   SettingDef{Name: "questions", Type: SettingInt},
+		Value: "10",
+	}))
+}
+
+func TestSettingsAdminTable(t *testing.T) {
+	// pollbot and triviabot both have settings for the conversation, each
+	// only sees and cleans up its own
+	filter, err := ParseAdminFilter([]string{"conv=abc"})
+	require.NoError(t, err)
+	where, args, err := filter.where(SettingsAdminTable("pollbot"))
+	require.NoError(t, err)
+	require.Equal(t, "WHERE scope_id = ? AND (scope = 'conv') AND (bot = 'pollbot')", where)
+	require.Equal(t, []interface{}{"abc"}, args)
+	require.Equal(t, "scope_id = ? AND (scope = 'conv') AND (bot = 'pollbot')",
+		SettingsAdminTable("pollbot").convCondition())
+
+	where, args, err = filter.where(SettingsAdminTable("triviabot"))
+	require.NoError(t, err)
+	require.Equal(t, "WHERE scope_id = ? AND (scope = 'conv') AND (bot = 'triviabot')", where)
+	require.Equal(t, []interface{}{"abc"}, args)
+
+	// listing without a filter still only shows the bot's rows
+	where, args, err = AdminFilter{}.where(SettingsAdminTable("pollbot"))
+	require.NoError(t, err)
+	require.Equal(t, "WHERE (bot = 'pollbot')", where)
+	require.Empty(t, args)
+}
+
+func TestOnboardingAdminTable(t *testing.T) {
+	filter, err := ParseAdminFilter([]string{"team=acme"})
+	require.NoError(t, err)
+	where, args, err := filter.where(OnboardingAdminTable("githubbot"))
+	require.NoError(t, err)
+	require.Equal(t, "WHERE team = ? AND (bot = 'githubbot')", where)
+	require.Equal(t, []interface{}{"acme"}, args)
+
+	// the bot column is set by the scoping, it can't be filtered on
+	_, _, err = AdminFilter{"bot": "gitlabbot"}.where(OnboardingAdminTable("githubbot"))
+	require.Error(t, err)
+}
@@ -2,6 +2,8 @@ package base
 
 import (
 	"errors"
+	"strings"
+	"sync"
 	"time"
 
 	stathat "github.com/stathat/go"
@@ -103,10 +105,45 @@ func NewStatsBackend(btype StatsBackendType, config interface{}) (StatsBackend,
 	}
 }
 
+// statsCounters keeps a running total of every stat posted since the process
+// started, for the admin dashboard.
+type statsCounters struct {
+	sync.Mutex
+	counts map[string]int64
+	values map[string]float64
+}
+
+func newStatsCounters() *statsCounters {
+	return &statsCounters{
+		counts: make(map[string]int64),
+		values: make(map[string]float64),
+	}
+}
+
+func (c *statsCounters) count(name string, count int) {
+	c.Lock()
+	defer c.Unlock()
+	c.counts[name] += int64(count)
+}
+
+func (c *statsCounters) value(name string, value float64) {
+	c.Lock()
+	defer c.Unlock()
+	c.values[name] = value
+}
+
+// StatsSnapshot holds the totals of counted stats and the last value of value
+// stats, by name.
+type StatsSnapshot struct {
+	Counts map[string]int64
+	Values map[string]float64
+}
+
 type StatsRegistry struct {
 	*DebugOutput
-	backend StatsBackend
-	prefix  string
+	backend  StatsBackend
+	counters *statsCounters
+	prefix   string
 }
 
 func (r *StatsRegistry) makeFname(name string) string {
@@ -115,20 +152,46 @@ func (r *StatsRegistry) makeFname(name string) string {
 
 func (r *StatsRegistry) SetPrefix(prefix string) *StatsRegistry {
 	prefix = r.prefix + prefix + " - "
-	return newStatsRegistryWithPrefix(r.DebugOutput.Config(), r.backend, prefix)
+	return newStatsRegistryWithPrefix(r.DebugOutput.Config(), r.backend, r.counters, prefix)
 }
 
 func (r *StatsRegistry) ResetPrefix() *StatsRegistry {
-	return NewStatsRegistryWithBackend(r.DebugOutput.Config(), r.backend)
+	reg := NewStatsRegistryWithBackend(r.DebugOutput.Config(), r.backend)
+	reg.counters = r.counters
+	return reg
+}
+
+// Snapshot returns the stats posted under this registry's prefix since the
+// process started, with the prefix trimmed from their names.
+func (r *StatsRegistry) Snapshot() StatsSnapshot {
+	r.counters.Lock()
+	defer r.counters.Unlock()
+	snapshot := StatsSnapshot{
+		Counts: make(map[string]int64),
+		Values: make(map[string]float64),
+	}
+	for name, count := range r.counters.counts {
+		if strings.HasPrefix(name, r.prefix) {
+			snapshot.Counts[strings.TrimPrefix(name, r.prefix)] = count
+		}
+	}
+	for name, value := range r.counters.values {
+		if strings.HasPrefix(name, r.prefix) {
+			snapshot.Values[strings.TrimPrefix(name, r.prefix)] = value
+		}
+	}
+	return snapshot
 }
 
 func (r *StatsRegistry) Count(name string) {
+	r.counters.count(r.makeFname(name), 1)
 	if err := r.backend.Count(r.makeFname(name)); err != nil {
 		r.Errorf("failed to post stat: err: %s name: %s", err, name)
 	}
 }
 
 func (r *StatsRegistry) CountMult(name string, count int) {
+	r.counters.count(r.makeFname(name), count)
 	if err := r.backend.CountMult(r.makeFname(name), count); err != nil {
 		r.Errorf("failed to post stat: err: %s name: %s", err, name)
 	}
@@ -139,6 +202,7 @@ func (r *StatsRegistry) ValueInt(name string, value int) {
 }
 
 func (r *StatsRegistry) Value(name string, value float64) {
+	r.counters.value(r.makeFname(name), value)
 	if err := r.backend.Value(r.makeFname(name), value); err != nil {
 		r.Errorf("failed to post stat: err: %s name: %s", err, name)
 	}
@@ -153,13 +217,16 @@ func NewStatsRegistryWithBackend(debugConfig *ChatDebugOutputConfig, backend Sta
 	return &StatsRegistry{
 		DebugOutput: NewDebugOutput("StatsRegistry", debugConfig),
 		backend:     backend,
+		counters:    newStatsCounters(),
 	}
 }
 
-func newStatsRegistryWithPrefix(debugConfig *ChatDebugOutputConfig, backend StatsBackend, prefix string) *StatsRegistry {
+func newStatsRegistryWithPrefix(debugConfig *ChatDebugOutputConfig, backend StatsBackend,
+	counters *statsCounters, prefix string) *StatsRegistry {
 	return &StatsRegistry{
 		DebugOutput: NewDebugOutput("StatsRegistry - "+prefix, debugConfig),
 		backend:     backend,
+		counters:    counters,
 		prefix:      prefix,
 	}
 }
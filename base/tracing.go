@@ -0,0 +1,143 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/keybase/managed-bots/base"
+
+const (
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+)
+
+type TracingOptions struct {
+	// Exporter is where spans are sent, either "otlp" or "stdout"
+	Exporter string
+	// OTLPEndpoint is the OTLP/HTTP collector URL, if empty the standard
+	// OTEL_EXPORTER_OTLP_* environment variables are used
+	OTLPEndpoint string
+}
+
+func (o *TracingOptions) IsEmpty() bool {
+	return o == nil || o.Exporter == ""
+}
+
+var tracing struct {
+	sync.Mutex
+	provider *sdktrace.TracerProvider
+}
+
+// InitTracing installs the global tracer provider. Spans are only recorded
+// once this has been called with a non-empty TracingOptions, otherwise they
+// are no-ops. When several bots run in one process the first one to start
+// sets up the provider and the rest share it.
+func InitTracing(serviceName string, opts *TracingOptions) error {
+	if opts.IsEmpty() {
+		return nil
+	}
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.provider != nil {
+		return nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case TraceExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		if opts.OTLPEndpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(opts.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(context.Background(), exporterOpts...)
+	case TraceExporterStdout:
+		exporter, err = stdouttrace.New()
+	default:
+		return fmt.Errorf("unknown trace exporter %q, must be %q or %q",
+			opts.Exporter, TraceExporterOTLP, TraceExporterStdout)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create %s trace exporter: %s", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return err
+	}
+	tracing.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracing.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// ShutdownTracing flushes any buffered spans to the exporter.
+func ShutdownTracing() error {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.provider == nil {
+		return nil
+	}
+	err := tracing.provider.Shutdown(context.Background())
+	tracing.provider = nil
+	return err
+}
+
+// StartSpan starts a span as a child of any span in ctx. The returned func
+// ends it, recording *err if it is set, and is meant to be deferred:
+//
+//	ctx, end := StartSpan(ctx, "UpdateChannel")
+//	defer end(&err)
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(*error)) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err *error) {
+		if err != nil && *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// startChildSpan is StartSpan for work which is only worth tracing as part of
+// something else, it doesn't start a span if ctx has none.
+func startChildSpan(ctx context.Context, name string) (context.Context, func(*error)) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, func(*error) {}
+	}
+	return StartSpan(ctx, name)
+}
+
+// traceHandler wraps h so each request gets a server span, which handlers can
+// pick up from the request's context.
+func traceHandler(h http.Handler) http.Handler {
+	return otelhttp.NewHandler(h, "HTTPSrv",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+		}))
+}
+
+// traceTransport wraps rt so each outbound request gets a client span.
+func traceTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
-	"time"
 
 	"github.com/kballard/go-shellquote"
 
@@ -114,28 +113,6 @@ func EmojiToNumber(s string) int {
 	}
 }
 
-func HandleNewTeam(stats *StatsRegistry, log *DebugOutput, kbc *kbchat.API, conv chat1.ConvSummary, welcomeMsg string) error {
-	if conv.Channel.MembersType == "team" && !conv.IsDefaultConv {
-		log.Debug("HandleNewTeam: skipping conversation %+v, not default team conv", conv)
-		stats.Count("HandleNewTeam - skipped new conv")
-		return nil
-	} else if conv.CreatorInfo != nil && conv.CreatorInfo.Username == kbc.GetUsername() {
-		log.Debug("HandleNewTeam: skipping conversation %+v, bot created conversation", conv)
-		stats.Count("HandleNewTeam - skipped new conv")
-		return nil
-	}
-	// Delay for a short time in case there is an ephemeral policy on this
-	// conversation and the bot is not yet keyed.
-	go func() {
-		time.Sleep(time.Second)
-		stats.Count("HandleNewTeam - new conv")
-		if _, err := kbc.SendMessageByConvID(conv.Id, "%s", welcomeMsg); err != nil {
-			log.Errorf("unable to HandleNewTeam: %v", err)
-		}
-	}()
-	return nil
-}
-
 func IsAtLeastWriter(kbc *kbchat.API, senderUsername string, channel chat1.ChatChannel) (bool, error) {
 	switch channel.MembersType {
 	case "team": // make sure the member is an admin or owner
@@ -309,6 +286,24 @@ func SplitTokens(cmd string) (tokens []string, userErrorMessage string, err erro
 	}
 }
 
+func IsAtLeastAdmin(kbc *kbchat.API, senderUsername string, channel chat1.ChatChannel) (bool, error) {
+	switch channel.MembersType {
+	case "team": // make sure the member is an admin or owner
+	default: // authorization is per user so let anything through
+		return true, nil
+	}
+	res, err := kbc.ListMembersOfTeam(channel.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range append(res.Owners, res.Admins...) {
+		if member.Username == senderUsername {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func IsDirectPrivateMessage(botUsername, senderUsername string, channel chat1.ChatChannel) bool {
 	if channel.MembersType == "team" {
 		return false
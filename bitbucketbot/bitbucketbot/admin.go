@@ -0,0 +1,11 @@
+package bitbucketbot
+
+import (
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+func init() {
+	base.RegisterAdminTables("bitbucketbot", git.WebhookAdminTables()...)
+	base.RegisterAdminTables("bitbucketbot", base.OnboardingAdminTable("bitbucketbot"))
+}
@@ -0,0 +1,428 @@
+package bitbucketbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/keybase/managed-bots/base/git"
+)
+
+/*
+Bitbucket Cloud webhooks:
+https://support.atlassian.com/bitbucket-cloud/docs/event-payloads/
+Bitbucket Pipelines report their results as build statuses.
+
+Bitbucket Server (and Data Center) webhooks:
+https://confluence.atlassian.com/bitbucketserver/event-payload-938025882.html
+Server pushes don't include the pushed commits, branch updates are announced
+with the commit the branch now points at.
+
+Only the fields the bot uses are decoded.
+*/
+
+type link struct {
+	Href string `json:"href"`
+}
+
+type cloudUser struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+func (u *cloudUser) name() string {
+	if u == nil {
+		return ""
+	}
+	if u.Nickname != "" {
+		return u.Nickname
+	}
+	return u.DisplayName
+}
+
+type cloudPullRequest struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Author      *cloudUser `json:"author"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	Links struct {
+		HTML link `json:"html"`
+	} `json:"links"`
+}
+
+type cloudIssue struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Links struct {
+		HTML link `json:"html"`
+	} `json:"links"`
+}
+
+type cloudPayload struct {
+	Actor      *cloudUser `json:"actor"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+		Links    struct {
+			HTML link `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+	Push *struct {
+		Changes []struct {
+			New *struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			} `json:"new"`
+			Old *struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			} `json:"old"`
+			// Commits are newest first.
+			Commits []struct {
+				Message string `json:"message"`
+				Links   struct {
+					HTML link `json:"html"`
+				} `json:"links"`
+			} `json:"commits"`
+			Links struct {
+				HTML link `json:"html"`
+			} `json:"links"`
+		} `json:"changes"`
+	} `json:"push"`
+	PullRequest *cloudPullRequest `json:"pullrequest"`
+	Issue       *cloudIssue       `json:"issue"`
+	Changes     *struct {
+		Status *struct {
+			Old string `json:"old"`
+			New string `json:"new"`
+		} `json:"status"`
+	} `json:"changes"`
+	Comment *struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+		User  *cloudUser `json:"user"`
+		Links struct {
+			HTML link `json:"html"`
+		} `json:"links"`
+	} `json:"comment"`
+	CommitStatus *struct {
+		Name    string `json:"name"`
+		State   string `json:"state"`
+		URL     string `json:"url"`
+		RefName string `json:"refname"`
+	} `json:"commit_status"`
+}
+
+type serverUser struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func (u *serverUser) name() string {
+	if u == nil {
+		return ""
+	}
+	if u.Slug != "" {
+		return u.Slug
+	}
+	return u.Name
+}
+
+type serverRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Self []link `json:"self"`
+	} `json:"links"`
+}
+
+type serverPayload struct {
+	Actor      *serverUser       `json:"actor"`
+	Repository *serverRepository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			DisplayID string `json:"displayId"`
+			Type      string `json:"type"`
+		} `json:"ref"`
+		ToHash string `json:"toHash"`
+		Type   string `json:"type"`
+	} `json:"changes"`
+	PullRequest *struct {
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
+		Author struct {
+			User *serverUser `json:"user"`
+		} `json:"author"`
+		ToRef struct {
+			DisplayID  string            `json:"displayId"`
+			Repository *serverRepository `json:"repository"`
+		} `json:"toRef"`
+		Links struct {
+			Self []link `json:"self"`
+		} `json:"links"`
+	} `json:"pullRequest"`
+	Comment *struct {
+		Text   string      `json:"text"`
+		Author *serverUser `json:"author"`
+	} `json:"comment"`
+}
+
+// parseEvent translates the webhook payload of the given X-Event-Key, it
+// returns nil for events that aren't supported.
+func parseEvent(eventKey string, data []byte) (res *git.Event, err error) {
+	switch {
+	case strings.HasPrefix(eventKey, "pr:"), eventKey == "repo:refs_changed":
+		var p serverPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("could not parse %s webhook: %s", eventKey, err)
+		}
+		res = parseServerEvent(eventKey, &p)
+	default:
+		var p cloudPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("could not parse %s webhook: %s", eventKey, err)
+		}
+		res = parseCloudEvent(eventKey, &p)
+	}
+	if res == nil || res.Repo == "" {
+		return nil, nil
+	}
+	res.Provider = git.BITBUCKET
+	return res, nil
+}
+
+func parseCloudEvent(eventKey string, p *cloudPayload) *git.Event {
+	if p.Repository == nil {
+		return nil
+	}
+	_, repo, err := parseRepoInput(p.Repository.Links.HTML.Href)
+	if err != nil {
+		return nil
+	}
+	res := &git.Event{
+		Repo:     repo,
+		RepoName: p.Repository.FullName,
+		Author:   p.Actor.name(),
+	}
+	switch eventKey {
+	case "repo:push":
+		if p.Push == nil {
+			return nil
+		}
+		// a push can update several refs, only the first one worth a message
+		// is announced
+		for _, change := range p.Push.Changes {
+			switch {
+			case change.New != nil && change.New.Type == "tag" && change.Old == nil:
+				res.Kind = git.EventTag
+				res.Action = "created"
+				res.Tag = change.New.Name
+				res.URL = fmt.Sprintf("%s/src/%s", p.Repository.Links.HTML.Href, change.New.Name)
+				return res
+			case change.Old != nil && change.Old.Type == "tag" && change.New == nil:
+				res.Kind = git.EventTag
+				res.Action = "deleted"
+				res.Tag = change.Old.Name
+				return res
+			case change.New != nil && change.New.Type == "branch" && len(change.Commits) > 0:
+				res.Kind = git.EventPush
+				res.Branch = change.New.Name
+				for i := len(change.Commits) - 1; i >= 0; i-- {
+					res.Commits = append(res.Commits, change.Commits[i].Message)
+				}
+				res.URL = change.Links.HTML.Href
+				if res.URL == "" || len(change.Commits) == 1 {
+					res.URL = change.Commits[0].Links.HTML.Href
+				}
+				return res
+			}
+		}
+		return nil
+	case "pullrequest:created", "pullrequest:fulfilled", "pullrequest:rejected":
+		if p.PullRequest == nil {
+			return nil
+		}
+		res.Kind = git.EventPullRequest
+		res.Action = map[string]string{
+			"pullrequest:created":   "opened",
+			"pullrequest:fulfilled": "merged",
+			"pullrequest:rejected":  "closed",
+		}[eventKey]
+		if res.Action == "opened" {
+			res.Author = p.PullRequest.Author.name()
+		}
+		res.Number = p.PullRequest.ID
+		res.Title = p.PullRequest.Title
+		res.TargetBranch = p.PullRequest.Destination.Branch.Name
+		res.URL = p.PullRequest.Links.HTML.Href
+	case "issue:created", "issue:updated":
+		if p.Issue == nil {
+			return nil
+		}
+		res.Kind = git.EventIssue
+		res.Action = "opened"
+		if eventKey == "issue:updated" {
+			if p.Changes == nil || p.Changes.Status == nil {
+				return nil
+			}
+			if res.Action = issueStatusAction(p.Changes.Status.Old, p.Changes.Status.New); res.Action == "" {
+				return nil
+			}
+		}
+		res.Number = p.Issue.ID
+		res.Title = p.Issue.Title
+		res.URL = p.Issue.Links.HTML.Href
+	case "pullrequest:comment_created", "issue:comment_created":
+		if p.Comment == nil {
+			return nil
+		}
+		res.Kind = git.EventComment
+		res.Action = "created"
+		res.Author = p.Comment.User.name()
+		res.Body = p.Comment.Content.Raw
+		res.URL = p.Comment.Links.HTML.Href
+		switch {
+		case p.PullRequest != nil:
+			res.IsPullRequest = true
+			res.Number = p.PullRequest.ID
+			res.Title = p.PullRequest.Title
+		case p.Issue != nil:
+			res.Number = p.Issue.ID
+			res.Title = p.Issue.Title
+		default:
+			return nil
+		}
+	case "repo:commit_status_created", "repo:commit_status_updated":
+		if p.CommitStatus == nil || p.CommitStatus.RefName == "" {
+			return nil
+		}
+		res.Kind = git.EventCheck
+		res.Branch = p.CommitStatus.RefName
+		res.CheckName = p.CommitStatus.Name
+		res.CheckState = commitStatusState(p.CommitStatus.State)
+		res.URL = p.CommitStatus.URL
+	default:
+		return nil
+	}
+	return res
+}
+
+func parseServerEvent(eventKey string, p *serverPayload) *git.Event {
+	repository := p.Repository
+	if p.PullRequest != nil {
+		repository = p.PullRequest.ToRef.Repository
+	}
+	if repository == nil || len(repository.Links.Self) == 0 {
+		return nil
+	}
+	_, repo, err := parseRepoInput(repository.Links.Self[0].Href)
+	if err != nil {
+		return nil
+	}
+	res := &git.Event{
+		Repo:     repo,
+		RepoName: fmt.Sprintf("%s/%s", repository.Project.Key, repository.Slug),
+		Author:   p.Actor.name(),
+	}
+	switch eventKey {
+	case "repo:refs_changed":
+		// a push can update several refs, only the first one worth a message
+		// is announced
+		for _, change := range p.Changes {
+			switch {
+			case change.Ref.Type == "TAG" && change.Type == "ADD":
+				res.Kind = git.EventTag
+				res.Action = "created"
+				res.Tag = change.Ref.DisplayID
+				return res
+			case change.Ref.Type == "TAG" && change.Type == "DELETE":
+				res.Kind = git.EventTag
+				res.Action = "deleted"
+				res.Tag = change.Ref.DisplayID
+				return res
+			case change.Ref.Type == "BRANCH" && (change.Type == "ADD" || change.Type == "UPDATE") &&
+				change.ToHash != "":
+				res.Kind = git.EventPush
+				res.Branch = change.Ref.DisplayID
+				res.Commit = change.ToHash
+				res.URL = fmt.Sprintf("%s/commits/%s",
+					strings.TrimSuffix(repository.Links.Self[0].Href, "/browse"), change.ToHash)
+				return res
+			}
+		}
+		return nil
+	case "pr:opened", "pr:merged", "pr:declined", "pr:comment:added":
+		if p.PullRequest == nil {
+			return nil
+		}
+		res.Number = p.PullRequest.ID
+		res.Title = p.PullRequest.Title
+		res.TargetBranch = p.PullRequest.ToRef.DisplayID
+		if len(p.PullRequest.Links.Self) > 0 {
+			res.URL = p.PullRequest.Links.Self[0].Href
+		}
+		res.Kind = git.EventPullRequest
+		switch eventKey {
+		case "pr:opened":
+			res.Action = "opened"
+			res.Author = p.PullRequest.Author.User.name()
+		case "pr:merged":
+			res.Action = "merged"
+		case "pr:declined":
+			res.Action = "closed"
+		case "pr:comment:added":
+			if p.Comment == nil {
+				return nil
+			}
+			res.Kind = git.EventComment
+			res.Action = "created"
+			res.IsPullRequest = true
+			res.Author = p.Comment.Author.name()
+			res.Body = p.Comment.Text
+		}
+	default:
+		return nil
+	}
+	return res
+}
+
+// issueStatusAction translates an issue's status change, it returns an
+// empty action for the ones not worth a message.
+func issueStatusAction(oldStatus, newStatus string) string {
+	isClosed := func(status string) bool {
+		switch status {
+		case "resolved", "closed", "invalid", "duplicate", "wontfix":
+			return true
+		}
+		return false
+	}
+	switch {
+	case isClosed(newStatus) && !isClosed(oldStatus):
+		return "closed"
+	case !isClosed(newStatus) && isClosed(oldStatus):
+		return "reopened"
+	default:
+		return ""
+	}
+}
+
+// commitStatusState translates build statuses, it returns an empty string
+// for the ones not worth a message.
+func commitStatusState(state string) string {
+	switch state {
+	case "SUCCESSFUL":
+		return "success"
+	case "FAILED":
+		return "failure"
+	case "STOPPED":
+		return "cancelled"
+	default:
+		return ""
+	}
+}
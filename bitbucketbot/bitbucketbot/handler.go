@@ -0,0 +1,20 @@
+package bitbucketbot
+
+import (
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// NewHandler handles the `!bitbucket` commands.
+func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
+	db *git.WebhookDB, httpPrefix string, secret string) *git.WebhookHandler {
+	return git.NewWebhookHandler(stats, kbc, debugConfig, db, git.WebhookProvider{
+		Name:              "bitbucketbot",
+		Cmd:               "bitbucket",
+		Welcome:           "Hi! I can notify you whenever something happens on a Bitbucket repository. To get started, set up a repository by sending `!bitbucket subscribe <workspace/repo>`",
+		RepoUsage:         "`<workspace/repo>` or `https://domain.com/projects/PROJECT/repos/repo`",
+		ParseRepo:         parseRepoInput,
+		SetupInstructions: formatSetupInstructions,
+	}, httpPrefix, secret)
+}
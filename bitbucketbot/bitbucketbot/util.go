@@ -0,0 +1,111 @@
+package bitbucketbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+
+	"github.com/keybase/managed-bots/base"
+)
+
+const cloudHost = "bitbucket.org"
+
+var repoRegex = regexp.MustCompile(`^~?[a-zA-Z0-9_\.-]+$`)
+
+func formatSetupInstructions(repo string, repoURL string, msg chat1.MsgSummary, httpAddress string, secret string) (res string) {
+	back := "`"
+	settingsURL := repoURL + "/settings"
+	if isCloud(repo) {
+		settingsURL = repoURL + "/admin/webhooks"
+	}
+	message := fmt.Sprintf(`
+To configure your repository to send notifications, go to %s and add a new webhook.
+For “URL”, enter %s%s/bitbucketbot/webhook%s.
+For “Secret”, enter %s%s%s.
+Remember to check all the triggers you would like me to update you on.
+Note that I currently support the following Webhook Events: Push, Pull request created, merged, declined and comment created, Issue created, updated and comment created, Build status created and updated (which is how Bitbucket Pipelines report)
+
+Happy coding!`,
+		settingsURL, back, httpAddress, back, back, base.MakeSecret(repo, msg.ConvID, secret), back)
+	return message
+}
+
+func isCloud(repo string) bool {
+	return strings.HasPrefix(repo, cloudHost+"/")
+}
+
+// parseRepoInput parses a Bitbucket Cloud `<workspace/repo>` or repository
+// URL, or a Bitbucket Server repository or clone URL such as
+// `https://domain.com/projects/PROJECT/repos/repo`. repo includes the domain
+// to tell the instances apart.
+func parseRepoInput(input string) (repoURL string, repo string, err error) {
+	input = strings.TrimSuffix(strings.TrimSuffix(input, "/"), ".git")
+	if !strings.Contains(input, "://") {
+		if strings.Count(input, "/") == 1 {
+			input = cloudHost + "/" + input
+		}
+		input = "https://" + input
+	}
+	parsedURL, err := url.ParseRequestURI(input)
+	if err != nil || parsedURL.Host == "" {
+		return "", "", fmt.Errorf("invalid repository URL")
+	}
+	host := parsedURL.Scheme + "://" + parsedURL.Host
+
+	var owner, name string
+	parts := strings.Split(strings.TrimPrefix(parsedURL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parsedURL.Host == cloudHost:
+		owner, name = parts[0], parts[1]
+		repoURL = fmt.Sprintf("%s/%s/%s", host, owner, name)
+	case len(parts) >= 4 && parts[0] == "projects" && parts[2] == "repos":
+		owner, name = parts[1], parts[3]
+	case len(parts) >= 4 && parts[0] == "users" && parts[2] == "repos":
+		owner, name = "~"+parts[1], parts[3]
+	case len(parts) == 3 && parts[0] == "scm":
+		owner, name = parts[1], parts[2]
+	default:
+		return "", "", fmt.Errorf("invalid repository URL")
+	}
+	if !isValidArgs([]string{owner, name}) {
+		return "", "", fmt.Errorf("invalid repository URL")
+	}
+	if repoURL == "" {
+		if strings.HasPrefix(owner, "~") {
+			repoURL = fmt.Sprintf("%s/users/%s/repos/%s", host, owner[1:], name)
+		} else {
+			repoURL = fmt.Sprintf("%s/projects/%s/repos/%s", host, owner, name)
+		}
+	}
+	return repoURL, strings.ToLower(fmt.Sprintf("%s/%s/%s", parsedURL.Host, owner, name)), nil
+}
+
+func isValidArgs(args []string) bool {
+	for _, arg := range args {
+		if !repoRegex.MatchString(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSignature checks the `sha256=` prefixed and hex encoded
+// HMAC-SHA256 of the payload Bitbucket signs with the webhook's secret.
+func validateSignature(signature string, payload []byte, secret string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,137 @@
+package bitbucketbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keybase/managed-bots/base/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoInput(t *testing.T) {
+	cases := []struct {
+		input   string
+		repoURL string
+		repo    string
+	}{
+		{"keybase/client", "https://bitbucket.org/keybase/client", "bitbucket.org/keybase/client"},
+		{"https://bitbucket.org/Keybase/Client.git", "https://bitbucket.org/Keybase/Client", "bitbucket.org/keybase/client"},
+		{"https://mywebsite.com/projects/KB/repos/client/browse", "https://mywebsite.com/projects/KB/repos/client",
+			"mywebsite.com/kb/client"},
+		{"https://mywebsite.com/scm/kb/client.git", "https://mywebsite.com/projects/kb/repos/client", "mywebsite.com/kb/client"},
+		{"https://mywebsite.com/users/alice/repos/dotfiles", "https://mywebsite.com/users/alice/repos/dotfiles",
+			"mywebsite.com/~alice/dotfiles"},
+	}
+	for _, c := range cases {
+		repoURL, repo, err := parseRepoInput(c.input)
+		require.NoError(t, err, c.input)
+		require.Equal(t, c.repoURL, repoURL, c.input)
+		require.Equal(t, c.repo, repo, c.input)
+	}
+
+	for _, input := range []string{"keybase", "https://bitbucket.org/keybase", "https://mywebsite.com/projects/KB",
+		"https://bitbucket.org/keybase/client/src/main"} {
+		_, _, err := parseRepoInput(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"push":{}}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(payload)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	require.True(t, validateSignature(signature, payload, "secret"))
+	require.False(t, validateSignature(signature, payload, "other secret"))
+	require.False(t, validateSignature(signature[len("sha256="):], payload, "secret"))
+	require.False(t, validateSignature("", payload, "secret"))
+}
+
+func TestParseEvent(t *testing.T) {
+	cases := []struct {
+		eventKey string
+		fixture  string
+		repo     string
+		output   string
+	}{
+		{
+			eventKey: "repo:push",
+			fixture:  "cloud_push.json",
+			repo:     "bitbucket.org/keybase/client",
+			output: "alice pushed 2 commits to keybase/client/main:\n- `Update the README`\n- `Fix the build on Windows`\n\n" +
+				"bitbucket.org/keybase/client/branches/compare/bffeb74224043ba2feb48d137756c8a9331c449a..28e1879d029cb852e4844d9c718537df08844e03",
+		},
+		{
+			eventKey: "pullrequest:fulfilled",
+			fixture:  "cloud_pullrequest_fulfilled.json",
+			repo:     "bitbucket.org/keybase/client",
+			output:   "alice merged pull request #7 into keybase/client/main.\nhttps://bitbucket.org/keybase/client/pull-requests/7",
+		},
+		{
+			eventKey: "issue:updated",
+			fixture:  "cloud_issue_updated.json",
+			repo:     "bitbucket.org/keybase/client",
+			output:   "alice closed issue #42 on keybase/client.\nhttps://bitbucket.org/keybase/client/issues/42/crash-when-opening-settings",
+		},
+		{
+			eventKey: "pullrequest:comment_created",
+			fixture:  "cloud_pullrequest_comment_created.json",
+			repo:     "bitbucket.org/keybase/client",
+			output: "alice commented on pull request #7 on keybase/client: “Add dark mode”\n" +
+				"> Looks great, could you add a screenshot?\nhttps://bitbucket.org/keybase/client/pull-requests/7/_/diff#comment-412093871",
+		},
+		{
+			eventKey: "repo:commit_status_updated",
+			fixture:  "cloud_commit_status_updated.json",
+			repo:     "bitbucket.org/keybase/client",
+			output: ":x: *Pipeline #118 for main* failed for keybase/client/main.\n" +
+				"bitbucket.org/keybase/client/addon/pipelines/home#!/results/118",
+		},
+		{
+			eventKey: "pr:opened",
+			fixture:  "server_pr_opened.json",
+			repo:     "bitbucket.example.com/kb/client",
+			output:   "bob opened pull request #12 on KB/client: “Add dark mode”\nhttps://bitbucket.example.com/projects/KB/repos/client/pull-requests/12",
+		},
+		{
+			eventKey: "repo:refs_changed",
+			fixture:  "server_refs_changed.json",
+			repo:     "bitbucket.example.com/kb/client",
+			output:   "alice pushed the tag v1.2.0 to KB/client.",
+		},
+		{
+			eventKey: "repo:refs_changed",
+			fixture:  "server_refs_changed_branch.json",
+			repo:     "bitbucket.example.com/kb/client",
+			output: "alice pushed to KB/client/master, which is now at `28e1879`.\n\n" +
+				"bitbucket.example.com/projects/KB/repos/client/commits/28e1879d029cb852e4844d9c718537df08844e03",
+		},
+	}
+	for _, c := range cases {
+		payload, err := os.ReadFile(filepath.Join("testdata", c.fixture))
+		require.NoError(t, err)
+		event, err := parseEvent(c.eventKey, payload)
+		require.NoError(t, err)
+		require.NotNil(t, event, c.fixture)
+		require.Equal(t, c.repo, event.Repo, c.fixture)
+		require.Equal(t, c.output, git.Render(event, nil), c.fixture)
+	}
+
+	// edits which don't change the status aren't announced
+	event, err := parseEvent("issue:updated", []byte(`{"issue":{"id":1},"changes":{"title":{}},
+		"repository":{"full_name":"keybase/client","links":{"html":{"href":"https://bitbucket.org/keybase/client"}}}}`))
+	require.NoError(t, err)
+	require.Nil(t, event)
+
+	// neither are deleted branches
+	event, err = parseEvent("repo:refs_changed", []byte(`{"actor":{"slug":"alice"},
+		"repository":{"slug":"client","project":{"key":"KB"},"links":{"self":[{"href":"https://bitbucket.example.com/projects/KB/repos/client/browse"}]}},
+		"changes":[{"ref":{"displayId":"old","type":"BRANCH"},"toHash":"0000000000000000000000000000000000000000","type":"DELETE"}]}`))
+	require.NoError(t, err)
+	require.Nil(t, event)
+}
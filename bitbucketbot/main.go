@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/keybase/managed-bots/bitbucketbot/bitbucketbot"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+	"golang.org/x/sync/errgroup"
+)
+
+type Options struct {
+	*base.Options
+	HTTPPrefix        string
+	WebhookSecret     string
+	OAuthClientID     string
+	OAuthClientSecret string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Options: base.NewOptions(),
+	}
+}
+
+type BotServer struct {
+	*base.Server
+
+	opts Options
+	kbc  *kbchat.API
+}
+
+func NewBotServer(opts Options) *BotServer {
+	return &BotServer{
+		Server: base.NewServer("bitbucketbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+		}),
+		opts: opts,
+	}
+}
+
+const backs = "```"
+
+func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	features := strings.Join(git.FeatureNames(), ", ")
+	subExtended := fmt.Sprintf(`Enables posting updates from the provided Bitbucket Cloud or Server repository to this conversation.
+
+Running this command with a branch limits pushes and builds to the branches you subscribe to, branches can be patterns such as %srelease/*%s. Running it with an event type limits the events to the types you subscribe to.
+
+Event type must be one of %s%s%s
+
+Example:%s
+!bitbucket subscribe atlassian/python-bitbucket
+!bitbucket subscribe atlassian/python-bitbucket pulls
+!bitbucket subscribe atlassian/python-bitbucket master%s
+
+Subscribe to a Bitbucket Server repository:%s
+!bitbucket subscribe https://mywebsite.com/projects/PROJECT/repos/repo%s`,
+		"`", "`", backs, features, backs, backs, backs, backs, backs)
+
+	unsubExtended := fmt.Sprintf(`Disables updates from the provided Bitbucket repository to this conversation.
+
+Running this command without a branch or event type will unsubscribe you from all events on the specified repository.
+
+Event type must be one of %s%s%s
+
+Example:%s
+!bitbucket unsubscribe atlassian/python-bitbucket
+!bitbucket unsubscribe atlassian/python-bitbucket commits%s`,
+		backs, features, backs, backs, backs)
+
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "bitbucket subscribe",
+			Description: "Enable updates from Bitbucket repositories",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!bitbucket subscribe* <workspace/repo> [branch or event type]`,
+				DesktopBody: subExtended,
+				MobileBody:  subExtended,
+			},
+		},
+		{
+			Name:        "bitbucket unsubscribe",
+			Description: "Disable updates from Bitbucket repositories",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!bitbucket unsubscribe* <workspace/repo> [branch or event type]`,
+				DesktopBody: unsubExtended,
+				MobileBody:  unsubExtended,
+			},
+		},
+		{
+			Name:        "bitbucket list",
+			Description: "Lists all your repository subscriptions",
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("bitbucket", false)...)
+	return kbchat.Advertisement{
+		Alias: "Bitbucket",
+		Advertisements: []chat1.AdvertiseCommandAPIParam{
+			{
+				Typ:      "public",
+				Commands: cmds,
+			},
+		},
+	}
+}
+
+func (s *BotServer) getConfig() (webhookSecret string, err error) {
+	if s.opts.WebhookSecret != "" {
+		return s.opts.WebhookSecret, nil
+	}
+	path := fmt.Sprintf("/keybase/private/%s/credentials.json", s.kbc.GetUsername())
+	cmd := s.opts.Command("fs", "read", path)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	s.Debug("Running `keybase fs read` on %q and waiting for it to finish...\n", path)
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	var j struct {
+		WebhookSecret string `json:"webhook_secret"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &j); err != nil {
+		return "", err
+	}
+
+	return j.WebhookSecret, nil
+}
+
+func (s *BotServer) Go() (err error) {
+	if s.kbc, err = s.Start(s.opts.ErrReportConv); err != nil {
+		return err
+	}
+
+	sdb, err := sql.Open("mysql", s.opts.DSN)
+	if err != nil {
+		s.Errorf("failed to connect to MySQL: %s", err)
+		return err
+	}
+	defer sdb.Close()
+	db := git.NewWebhookDB(sdb)
+
+	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
+	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
+	if err != nil {
+		s.Debug("unable to create stats: %v", err)
+		return err
+	}
+	secret, err := s.getConfig()
+	if err != nil {
+		s.Errorf("failed to get configuration: %s", err)
+		return err
+	}
+	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	handler := bitbucketbot.NewHandler(stats, s.kbc, debugConfig, db, s.opts.HTTPPrefix, secret)
+	httpSrv := bitbucketbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, handler, secret)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
+	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
+	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
+	s.GoWithRecover(eg, httpSrv.Listen)
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats, cleaner) })
+	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
+	if err := eg.Wait(); err != nil {
+		s.Debug("wait error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	opts := NewOptions()
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&opts.HTTPPrefix, "http-prefix", os.Getenv("BOT_HTTP_PREFIX"), "address of bots HTTP server for webhooks")
+	fs.StringVar(&opts.WebhookSecret, "secret", os.Getenv("BOT_WEBHOOK_SECRET"), "Webhook secret")
+	if err := opts.Parse(fs, os.Args); err != nil {
+		return 3
+	}
+	if len(opts.DSN) == 0 {
+		fmt.Printf("must specify a database DSN\n")
+		return 3
+	}
+	bs := NewBotServer(*opts)
+	if err := bs.Go(); err != nil {
+		fmt.Printf("error running chat loop: %v\n", err)
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	_ "github.com/keybase/managed-bots/bitbucketbot/bitbucketbot"
+	_ "github.com/keybase/managed-bots/elastiwatch/elastiwatch"
+	_ "github.com/keybase/managed-bots/gcalbot/gcalbot"
+	_ "github.com/keybase/managed-bots/giteabot/giteabot"
+	_ "github.com/keybase/managed-bots/githubbot/githubbot"
+	_ "github.com/keybase/managed-bots/gitlabbot/gitlabbot"
+	_ "github.com/keybase/managed-bots/macrobot/macrobot"
+	_ "github.com/keybase/managed-bots/meetbot/meetbot"
+	_ "github.com/keybase/managed-bots/pollbot/pollbot"
+	_ "github.com/keybase/managed-bots/triviabot/triviabot"
+	_ "github.com/keybase/managed-bots/webhookbot/webhookbot"
+	_ "github.com/keybase/managed-bots/zoombot/zoombot"
+)
+
+const usage = `usage: botadmin --bot <bot> [--dsn <dsn>] <command> [args]
+
+commands:
+  tables                              list the bot's tables
+  list <table> [column=value ...]     list rows, conv=<id> matches the table's conversation column
+  inspect <conv_id>                   list every row belonging to a conversation
+  move <table|all> <from> <to>        move a conversation's rows to another conversation
+  delete <table> column=value ...     delete the matching rows
+  orphans [--archive]                 find (and archive) rows of conversations which no longer exist,
+                                      using the keybase service given by --keybase and --home
+  restore <conv_id>                   put back a conversation's rows archived when it was found to be gone
+`
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	var bot string
+	opts := base.NewOptions()
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&bot, "bot", os.Getenv("BOT_NAME"),
+		fmt.Sprintf("Bot whose state to manage, from: %s", strings.Join(base.RegisteredAdminBots(), ", ")))
+	if err := opts.Parse(fs, os.Args); err != nil {
+		fmt.Printf("Unable to parse options: %v\n", err)
+		return 3
+	}
+	tables := base.RegisteredAdminTables(bot)
+	if len(tables) == 0 {
+		fmt.Printf("unknown bot %q, have: %s\n", bot, strings.Join(base.RegisteredAdminBots(), ", "))
+		return 3
+	}
+	if opts.DSN == "" {
+		fmt.Printf("must specify a database DSN\n")
+		return 3
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return 3
+	}
+
+	sdb, err := sql.Open("mysql", opts.DSN)
+	if err != nil {
+		fmt.Printf("failed to connect to MySQL: %s\n", err)
+		return 3
+	}
+	defer sdb.Close()
+	admin := base.NewAdmin(base.NewDB(sdb), bot)
+	if err := runCommand(admin, opts, args[0], args[1:]); err != nil {
+		fmt.Printf("%s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func printRows(rows []base.AdminRow) {
+	for _, row := range rows {
+		fmt.Println(row)
+	}
+	fmt.Printf("%d rows\n", len(rows))
+}
+
+func runCommand(admin *base.Admin, opts *base.Options, cmd string, args []string) error {
+	switch cmd {
+	case "tables":
+		for _, table := range admin.Tables() {
+			conv := table.ConvColumn
+			if conv == "" {
+				conv = "-"
+			}
+			fmt.Printf("%s conv=%s keys=%s\n", table.Name, conv, strings.Join(table.KeyColumns, ","))
+		}
+		return nil
+	case "list":
+		if len(args) < 1 {
+			return fmt.Errorf("must specify a table")
+		}
+		filter, err := base.ParseAdminFilter(args[1:])
+		if err != nil {
+			return err
+		}
+		rows, err := admin.List(args[0], filter)
+		if err != nil {
+			return err
+		}
+		printRows(rows)
+		return nil
+	case "inspect":
+		if len(args) != 1 {
+			return fmt.Errorf("must specify a conversation ID")
+		}
+		rows, err := admin.Inspect(chat1.ConvIDStr(args[0]))
+		if err != nil {
+			return err
+		}
+		printRows(rows)
+		return nil
+	case "move":
+		if len(args) != 3 {
+			return fmt.Errorf("must specify a table (or all), and the conversation IDs to move from and to")
+		}
+		table := args[0]
+		if table == "all" {
+			table = ""
+		}
+		moved, err := admin.Move(table, chat1.ConvIDStr(args[1]), chat1.ConvIDStr(args[2]))
+		if err != nil {
+			return err
+		}
+		var names []string
+		for name := range moved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s: moved %d rows\n", name, moved[name])
+		}
+		return nil
+	case "delete":
+		if len(args) < 2 {
+			return fmt.Errorf("must specify a table and column=value filters")
+		}
+		filter, err := base.ParseAdminFilter(args[1:])
+		if err != nil {
+			return err
+		}
+		deleted, err := admin.Delete(args[0], filter)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: deleted %d rows\n", args[0], deleted)
+		return nil
+	case "orphans":
+		var doArchive bool
+		fs := flag.NewFlagSet("orphans", flag.ContinueOnError)
+		fs.BoolVar(&doArchive, "archive", false, "Move the orphaned rows to conv_archive")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		kbc, err := kbchat.Start(kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to start keybase: %s", err)
+		}
+		defer kbc.Shutdown()
+		orphans, err := admin.Orphans(kbc)
+		if err != nil {
+			return err
+		}
+		for _, orphan := range orphans {
+			var counts []string
+			for table, count := range orphan.Rows {
+				counts = append(counts, fmt.Sprintf("%s=%d", table, count))
+			}
+			sort.Strings(counts)
+			fmt.Printf("%s %s\n", orphan.ConvID, strings.Join(counts, " "))
+			if !doArchive {
+				continue
+			}
+			archived, err := admin.ArchiveConv(orphan.ConvID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: archived %d rows\n", orphan.ConvID, archived)
+		}
+		fmt.Printf("%d orphaned conversations\n", len(orphans))
+		return nil
+	case "restore":
+		if len(args) != 1 {
+			return fmt.Errorf("must specify a conversation ID")
+		}
+		restored, err := admin.RestoreConv(chat1.ConvIDStr(args[0]))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored %d rows\n", restored)
+		return nil
+	default:
+		return fmt.Errorf("unknown command, run with --help for usage")
+	}
+}
@@ -1,6 +1,7 @@
 package canarybot
 
 import (
+	"context"
 	"strings"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
@@ -8,11 +9,14 @@ import (
 	"github.com/keybase/managed-bots/base"
 )
 
+const welcomeMsg = "Hey there I'm canarybot. Seems like I'm alive because you're getting this message. Happy days."
+
 type Handler struct {
 	*base.DebugOutput
 
-	stats *base.StatsRegistry
-	kbc   *kbchat.API
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	onboarding *base.Onboarding
 }
 
 var _ base.Handler = (*Handler)(nil)
@@ -22,6 +26,8 @@ func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.Ch
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
 		kbc:         kbc,
+		// canarybot doesn't have a database, so the welcome can't be customized
+		onboarding: base.NewOnboarding(stats, kbc, debugConfig, nil, "canarybot", "canary", welcomeMsg),
 	}
 }
 
@@ -36,14 +42,16 @@ func (h *Handler) handleEcho(cmd string, msg chat1.MsgSummary) error {
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Hey there I'm canarybot. Seems like I'm alive because you're getting this message. Happy days."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil || !strings.HasPrefix(msg.Content.Text.Body, "!canary") {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	switch {
 	case strings.HasPrefix(cmd, "!canary echo"):
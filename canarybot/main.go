@@ -33,7 +33,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("canarybot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("canarybot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 			NumPipes:        5,
@@ -74,6 +74,7 @@ func (s *BotServer) Go() (err error) {
 	}
 	stats = stats.SetPrefix(s.Name())
 	httpSrv := canarybot.NewHTTPSrv(stats, debugConfig)
+	base.NewDashboard(s.Server, stats, debugConfig, nil, s.opts.DashboardOpts)
 	handler := canarybot.NewHandler(stats, s.kbc, debugConfig)
 	eg := &errgroup.Group{}
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
@@ -0,0 +1,9 @@
+package elastiwatch
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("elastiwatch",
+		base.AdminTable{Name: "deferrals", KeyColumns: []string{"id", "author"}},
+	)
+}
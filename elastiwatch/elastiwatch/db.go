@@ -1,6 +1,7 @@
 package elastiwatch
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -17,8 +18,8 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
-func (d *DB) Create(regex, author string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) Create(ctx context.Context, regex, author string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO deferrals (regex, author, ctime) VALUES (?, ?, NOW())
 		`, regex, author)
@@ -51,8 +52,8 @@ func (d *DB) List() (res []Deferral, err error) {
 	return res, nil
 }
 
-func (d *DB) Remove(id int) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) Remove(ctx context.Context, id int) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM deferrals WHERE id = ?
 		`, id)
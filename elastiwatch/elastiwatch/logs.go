@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -11,8 +12,13 @@ import (
 	"github.com/olivere/elastic"
 )
 
+// QueryJobName is the name of the scheduler job which polls Elasticsearch
+// every minute.
+const QueryJobName = "elastiwatch.query"
+
 type LogWatch struct {
 	*base.DebugOutput
+	sync.Mutex
 	db           *DB
 	cli          *elastic.Client
 	index, email string
@@ -165,11 +171,17 @@ func (l *LogWatch) generateAndSend(entries []*entry) {
 	l.lastSend = time.Now()
 }
 
-func (l *LogWatch) runOnce() {
+// RunJob queries the minute of logs ending at the job's scheduled time, so
+// minutes missed while the bot was down are picked up when it comes back.
+func (l *LogWatch) RunJob(job base.Job) error {
+	return l.runOnce(job.RunAt)
+}
+
+func (l *LogWatch) runOnce(end time.Time) error {
 	query := elastic.NewBoolQuery().
 		Must(elastic.NewRangeQuery("@timestamp").
-			From(time.Now().Add(-time.Minute)).
-			To(time.Now())).
+			From(end.Add(-time.Minute)).
+			To(end)).
 		MustNot(elastic.NewTermQuery("severity", "debug"))
 	res, err := l.cli.Search().
 		Index(l.index).
@@ -179,7 +191,7 @@ func (l *LogWatch) runOnce() {
 		Do(context.Background())
 	if err != nil {
 		l.Debug("failed to run Elasticsearch query: %s", err)
-		return
+		return err
 	}
 
 	var entries []*entry
@@ -197,7 +209,10 @@ func (l *LogWatch) runOnce() {
 		l.Debug("no query hits, doing nothing")
 	}
 
+	l.Lock()
+	defer l.Unlock()
 	l.addAndCheckForSend(entries)
+	return nil
 }
 
 func (l *LogWatch) Run() error {
@@ -208,15 +223,14 @@ func (l *LogWatch) Run() error {
 	if l.emailConvID != "" {
 		l.Debug("email notices into convID: %s", l.emailConvID)
 	}
-	l.runOnce()
 	for {
 		select {
 		case <-l.shutdownCh:
 			return nil
 		case <-l.peekCh:
+			l.Lock()
 			l.peek()
-		case <-time.After(time.Minute):
-			l.runOnce()
+			l.Unlock()
 		}
 	}
 }
@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 
 	"github.com/aws/aws-sdk-go/aws/defaults"
@@ -44,7 +43,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("elastiwatch", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("elastiwatch", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -120,23 +119,27 @@ func (s *BotServer) Go() (err error) {
 	db := elastiwatch.NewDB(sdb)
 	s.Debug("Connect to Elasticsearch at %s", s.opts.ESAddress)
 	var emailer base.Emailer
-	var httpClient *http.Client
 	emailer = base.DummyEmailer{}
 	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
 	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
 	if err != nil {
 		s.Errorf("failed to initialize stats: %s", err)
+		return err
 	}
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	esClient := httpClient.Client()
 	if s.opts.AWSOpts != nil {
 		s.Debug("Using AWS HTTP client: region: %s", s.opts.AWSOpts.AWSRegion)
-		httpClient = elaws.NewV4SigningClient(defaults.Get().Config.Credentials, s.opts.AWSOpts.AWSRegion)
+		// retry outside of the signing so every attempt is signed afresh
+		signingClient := elaws.NewV4SigningClient(defaults.Get().Config.Credentials, s.opts.AWSOpts.AWSRegion)
+		esClient = httpClient.WithTransport(signingClient.Transport)
 		emailer = base.NewSESEmailer(s.opts.SenderEmail, s.opts.AWSOpts.AWSRegion, debugConfig)
 	}
 	cli, err := elastic.NewClient(
 		elastic.SetURL(s.opts.ESAddress),
 		elastic.SetSniff(false),
 		elastic.SetHealthcheck(false),
-		elastic.SetHttpClient(httpClient),
+		elastic.SetHttpClient(esClient),
 	)
 	if err != nil {
 		s.Errorf("unable to connect to Elasticsearch: %s", err)
@@ -146,13 +149,21 @@ func (s *BotServer) Go() (err error) {
 
 	logwatch := elastiwatch.NewLogWatch(cli, db, s.opts.Index, s.opts.Email, emailer, s.opts.AlertConvID,
 		s.opts.EmailConvID, debugConfig)
+	scheduler := base.NewScheduler(stats, s.kbc, debugConfig, db.DB, s.IsLeader, base.DefaultSchedulerOptions())
+	scheduler.Register(elastiwatch.QueryJobName, logwatch.RunJob)
+	if _, err := scheduler.ScheduleCron(elastiwatch.QueryJobName, elastiwatch.QueryJobName, "* * * * *", nil); err != nil {
+		s.Errorf("failed to schedule log query: %s", err)
+		return err
+	}
 	httpSrv := elastiwatch.NewHTTPSrv(stats, s.kbc, debugConfig, db)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	handler := elastiwatch.NewHandler(s.kbc, debugConfig, httpSrv, db, logwatch)
 	eg := &errgroup.Group{}
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
-	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, logwatch, stats) })
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, logwatch, scheduler, stats) })
 	s.GoWithRecover(eg, func() error { return logwatch.Run() })
+	s.GoWithRecover(eg, scheduler.Run)
 	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
 	if err := eg.Wait(); err != nil {
 		s.Debug("wait error: %s", err)
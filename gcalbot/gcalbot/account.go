@@ -11,6 +11,7 @@ import (
 	"google.golang.org/api/googleapi"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/option"
 )
@@ -37,7 +38,7 @@ func (h *Handler) handleAccountsList(msg chat1.MsgSummary) error {
 	return nil
 }
 
-func (h *Handler) handleAccountsConnect(msg chat1.MsgSummary, args []string) error {
+func (h *Handler) handleAccountsConnect(ctx context.Context, msg chat1.MsgSummary, args []string) error {
 	if len(args) != 1 {
 		h.ChatEcho(msg.ConvID, "Invalid number of arguments.")
 		return nil
@@ -54,10 +55,10 @@ func (h *Handler) handleAccountsConnect(msg chat1.MsgSummary, args []string) err
 		return nil
 	}
 
-	return h.requestOAuth(msg, accountNickname)
+	return h.requestOAuth(ctx, msg, accountNickname)
 }
 
-func (h *Handler) handleAccountsDisconnect(msg chat1.MsgSummary, args []string) error {
+func (h *Handler) handleAccountsDisconnect(ctx context.Context, msg chat1.MsgSummary, args []string) error {
 	if len(args) != 1 {
 		h.ChatEcho(msg.ConvID, "Invalid number of arguments.")
 		return nil
@@ -74,7 +75,7 @@ func (h *Handler) handleAccountsDisconnect(msg chat1.MsgSummary, args []string)
 		return nil
 	}
 
-	err = h.deleteAccount(keybaseUsername, accountNickname)
+	err = h.deleteAccount(ctx, keybaseUsername, accountNickname)
 	if err != nil {
 		return err
 	}
@@ -83,13 +84,13 @@ func (h *Handler) handleAccountsDisconnect(msg chat1.MsgSummary, args []string)
 	return nil
 }
 
-func (h *Handler) deleteAccount(keybaseUsername, accountNickname string) error {
+func (h *Handler) deleteAccount(ctx context.Context, keybaseUsername, accountNickname string) error {
 	account, err := h.db.GetAccount(keybaseUsername, accountNickname)
 	if err != nil || account == nil {
 		return fmt.Errorf("error getting account: %s", err)
 	}
 
-	srv, err := GetCalendarService(account, h.oauth, h.db)
+	srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 	if err != nil {
 		return err
 	}
@@ -118,25 +119,28 @@ func (h *Handler) deleteAccount(keybaseUsername, accountNickname string) error {
 	}
 
 	// cascading delete of account, oauth, subscriptions, channels and invites
-	err = h.db.DeleteAccount(keybaseUsername, accountNickname)
+	err = h.db.DeleteAccount(ctx, keybaseUsername, accountNickname)
 
 	return err
 }
 
-func GetCalendarService(account *Account, config *oauth2.Config, db *DB) (srv *calendar.Service, err error) {
+func GetCalendarService(ctx context.Context, account *Account, config *oauth2.Config, db *DB,
+	httpClient *base.HTTPClient) (srv *calendar.Service, err error) {
+	// the service outlives ctx, so its client doesn't use it
+	clientCtx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient.Client())
 	if account.Token.Expiry.Before(time.Now()) {
-		newToken, err := config.TokenSource(context.Background(), &account.Token).Token()
+		newToken, err := config.TokenSource(clientCtx, &account.Token).Token()
 		if err != nil {
 			return nil, err
 		}
 		if newToken.AccessToken != account.Token.AccessToken {
 			account.Token = *newToken
-			err = db.InsertAccount(*account)
+			err = db.InsertAccount(ctx, *account)
 			if err != nil {
 				return nil, fmt.Errorf("unable to update account token: %s", err)
 			}
 		}
 	}
-	client := config.Client(context.Background(), &account.Token)
+	client := config.Client(clientCtx, &account.Token)
 	return calendar.NewService(context.Background(), option.WithHTTPClient(client))
 }
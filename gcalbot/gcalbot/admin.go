@@ -0,0 +1,21 @@
+package gcalbot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	account := []string{"keybase_username", "account_nickname"}
+	calendar := append(account, "calendar_id")
+	base.RegisterAdminTables("gcalbot",
+		base.AdminTable{
+			Name:          "account",
+			KeyColumns:    account,
+			SecretColumns: []string{"access_token", "refresh_token"},
+		},
+		base.AdminTable{Name: "oauth_state", ConvColumn: "keybase_conv_id", KeyColumns: append([]string{"state"}, account...)},
+		base.AdminTable{Name: "channel", KeyColumns: append([]string{"channel_id"}, calendar...)},
+		base.AdminTable{Name: "subscription", ConvColumn: "keybase_conv_id", KeyColumns: append([]string{"type"}, calendar...)},
+		base.AdminTable{Name: "invite", KeyColumns: append([]string{"event_id"}, calendar...)},
+		base.AdminTable{Name: "daily_schedule_subscription", ConvColumn: "keybase_conv_id", KeyColumns: calendar},
+	)
+	base.RegisterAdminTables("gcalbot", base.OnboardingAdminTable("gcalbot"), base.SettingsAdminTable("gcalbot"))
+}
@@ -1,6 +1,7 @@
 package gcalbot
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"time"
@@ -45,8 +46,8 @@ func (d *DB) GetState(state string) (*OAuthRequest, error) {
 	}
 }
 
-func (d *DB) PutState(state string, oauthState OAuthRequest) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) PutState(ctx context.Context, state string, oauthState OAuthRequest) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO oauth_state
 			(state, keybase_username, account_nickname, keybase_conv_id)
@@ -61,8 +62,8 @@ func (d *DB) PutState(state string, oauthState OAuthRequest) error {
 	return err
 }
 
-func (d *DB) CompleteState(state string) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CompleteState(ctx context.Context, state string) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			UPDATE oauth_state
 			SET is_complete = true
@@ -74,8 +75,8 @@ func (d *DB) CompleteState(state string) error {
 }
 
 // Account
-func (d *DB) InsertAccount(account Account) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) InsertAccount(ctx context.Context, account Account) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO account
 			(keybase_username, account_nickname, access_token, token_type, refresh_token, expiry, ctime, mtime)
@@ -112,8 +113,8 @@ func (d *DB) GetAccount(keybaseUsername, accountNickname string) (account *Accou
 	}
 }
 
-func (d *DB) DeleteAccount(keybaseUsername, accountNickname string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteAccount(ctx context.Context, keybaseUsername, accountNickname string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		// remove subscriptions first due to foreign key constraint
 		_, err := tx.Exec(`
 			DELETE FROM subscription
@@ -165,8 +166,8 @@ func (d *DB) GetAccountListForUsername(keybaseUsername string) (accounts []*Acco
 }
 
 // Channel
-func (d *DB) InsertChannel(account *Account, channel Channel) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) InsertChannel(ctx context.Context, account *Account, channel Channel) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO channel
 			(channel_id, keybase_username, account_nickname, calendar_id, resource_id, expiry, next_sync_token)
@@ -177,8 +178,8 @@ func (d *DB) InsertChannel(account *Account, channel Channel) error {
 	})
 }
 
-func (d *DB) UpdateChannel(oldChannelID, newChannelID string, expiry time.Time) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) UpdateChannel(ctx context.Context, oldChannelID, newChannelID string, expiry time.Time) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			UPDATE channel
 			SET channel_id = ?, expiry = ?
@@ -188,8 +189,8 @@ func (d *DB) UpdateChannel(oldChannelID, newChannelID string, expiry time.Time)
 	})
 }
 
-func (d *DB) UpdateChannelNextSyncToken(channelID, nextSyncToken string) error {
-	return d.DB.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) UpdateChannelNextSyncToken(ctx context.Context, channelID, nextSyncToken string) error {
+	return d.DB.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			UPDATE channel
 			SET next_sync_token = ?
@@ -310,8 +311,8 @@ func (d *DB) ExistsChannelByAccountAndCalendar(account *Account, calendarID stri
 	return exists, err
 }
 
-func (d *DB) DeleteChannelByChannelID(channelID string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteChannelByChannelID(ctx context.Context, channelID string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM channel
 			WHERE channel_id = ?
@@ -321,9 +322,9 @@ func (d *DB) DeleteChannelByChannelID(channelID string) error {
 }
 
 // Subscription
-func (d *DB) InsertSubscription(account *Account, subscription Subscription) error {
+func (d *DB) InsertSubscription(ctx context.Context, account *Account, subscription Subscription) error {
 	minutesBefore := GetMinutesFromDuration(subscription.DurationBefore)
-	return d.RunTxn(func(tx *sql.Tx) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO subscription
 			(keybase_username, account_nickname, calendar_id, keybase_conv_id, minutes_before, type)
@@ -437,9 +438,9 @@ func (d *DB) GetSubscriptions(account *Account, calendarID string, keybaseConvID
 	return subscriptions, nil
 }
 
-func (d *DB) DeleteSubscription(account *Account, subscription Subscription) error {
+func (d *DB) DeleteSubscription(ctx context.Context, account *Account, subscription Subscription) error {
 	minutesBefore := GetMinutesFromDuration(subscription.DurationBefore)
-	return d.RunTxn(func(tx *sql.Tx) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM subscription
 			WHERE keybase_username = ? AND account_nickname = ? AND calendar_id = ? AND keybase_conv_id = ? AND
@@ -451,8 +452,8 @@ func (d *DB) DeleteSubscription(account *Account, subscription Subscription) err
 }
 
 // Invite
-func (d *DB) InsertInvite(account *Account, invite Invite) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) InsertInvite(ctx context.Context, account *Account, invite Invite) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO invite
 			(keybase_username, account_nickname, calendar_id, event_id, message_id)
@@ -501,9 +502,9 @@ func (d *DB) GetInviteAndAccountByUserMessage(keybaseUsername string, messageID
 }
 
 // Daily Schedule Subscription
-func (d *DB) InsertDailyScheduleSubscription(account *Account, subscription DailyScheduleSubscription) error {
+func (d *DB) InsertDailyScheduleSubscription(ctx context.Context, account *Account, subscription DailyScheduleSubscription) error {
 	notificationTime := GetTimeStringFromDuration(subscription.NotificationTime)
-	return d.RunTxn(func(tx *sql.Tx) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO daily_schedule_subscription
 			(keybase_username, account_nickname, calendar_id, keybase_conv_id, timezone, days_to_send, schedule_to_send, notification_time)
@@ -595,8 +596,8 @@ func (d *DB) GetDailyScheduleSubscription(
 	}
 }
 
-func (d *DB) DeleteDailyScheduleSubscription(account *Account, calendarID string, keybaseConvID chat1.ConvIDStr) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteDailyScheduleSubscription(ctx context.Context, account *Account, calendarID string, keybaseConvID chat1.ConvIDStr) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM daily_schedule_subscription
 			WHERE keybase_username = ? AND account_nickname = ? AND calendar_id = ? AND keybase_conv_id = ?
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/keybase/managed-bots/base"
 	"google.golang.org/api/calendar/v3"
 )
 
@@ -22,26 +23,27 @@ func FormatEvent(
 	calendarSummary string,
 	timezone *time.Location,
 	format24HourTime bool,
+	locale base.Locale,
 ) (string, error) {
 	message := `%s
-> When: %s%s%s%s
-> Calendar: %s%s
+> %s: %s%s%s%s
+> %s: %s%s
 %s`
 
 	var what string
 	if event.Summary != "" {
-		what = fmt.Sprintf("\n> What: *%s*", event.Summary)
+		what = fmt.Sprintf("\n> %s: *%s*", catalog.T(locale, "event.what"), event.Summary)
 	}
 
 	// TODO(marcel): better date formatting for recurring events
-	when, err := FormatTimeRange(event.Start, event.End, timezone, format24HourTime)
+	when, err := FormatTimeRange(event.Start, event.End, timezone, format24HourTime, locale)
 	if err != nil {
 		return "", err
 	}
 
 	var where string
 	if event.Location != "" {
-		where = fmt.Sprintf("\n> Where: %s", event.Location)
+		where = fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.where"), event.Location)
 	}
 
 	var isOrganizer bool
@@ -59,11 +61,12 @@ func FormatEvent(
 	// don't show organizer for self-organized event
 	if !isOrganizer {
 		if event.Organizer.DisplayName != "" && event.Organizer.Email != "" {
-			organizer = fmt.Sprintf("\n> Organizer: %s <%s>", event.Organizer.DisplayName, event.Organizer.Email)
+			organizer = fmt.Sprintf("\n> %s: %s <%s>", catalog.T(locale, "event.organizer"),
+				event.Organizer.DisplayName, event.Organizer.Email)
 		} else if event.Organizer.DisplayName != "" {
-			organizer = fmt.Sprintf("\n> Organizer: %s", event.Organizer.DisplayName)
+			organizer = fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.organizer"), event.Organizer.DisplayName)
 		} else if event.Organizer.Email != "" {
-			organizer = fmt.Sprintf("\n> Organizer: %s", event.Organizer.Email)
+			organizer = fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.organizer"), event.Organizer.Email)
 		}
 	}
 
@@ -73,14 +76,14 @@ func FormatEvent(
 			uri := strings.TrimPrefix(entryPoint.Uri, "https://")
 			switch entryPoint.EntryPointType {
 			case "video", "more":
-				conferenceData += fmt.Sprintf("\n> Join online: %s", uri)
+				conferenceData += fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.join_online"), uri)
 			case "phone":
-				conferenceData += fmt.Sprintf("\n> Join by phone: %s", entryPoint.Label)
+				conferenceData += fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.join_phone"), entryPoint.Label)
 				if entryPoint.Pin != "" {
-					conferenceData += fmt.Sprintf(" PIN: %s", entryPoint.Pin)
+					conferenceData += fmt.Sprintf(" %s: %s", catalog.T(locale, "event.pin"), entryPoint.Pin)
 				}
 			case "sip":
-				conferenceData += fmt.Sprintf("\n> Join by SIP: %s", entryPoint.Label)
+				conferenceData += fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.join_sip"), entryPoint.Label)
 			}
 		}
 	}
@@ -91,9 +94,9 @@ func FormatEvent(
 		// quote all newlines
 		if strings.Contains(event.Description, "\n") {
 			descriptionBody := strings.ReplaceAll(event.Description, "\n", "\n> > ")
-			description = fmt.Sprintf("\n> Description:\n> > %s", descriptionBody)
+			description = fmt.Sprintf("\n> %s:\n> > %s", catalog.T(locale, "event.description"), descriptionBody)
 		} else {
-			description = fmt.Sprintf("\n> Description: %s", event.Description)
+			description = fmt.Sprintf("\n> %s: %s", catalog.T(locale, "event.description"), event.Description)
 		}
 	}
 
@@ -101,14 +104,20 @@ func FormatEvent(
 	url := strings.TrimPrefix(event.HtmlLink, "https://")
 
 	return fmt.Sprintf(message,
-		what, when, where, conferenceData, organizer, calendarSummary, description, url), nil
+		what, catalog.T(locale, "event.when"), when, where, conferenceData, organizer,
+		catalog.T(locale, "event.calendar"), calendarSummary, description, url), nil
 }
 
 func FormatEventSchedule(
 	events []*calendar.Event,
 	timezone *time.Location,
 	format24HourTime bool,
+	locale base.Locale,
 ) (schedule string, err error) {
+	if len(events) == 0 {
+		return "> " + catalog.T(locale, "schedule.no_events"), nil
+	}
+
 	type eventItem struct {
 		start   time.Time
 		end     time.Time
@@ -148,7 +157,7 @@ func FormatEventSchedule(
 
 	formattedEvents := make([]string, len(events))
 	for index, item := range allDayEvents {
-		formattedEvents[index] = fmt.Sprintf("> All Day *%s*", item)
+		formattedEvents[index] = fmt.Sprintf("> %s *%s*", catalog.T(locale, "schedule.all_day"), item)
 	}
 	for index, item := range eventItems {
 		index += len(allDayEvents)
@@ -1,6 +1,7 @@
 package gcalbot
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -17,10 +18,14 @@ import (
 type Handler struct {
 	*base.DebugOutput
 
-	stats *base.StatsRegistry
-	kbc   *kbchat.API
-	db    *DB
-	oauth *oauth2.Config
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	db         *DB
+	onboarding *base.Onboarding
+	settings   *base.Settings
+	oauth      *oauth2.Config
+
+	httpClient *base.HTTPClient
 
 	reminderScheduler ReminderScheduler
 
@@ -31,12 +36,21 @@ type Handler struct {
 
 var _ base.Handler = (*Handler)(nil)
 
+// NewSettings creates the settings shared by the handler and the schedulers,
+// whose `language` defaults to the one of the user's Google Calendar, see
+// GetLocale.
+func NewSettings(kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB) *base.Settings {
+	return base.NewSettings(kbc, debugConfig, db.DB, "gcalbot", "gcal", base.LanguageSetting(catalog, base.DefaultLocale))
+}
+
 func NewHandler(
 	stats *base.StatsRegistry,
 	kbc *kbchat.API,
 	debugConfig *base.ChatDebugOutputConfig,
 	db *DB,
+	settings *base.Settings,
 	oauth *oauth2.Config,
+	httpClient *base.HTTPClient,
 	reminderScheduler ReminderScheduler,
 	tokenSecret string,
 	httpPrefix string,
@@ -46,26 +60,34 @@ func NewHandler(
 		stats:             stats.SetPrefix("Handler"),
 		kbc:               kbc,
 		db:                db,
+		settings:          settings,
 		oauth:             oauth,
+		httpClient:        httpClient,
 		reminderScheduler: reminderScheduler,
 		tokenSecret:       tokenSecret,
 		httpPrefix:        httpPrefix,
+		onboarding:        base.NewOnboarding(stats, kbc, debugConfig, db.DB, "gcalbot", "gcal", "Hello! I can get you set up with Google Calendar anytime, just send me `!gcal accounts connect <account nickname>`."),
 	}
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Hello! I can get you set up with Google Calendar anytime, just send me `!gcal accounts connect <account nickname>`."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Reaction != nil && msg.Sender.Username != h.kbc.GetUsername() {
-		return h.handleReaction(msg)
+		return h.handleReaction(ctx, msg)
 	}
 
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
+	if handled, err := h.settings.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 
@@ -87,14 +109,14 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 		return h.handleAccountsList(msg)
 	case strings.HasPrefix(cmd, "!gcal accounts connect"):
 		h.stats.Count("accounts connect")
-		return h.handleAccountsConnect(msg, tokens[3:])
+		return h.handleAccountsConnect(ctx, msg, tokens[3:])
 	case strings.HasPrefix(cmd, "!gcal accounts disconnect"):
 		h.stats.Count("accounts disconnect")
-		return h.handleAccountsDisconnect(msg, tokens[3:])
+		return h.handleAccountsDisconnect(ctx, msg, tokens[3:])
 
 	case strings.HasPrefix(cmd, "!gcal calendars list"):
 		h.stats.Count("calendars list")
-		return h.handleCalendarsList(msg, tokens[3:])
+		return h.handleCalendarsList(ctx, msg, tokens[3:])
 
 	case strings.HasPrefix(cmd, "!gcal configure"):
 		h.stats.Count("configure")
@@ -106,7 +128,7 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 	}
 }
 
-func (h *Handler) handleReaction(msg chat1.MsgSummary) error {
+func (h *Handler) handleReaction(ctx context.Context, msg chat1.MsgSummary) error {
 	username := msg.Sender.Username
 	messageID := msg.Content.Reaction.MessageID
 	reaction := msg.Content.Reaction.Body
@@ -115,7 +137,7 @@ func (h *Handler) handleReaction(msg chat1.MsgSummary) error {
 	if err != nil {
 		return err
 	} else if invite != nil && account != nil {
-		err = h.updateEventResponseStatus(invite, account, InviteReaction(reaction))
+		err = h.updateEventResponseStatus(ctx, invite, account, InviteReaction(reaction))
 		if err != nil {
 			return fmt.Errorf("error updating event response status: %s", err)
 		}
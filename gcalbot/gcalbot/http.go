@@ -23,10 +23,11 @@ import (
 type HTTPSrv struct {
 	*base.HTTPSrv
 
-	kbc     *kbchat.API
-	oauth   *oauth2.Config
-	db      *DB
-	handler *Handler
+	kbc        *kbchat.API
+	oauth      *oauth2.Config
+	httpClient *base.HTTPClient
+	db         *DB
+	handler    *Handler
 
 	reminderScheduler ReminderScheduler
 }
@@ -37,12 +38,14 @@ func NewHTTPSrv(
 	debugConfig *base.ChatDebugOutputConfig,
 	db *DB,
 	oauthConfig *oauth2.Config,
+	httpClient *base.HTTPClient,
 	reminderScheduler ReminderScheduler,
 	handler *Handler,
 ) *HTTPSrv {
 	h := &HTTPSrv{
 		kbc:               kbc,
 		oauth:             oauthConfig,
+		httpClient:        httpClient,
 		db:                db,
 		handler:           handler,
 		reminderScheduler: reminderScheduler,
@@ -83,6 +86,7 @@ func (h *HTTPSrv) healthCheckHandler(_ http.ResponseWriter, _ *http.Request) {}
 
 func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 	h.Stats.Count("config")
+	ctx := r.Context()
 	var err error
 	defer func() {
 		if err != nil {
@@ -197,7 +201,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	srv, err := GetCalendarService(selectedAccount, h.oauth, h.db)
+	srv, err := GetCalendarService(ctx, selectedAccount, h.oauth, h.db, h.httpClient)
 	if err != nil {
 		return
 	}
@@ -319,7 +323,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 
 		if (!page.Invite && page.Reminder == "") && (inviteInput != "" || reminderInput != "") {
 			// this update must open a new webhook channel, do that now and if it errors, fail early
-			err = h.handler.createEventChannel(selectedAccount, calendarID)
+			err = h.handler.createEventChannel(ctx, selectedAccount, calendarID)
 			switch typedErr := err.(type) {
 			case nil:
 			case *googleapi.Error:
@@ -336,7 +340,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if dsEnabled {
-			err = h.db.InsertDailyScheduleSubscription(selectedAccount, DailyScheduleSubscription{
+			err = h.db.InsertDailyScheduleSubscription(ctx, selectedAccount, DailyScheduleSubscription{
 				CalendarID:       calendarID,
 				KeybaseConvID:    keybaseConvID,
 				Timezone:         dsTimezone,
@@ -353,7 +357,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 			page.DSTime = strconv.Itoa(GetMinutesFromDuration(dsTime))
 		} else if !dsEnabled && dsSubExists {
 			page.DSEnabled = false
-			err = h.db.DeleteDailyScheduleSubscription(selectedAccount, calendarID, keybaseConvID)
+			err = h.db.DeleteDailyScheduleSubscription(ctx, selectedAccount, calendarID, keybaseConvID)
 			if err != nil {
 				return
 			}
@@ -371,14 +375,14 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 			if page.Invite && !invite {
 				// remove invite subscription
 				h.Stats.Count("config - update - invite - remove")
-				err = h.handler.removeSubscription(selectedAccount, inviteSubscription)
+				err = h.handler.removeSubscription(ctx, selectedAccount, inviteSubscription)
 				if err != nil {
 					return
 				}
 			} else if !page.Invite && invite {
 				// create invite subscription
 				h.Stats.Count("config - update - invite - create")
-				_, err = h.handler.createSubscription(selectedAccount, inviteSubscription)
+				_, err = h.handler.createSubscription(ctx, selectedAccount, inviteSubscription)
 				if err != nil {
 					return
 				}
@@ -397,7 +401,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			err = h.handler.removeSubscription(selectedAccount, Subscription{
+			err = h.handler.removeSubscription(ctx, selectedAccount, Subscription{
 				CalendarID:     calendarID,
 				KeybaseConvID:  keybaseConvID,
 				DurationBefore: GetDurationFromMinutes(oldMinutesBefore),
@@ -416,7 +420,7 @@ func (h *HTTPSrv) configHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			_, err = h.handler.createSubscription(selectedAccount, Subscription{
+			_, err = h.handler.createSubscription(ctx, selectedAccount, Subscription{
 				CalendarID:     calendarID,
 				KeybaseConvID:  keybaseConvID,
 				DurationBefore: GetDurationFromMinutes(newMinutesBefore),
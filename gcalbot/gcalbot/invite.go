@@ -8,6 +8,7 @@ import (
 	"google.golang.org/api/googleapi"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
 	"google.golang.org/api/calendar/v3"
 )
 
@@ -28,20 +29,10 @@ const (
 	ResponseStatusAccepted    ResponseStatus = "accepted"
 )
 
-func (h *Handler) sendEventInvite(account *Account, channel *Channel, event *calendar.Event) error {
+func (h *Handler) sendEventInvite(ctx context.Context, account *Account, channel *Channel, event *calendar.Event) error {
 	h.stats.Count("sendEventInvite")
 
-	message := `You've been invited to %s: %s
-Awaiting your response. *Are you going?*`
-
-	var eventType string
-	if event.Recurrence == nil {
-		eventType = "an event"
-	} else {
-		eventType = "a recurring event"
-	}
-
-	srv, err := GetCalendarService(account, h.oauth, h.db)
+	srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 	if err != nil {
 		return err
 	}
@@ -53,21 +44,27 @@ Awaiting your response. *Are you going?*`
 	if err != nil {
 		return err
 	}
+	locale := GetLocale(h.settings, base.SettingsTarget{Username: account.KeybaseUsername}, srv)
 	invitedCalendar, err := srv.Calendars.Get(channel.CalendarID).Do()
 	if err != nil {
 		return err
 	}
-	eventContent, err := FormatEvent(event, invitedCalendar.Summary, timezone, format24HourTime)
+	eventContent, err := FormatEvent(event, invitedCalendar.Summary, timezone, format24HourTime, locale)
 	if err != nil {
 		return err
 	}
 
-	sendRes, err := h.kbc.SendMessageByTlfName(account.KeybaseUsername, message, eventType, eventContent)
+	eventType := catalog.T(locale, "invite.event")
+	if event.Recurrence != nil {
+		eventType = catalog.T(locale, "invite.recurring")
+	}
+	sendRes, err := h.kbc.SendMessageByTlfName(account.KeybaseUsername, "%s",
+		catalog.T(locale, "invite", eventType, eventContent))
 	if err != nil {
 		return err
 	}
 
-	err = h.db.InsertInvite(account, Invite{
+	err = h.db.InsertInvite(ctx, account, Invite{
 		CalendarID: invitedCalendar.Id,
 		EventID:    event.Id,
 		MessageID:  *sendRes.Result.MessageID,
@@ -87,7 +84,7 @@ Awaiting your response. *Are you going?*`
 	return nil
 }
 
-func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, reaction InviteReaction) error {
+func (h *Handler) updateEventResponseStatus(ctx context.Context, invite *Invite, account *Account, reaction InviteReaction) error {
 	h.stats.Count("updateEventResponseStatus")
 
 	var responseStatus ResponseStatus
@@ -95,22 +92,23 @@ func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, re
 	switch reaction {
 	case InviteReactionYes:
 		responseStatus = ResponseStatusAccepted
-		confirmationMessageStatus = "Going"
+		confirmationMessageStatus = "invite.going"
 	case InviteReactionNo:
 		responseStatus = ResponseStatusDeclined
-		confirmationMessageStatus = "Not Going"
+		confirmationMessageStatus = "invite.not_going"
 	case InviteReactionMaybe:
 		responseStatus = ResponseStatusTentative
-		confirmationMessageStatus = "Maybe Going"
+		confirmationMessageStatus = "invite.maybe"
 	default:
 		// reaction is not valid for responding to the event
 		return nil
 	}
 
-	srv, err := GetCalendarService(account, h.oauth, h.db)
+	srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 	if err != nil {
 		return err
 	}
+	locale := GetLocale(h.settings, base.SettingsTarget{Username: account.KeybaseUsername}, srv)
 
 	// fetch event
 	// TODO(marcel): check if event was deleted
@@ -119,8 +117,8 @@ func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, re
 	case nil:
 	case *googleapi.Error:
 		if typedErr.Code == 404 {
-			_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername,
-				"I couldn't update your status. Are you sure this event still exists?")
+			_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername, "%s",
+				catalog.T(locale, "invite.event_gone"))
 			if err != nil {
 				return err
 			}
@@ -142,8 +140,8 @@ func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, re
 	}
 
 	if !shouldPatch {
-		_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername,
-			"I couldn't update your status. Are you sure you're still invited to this event?")
+		_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername, "%s",
+			catalog.T(locale, "invite.not_invited"))
 		if err != nil {
 			return err
 		}
@@ -162,8 +160,8 @@ func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, re
 	}
 	accountCalendar := fmt.Sprintf("%s [%s]", invitedCalendar.Summary, account.AccountNickname)
 
-	_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername, "I've set your status as *%s* for event *%s* on calendar %s.",
-		confirmationMessageStatus, event.Summary, accountCalendar)
+	_, err = h.kbc.SendMessageByTlfName(account.KeybaseUsername, "%s", catalog.T(locale, "invite.status_set",
+		catalog.T(locale, confirmationMessageStatus), event.Summary, accountCalendar))
 	if err != nil {
 		return err
 	}
@@ -172,12 +170,13 @@ func (h *Handler) updateEventResponseStatus(invite *Invite, account *Account, re
 }
 
 func (h *Handler) syncAllInvites(account *Account, srv *calendar.Service, channelID, calendarID string) {
+	ctx := context.Background()
 	syncStart := time.Now()
 
 	var nextSyncToken string
 	var events []*calendar.Event
 	err := srv.Events.List(calendarID).
-		Pages(context.Background(), func(page *calendar.Events) error {
+		Pages(ctx, func(page *calendar.Events) error {
 			if page.NextPageToken == "" {
 				// set the sync token when the page token is empty
 				nextSyncToken = page.NextSyncToken
@@ -235,7 +234,7 @@ func (h *Handler) syncAllInvites(account *Account, srv *calendar.Service, channe
 		for _, attendee := range event.Attendees {
 			responseStatus := ResponseStatus(attendee.ResponseStatus)
 			if attendee.Self && !attendee.Organizer && responseStatus == ResponseStatusNeedsAction {
-				err = h.db.InsertInvite(account, Invite{
+				err = h.db.InsertInvite(ctx, account, Invite{
 					CalendarID: calendarID,
 					EventID:    event.Id,
 				})
@@ -247,7 +246,7 @@ func (h *Handler) syncAllInvites(account *Account, srv *calendar.Service, channe
 		}
 	}
 
-	err = h.db.UpdateChannelNextSyncToken(channelID, nextSyncToken)
+	err = h.db.UpdateChannelNextSyncToken(ctx, channelID, nextSyncToken)
 	if err != nil {
 		h.Errorf("unable to update sync token: %v", err)
 		return
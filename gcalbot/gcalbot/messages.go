@@ -0,0 +1,95 @@
+package gcalbot
+
+import "github.com/keybase/managed-bots/base"
+
+// catalog holds the messages about events, which are shown in the language
+// picked with `!gcal settings`, or the one of the user's Google Calendar.
+var catalog = base.NewCatalog(map[string]string{
+	"event.what":         "What",
+	"event.when":         "When",
+	"event.where":        "Where",
+	"event.organizer":    "Organizer",
+	"event.calendar":     "Calendar",
+	"event.description":  "Description",
+	"event.join_online":  "Join online",
+	"event.join_phone":   "Join by phone",
+	"event.join_sip":     "Join by SIP",
+	"event.pin":          "PIN",
+	"invite":             "You've been invited to %s: %s\nAwaiting your response. *Are you going?*",
+	"invite.event":       "an event",
+	"invite.recurring":   "a recurring event",
+	"invite.going":       "Going",
+	"invite.not_going":   "Not Going",
+	"invite.maybe":       "Maybe Going",
+	"invite.status_set":  "I've set your status as *%s* for event *%s* on calendar %s.",
+	"invite.event_gone":  "I couldn't update your status. Are you sure this event still exists?",
+	"invite.not_invited": "I couldn't update your status. Are you sure you're still invited to this event?",
+	"schedule.all_day":   "All Day",
+	"schedule.no_events": "You have no events today :sunny:",
+}).Add("de", map[string]string{
+	"event.what":         "Was",
+	"event.when":         "Wann",
+	"event.where":        "Wo",
+	"event.organizer":    "Organisator",
+	"event.calendar":     "Kalender",
+	"event.description":  "Beschreibung",
+	"event.join_online":  "Online teilnehmen",
+	"event.join_phone":   "Per Telefon teilnehmen",
+	"event.join_sip":     "Per SIP teilnehmen",
+	"event.pin":          "PIN",
+	"invite":             "Du wurdest zu %s eingeladen: %s\nDeine Antwort steht noch aus. *Nimmst du teil?*",
+	"invite.event":       "einem Termin",
+	"invite.recurring":   "einem wiederkehrenden Termin",
+	"invite.going":       "Zugesagt",
+	"invite.not_going":   "Abgesagt",
+	"invite.maybe":       "Vielleicht",
+	"invite.status_set":  "Ich habe deinen Status für den Termin *%[2]s* im Kalender %[3]s auf *%[1]s* gesetzt.",
+	"invite.event_gone":  "Ich konnte deinen Status nicht ändern. Gibt es den Termin noch?",
+	"invite.not_invited": "Ich konnte deinen Status nicht ändern. Bist du noch zu dem Termin eingeladen?",
+	"schedule.all_day":   "Ganztägig",
+	"schedule.no_events": "Du hast heute keine Termine :sunny:",
+}).Add("es", map[string]string{
+	"event.what":         "Qué",
+	"event.when":         "Cuándo",
+	"event.where":        "Dónde",
+	"event.organizer":    "Organizador",
+	"event.calendar":     "Calendario",
+	"event.description":  "Descripción",
+	"event.join_online":  "Unirse en línea",
+	"event.join_phone":   "Unirse por teléfono",
+	"event.join_sip":     "Unirse por SIP",
+	"event.pin":          "PIN",
+	"invite":             "Te han invitado a %s: %s\nEsperando tu respuesta. *¿Vas a asistir?*",
+	"invite.event":       "un evento",
+	"invite.recurring":   "un evento periódico",
+	"invite.going":       "Asistiré",
+	"invite.not_going":   "No asistiré",
+	"invite.maybe":       "Quizás asista",
+	"invite.status_set":  "He marcado tu estado como *%s* para el evento *%s* del calendario %s.",
+	"invite.event_gone":  "No he podido actualizar tu estado. ¿Seguro que el evento todavía existe?",
+	"invite.not_invited": "No he podido actualizar tu estado. ¿Seguro que todavía estás invitado al evento?",
+	"schedule.all_day":   "Todo el día",
+	"schedule.no_events": "No tienes eventos hoy :sunny:",
+}).Add("fr", map[string]string{
+	"event.what":         "Quoi",
+	"event.when":         "Quand",
+	"event.where":        "Où",
+	"event.organizer":    "Organisateur",
+	"event.calendar":     "Agenda",
+	"event.description":  "Description",
+	"event.join_online":  "Participer en ligne",
+	"event.join_phone":   "Participer par téléphone",
+	"event.join_sip":     "Participer par SIP",
+	"event.pin":          "Code",
+	"invite":             "Vous avez été invité à %s : %s\nEn attente de votre réponse. *Participerez-vous ?*",
+	"invite.event":       "un événement",
+	"invite.recurring":   "un événement récurrent",
+	"invite.going":       "Participe",
+	"invite.not_going":   "Ne participe pas",
+	"invite.maybe":       "Participera peut-être",
+	"invite.status_set":  "J'ai défini votre statut sur *%s* pour l'événement *%s* de l'agenda %s.",
+	"invite.event_gone":  "Je n'ai pas pu mettre à jour votre statut. Cet événement existe-t-il toujours ?",
+	"invite.not_invited": "Je n'ai pas pu mettre à jour votre statut. Êtes-vous toujours invité à cet événement ?",
+	"schedule.all_day":   "Toute la journée",
+	"schedule.no_events": "Vous n'avez aucun événement aujourd'hui :sunny:",
+})
@@ -58,7 +58,8 @@ func (h *HTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 		h.showOAuthError(w)
 		return
 	}
-	token, err := h.oauth.Exchange(context.TODO(), code)
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, h.httpClient.Client())
+	token, err := h.oauth.Exchange(ctx, code)
 	if err != nil {
 		return
 	}
@@ -68,11 +69,11 @@ func (h *HTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 		AccountNickname: req.AccountNickname,
 		Token:           *token,
 	}
-	err = h.db.InsertAccount(account)
+	err = h.db.InsertAccount(r.Context(), account)
 	if err != nil {
 		return
 	}
-	if err = h.db.CompleteState(state); err != nil {
+	if err = h.db.CompleteState(r.Context(), state); err != nil {
 		return
 	}
 
@@ -84,7 +85,7 @@ func (h *HTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 	// if account was created in a 1on1 conv, create default subscription to invites & 5 minute reminder for primary calendar
 	if base.IsDirectPrivateMessage(h.kbc.GetUsername(), req.KeybaseUsername, conv.Channel) {
 		var srv *calendar.Service
-		srv, err = GetCalendarService(&account, h.oauth, h.db)
+		srv, err = GetCalendarService(r.Context(), &account, h.oauth, h.db, h.httpClient)
 		if err != nil {
 			return
 		}
@@ -95,7 +96,7 @@ func (h *HTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		_, err = h.handler.createSubscription(&account, Subscription{
+		_, err = h.handler.createSubscription(r.Context(), &account, Subscription{
 			CalendarID:    primaryCalendar.Id,
 			KeybaseConvID: req.KeybaseConvID,
 			Type:          SubscriptionTypeInvite,
@@ -103,7 +104,7 @@ func (h *HTTPSrv) oauthHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return
 		}
-		_, err = h.handler.createSubscription(&account, Subscription{
+		_, err = h.handler.createSubscription(r.Context(), &account, Subscription{
 			CalendarID:     primaryCalendar.Id,
 			KeybaseConvID:  req.KeybaseConvID,
 			DurationBefore: GetDurationFromMinutes(5),
@@ -134,13 +135,13 @@ func (h *HTTPSrv) showOAuthError(w http.ResponseWriter) {
 	}
 }
 
-func (h *Handler) requestOAuth(msg chat1.MsgSummary, accountNickname string) error {
+func (h *Handler) requestOAuth(ctx context.Context, msg chat1.MsgSummary, accountNickname string) error {
 	state, err := base.MakeRequestID()
 	if err != nil {
 		return err
 	}
 
-	err = h.db.PutState(state, OAuthRequest{
+	err = h.db.PutState(ctx, state, OAuthRequest{
 		KeybaseUsername: msg.Sender.Username,
 		AccountNickname: accountNickname,
 		KeybaseConvID:   msg.ConvID,
@@ -15,9 +15,11 @@ type ReminderScheduler struct {
 
 	shutdownCh chan struct{}
 
-	stats *base.StatsRegistry
-	db    *gcalbot.DB
-	oauth *oauth2.Config
+	stats      *base.StatsRegistry
+	db         *gcalbot.DB
+	settings   *base.Settings
+	oauth      *oauth2.Config
+	httpClient *base.HTTPClient
 
 	subscriptionReminders *SubscriptionReminders
 	eventReminders        *EventReminders
@@ -28,14 +30,18 @@ func NewReminderScheduler(
 	stats *base.StatsRegistry,
 	debugConfig *base.ChatDebugOutputConfig,
 	db *gcalbot.DB,
+	settings *base.Settings,
 	oauth *oauth2.Config,
+	httpClient *base.HTTPClient,
 ) *ReminderScheduler {
 	return &ReminderScheduler{
 		stats:                 stats.SetPrefix("ReminderScheduler"),
 		DebugOutput:           base.NewDebugOutput("ReminderScheduler", debugConfig),
 		shutdownCh:            make(chan struct{}),
 		db:                    db,
+		settings:              settings,
 		oauth:                 oauth,
+		httpClient:            httpClient,
 		subscriptionReminders: NewSubscriptionReminders(),
 		eventReminders:        NewEventReminders(),
 		minuteReminders:       NewMinuteReminders(),
@@ -7,6 +7,7 @@ import (
 
 	"golang.org/x/oauth2"
 
+	"github.com/keybase/managed-bots/base"
 	"github.com/keybase/managed-bots/gcalbot/gcalbot"
 	"google.golang.org/api/calendar/v3"
 )
@@ -47,7 +48,8 @@ func (r *ReminderScheduler) eventSyncLoop(shutdownCh chan struct{}) error {
 }
 
 func (r *ReminderScheduler) syncEvents(account *gcalbot.Account, subscription *gcalbot.Subscription) {
-	srv, err := gcalbot.GetCalendarService(account, r.oauth, r.db)
+	ctx := context.Background()
+	srv, err := gcalbot.GetCalendarService(ctx, account, r.oauth, r.db, r.httpClient)
 	switch err.(type) {
 	case nil:
 	case *oauth2.RetrieveError:
@@ -67,7 +69,7 @@ func (r *ReminderScheduler) syncEvents(account *gcalbot.Account, subscription *g
 		TimeMin(minTime.Format(time.RFC3339)).
 		TimeMax(maxTime.Format(time.RFC3339)).
 		SingleEvents(true).
-		Pages(context.Background(), func(page *calendar.Events) error {
+		Pages(ctx, func(page *calendar.Events) error {
 			events = append(events, page.Items...)
 			return nil
 		})
@@ -81,7 +83,7 @@ func (r *ReminderScheduler) syncEvents(account *gcalbot.Account, subscription *g
 		return
 	}
 	for _, event := range events {
-		err = r.UpdateOrCreateReminderEvent(account, subscription, event)
+		err = r.UpdateOrCreateReminderEvent(ctx, account, subscription, event)
 		if err != nil {
 			r.Errorf("error updating or creating reminder event: %s", err)
 		}
@@ -89,6 +91,7 @@ func (r *ReminderScheduler) syncEvents(account *gcalbot.Account, subscription *g
 }
 
 func (r *ReminderScheduler) UpdateOrCreateReminderEvent(
+	ctx context.Context,
 	account *gcalbot.Account,
 	subscription *gcalbot.Subscription,
 	event *calendar.Event,
@@ -124,7 +127,7 @@ func (r *ReminderScheduler) UpdateOrCreateReminderEvent(
 		}
 	})
 
-	srv, err := gcalbot.GetCalendarService(account, r.oauth, r.db)
+	srv, err := gcalbot.GetCalendarService(ctx, account, r.oauth, r.db, r.httpClient)
 	switch err.(type) {
 	case nil:
 	case *oauth2.RetrieveError:
@@ -142,11 +145,15 @@ func (r *ReminderScheduler) UpdateOrCreateReminderEvent(
 	if err != nil {
 		return err
 	}
+	locale := gcalbot.GetLocale(r.settings, base.SettingsTarget{
+		ConvID:   subscription.KeybaseConvID,
+		Username: account.KeybaseUsername,
+	}, srv)
 	subscribedCalendar, err := srv.Calendars.Get(subscription.CalendarID).Do()
 	if err != nil {
 		return err
 	}
-	eventMsgContent, err := gcalbot.FormatEvent(event, subscribedCalendar.Summary, timezone, format24HourTime)
+	eventMsgContent, err := gcalbot.FormatEvent(event, subscribedCalendar.Summary, timezone, format24HourTime, locale)
 	if err != nil {
 		return err
 	}
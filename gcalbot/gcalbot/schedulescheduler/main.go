@@ -14,23 +14,29 @@ type ScheduleScheduler struct {
 
 	shutdownCh chan struct{}
 
-	stats *base.StatsRegistry
-	db    *gcalbot.DB
-	oauth *oauth2.Config
+	stats      *base.StatsRegistry
+	db         *gcalbot.DB
+	settings   *base.Settings
+	oauth      *oauth2.Config
+	httpClient *base.HTTPClient
 }
 
 func NewScheduleScheduler(
 	stats *base.StatsRegistry,
 	debugConfig *base.ChatDebugOutputConfig,
 	db *gcalbot.DB,
+	settings *base.Settings,
 	oauth *oauth2.Config,
+	httpClient *base.HTTPClient,
 ) *ScheduleScheduler {
 	return &ScheduleScheduler{
 		stats:       stats.SetPrefix("ScheduleScheduler"),
 		DebugOutput: base.NewDebugOutput("ScheduleScheduler", debugConfig),
 		shutdownCh:  make(chan struct{}),
 		db:          db,
+		settings:    settings,
 		oauth:       oauth,
+		httpClient:  httpClient,
 	}
 }
 
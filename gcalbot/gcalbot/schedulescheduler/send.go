@@ -11,6 +11,7 @@ import (
 
 	"google.golang.org/api/calendar/v3"
 
+	"github.com/keybase/managed-bots/base"
 	"github.com/keybase/managed-bots/gcalbot/gcalbot"
 )
 
@@ -100,7 +101,7 @@ func (s *ScheduleScheduler) SendDailyScheduleMessage(sendMinute time.Time, subsc
 	s.stats.Count("SendDailyScheduleMessage")
 	s.stats.CountMult("SendDailyScheduleMessage - calendars", len(subscription.CalendarIDs))
 
-	srv, err := gcalbot.GetCalendarService(&subscription.Account, s.oauth, s.db)
+	srv, err := gcalbot.GetCalendarService(context.Background(), &subscription.Account, s.oauth, s.db, s.httpClient)
 	switch err.(type) {
 	case nil:
 	case *oauth2.RetrieveError:
@@ -166,15 +167,14 @@ Calendars: %s
 	link := fmt.Sprintf("https://calendar.google.com/calendar/r/day/%d/%d/%d",
 		userSendMinute.Year(), userSendMinute.Month(), userSendMinute.Day())
 
-	var formattedSchedule string
-	if len(events) == 0 {
-		formattedSchedule = "> You have no events today :sunny:"
-	} else {
-		formattedSchedule, err = gcalbot.FormatEventSchedule(events, subscription.Timezone, format24HourTime)
-		if err != nil {
-			s.Errorf("unable to format schedule: %s", err)
-			return
-		}
+	locale := gcalbot.GetLocale(s.settings, base.SettingsTarget{
+		ConvID:   subscription.KeybaseConvID,
+		Username: subscription.Account.KeybaseUsername,
+	}, srv)
+	formattedSchedule, err := gcalbot.FormatEventSchedule(events, subscription.Timezone, format24HourTime, locale)
+	if err != nil {
+		s.Errorf("unable to format schedule: %s", err)
+		return
 	}
 
 	s.ChatEcho(subscription.KeybaseConvID, message,
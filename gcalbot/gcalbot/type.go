@@ -1,6 +1,7 @@
 package gcalbot
 
 import (
+	"context"
 	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -62,6 +63,7 @@ type Invite struct {
 
 type ReminderScheduler interface {
 	UpdateOrCreateReminderEvent(
+		ctx context.Context,
 		account *Account,
 		subscription *Subscription,
 		event *calendar.Event,
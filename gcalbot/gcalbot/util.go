@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
 
 	"google.golang.org/api/calendar/v3"
 )
@@ -51,6 +52,7 @@ func FormatTimeRange(
 	startDateTime, endDateTime *calendar.EventDateTime,
 	timezone *time.Location,
 	format24HourTime bool,
+	locale base.Locale,
 ) (timeRange string, err error) {
 	// For normal events:
 	//	If the year, month and day are the same: Wed Jan 1, 2020 6:30pm - 7:30pm (EST)
@@ -62,6 +64,7 @@ func FormatTimeRange(
 	//	If just the year and month are the same: Wed Jan 1 - Thu Jan 2, 2020
 	//	If just the year is the same (same ^):   Fri Jan 31 - Sat Feb 1, 2020
 	//	If none of the params are the same:		 Thu Dec 31, 2020 - Fri Jan 1, 2021
+	// Dates are written the way the locale does, such as Mi., 1. Jan. 2020 in German.
 
 	start, end, isAllDay, err := ParseTime(startDateTime, endDateTime)
 	if err != nil {
@@ -84,25 +87,25 @@ func FormatTimeRange(
 
 	if startYear == endYear && startMonth == endMonth && startDay == endDay {
 		if isAllDay {
-			return start.Format("Mon Jan 2, 2006"), nil
+			return base.FormatDate(locale, start, true), nil
 		}
 		return fmt.Sprintf("%s %s - %s (%s)",
-			start.Format("Mon Jan 2, 2006"), startTime, endTime, start.Format("MST")), nil
+			base.FormatDate(locale, start, true), startTime, endTime, start.Format("MST")), nil
 	} else if startYear == endYear {
 		if isAllDay {
 			return fmt.Sprintf("%s - %s",
-				start.Format("Mon Jan 2"), end.Format("Mon Jan 2, 2006")), nil
+				base.FormatDate(locale, start, false), base.FormatDate(locale, end, true)), nil
 		}
 		return fmt.Sprintf("%s %s - %s %s (%s)",
-			start.Format("Mon Jan 2"), startTime, end.Format("Mon Jan 2, 2006"), endTime,
+			base.FormatDate(locale, start, false), startTime, base.FormatDate(locale, end, true), endTime,
 			start.Format("MST")), nil
 	}
 	if isAllDay {
 		return fmt.Sprintf("%s - %s",
-			start.Format("Mon Jan 2, 2006"), end.Format("Mon Jan 2, 2006")), nil
+			base.FormatDate(locale, start, true), base.FormatDate(locale, end, true)), nil
 	}
 	return fmt.Sprintf("%s %s - %s %s (%s)",
-		start.Format("Mon Jan 2, 2006"), startTime, end.Format("Mon Jan 2, 2006"), endTime,
+		base.FormatDate(locale, start, true), startTime, base.FormatDate(locale, end, true), endTime,
 		start.Format("MST")), nil
 }
 
@@ -124,6 +127,34 @@ func GetUserTimezone(srv *calendar.Service) (timezone *time.Location, err error)
 	return time.LoadLocation(timezoneSetting.Value)
 }
 
+// GetUserLocale returns the language the user chose for Google Calendar.
+func GetUserLocale(srv *calendar.Service) (locale base.Locale, err error) {
+	localeSetting, err := srv.Settings.Get("locale").Do()
+	if err != nil {
+		return "", err
+	}
+	return base.ParseLocale(localeSetting.Value), nil
+}
+
+// GetLocale returns the language picked for the target with `!gcal settings`,
+// or the one the user chose for Google Calendar if none was.
+func GetLocale(settings *base.Settings, target base.SettingsTarget, srv *calendar.Service) base.Locale {
+	value, err := settings.Lookup(target, base.SettingLanguage)
+	switch {
+	case err != nil:
+		settings.Debug("GetLocale: unable to get language: %s", err)
+	case value.Scope != "":
+		return base.ParseLocale(value.Value)
+	}
+	locale, err := GetUserLocale(srv)
+	if err != nil {
+		// the language only affects the wording, so don't lose the message over it
+		settings.Debug("GetLocale: unable to get user locale, using the default: %s", err)
+		return base.DefaultLocale
+	}
+	return locale
+}
+
 func GetUserFormat24HourTime(srv *calendar.Service) (format24HourTime bool, err error) {
 	format24HourTimeSetting, err := srv.Settings.Get("format24HourTime").Do()
 	if err != nil {
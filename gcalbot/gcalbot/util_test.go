@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/keybase/managed-bots/base"
 	"github.com/keybase/managed-bots/gcalbot/gcalbot"
 )
 
@@ -36,6 +37,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -54,6 +56,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -72,6 +75,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -90,6 +94,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -108,6 +113,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -126,6 +132,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -144,6 +151,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -162,6 +170,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			false,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -180,6 +189,7 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			true,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
@@ -198,8 +208,76 @@ func TestFormatTimeRange(t *testing.T) {
 			},
 			timezone,
 			true,
+			base.DefaultLocale,
 		)
 		require.NoError(t, err)
 		require.Equal(t, expected, actual)
 	})
+
+	t.Run("german, 24 hour format", func(t *testing.T) {
+		expected := "Mi., 1. Jan. 16:30 - Do., 2. Jan. 2020 18:30 (CET)"
+		timezone, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		actual, err := gcalbot.FormatTimeRange(
+			&calendar.EventDateTime{
+				DateTime: "2020-01-01T16:30:00+01:00",
+			},
+			&calendar.EventDateTime{
+				DateTime: "2020-01-02T18:30:00+01:00",
+			},
+			timezone,
+			true,
+			base.ParseLocale("de_DE"),
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("unknown locale", func(t *testing.T) {
+		expected := "Fri Jan 31 - Sat Feb 1, 2020"
+		timezone, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		actual, err := gcalbot.FormatTimeRange(
+			&calendar.EventDateTime{
+				Date: "2020-01-31",
+			},
+			&calendar.EventDateTime{
+				Date: "2020-02-02",
+			},
+			timezone,
+			false,
+			"xx",
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+}
+
+func TestFormatEventSchedule(t *testing.T) {
+	timezone, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	events := []*calendar.Event{
+		{
+			Summary: "Standup",
+			Start:   &calendar.EventDateTime{DateTime: "2020-01-01T09:30:00-05:00"},
+			End:     &calendar.EventDateTime{DateTime: "2020-01-01T10:00:00-05:00"},
+		},
+		{
+			Summary: "Holiday",
+			Start:   &calendar.EventDateTime{Date: "2020-01-01"},
+			End:     &calendar.EventDateTime{Date: "2020-01-02"},
+		},
+	}
+
+	schedule, err := gcalbot.FormatEventSchedule(events, timezone, true, base.DefaultLocale)
+	require.NoError(t, err)
+	require.Equal(t, "> All Day *Holiday*\n> 09:30 - 10:00 *Standup*", schedule)
+
+	schedule, err = gcalbot.FormatEventSchedule(events, timezone, true, "de")
+	require.NoError(t, err)
+	require.Equal(t, "> Ganztägig *Holiday*\n> 09:30 - 10:00 *Standup*", schedule)
+
+	schedule, err = gcalbot.FormatEventSchedule(nil, timezone, true, "fr")
+	require.NoError(t, err)
+	require.Equal(t, "> Vous n'avez aucun événement aujourd'hui :sunny:", schedule)
 }
@@ -16,6 +16,7 @@ import (
 )
 
 func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	var err error
 	defer func() {
 		if err != nil {
@@ -57,7 +58,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	srv, err := GetCalendarService(account, h.oauth, h.db)
+	srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 	switch err.(type) {
 	case nil:
 	case *oauth2.RetrieveError:
@@ -76,7 +77,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 		// check if the event starts in the next 3 hours before registering it
 		if time.Now().Before(start) && time.Now().Add(3*time.Hour).After(start) {
 			for _, subscription := range reminderSubscriptions {
-				err = h.reminderScheduler.UpdateOrCreateReminderEvent(account, subscription, event)
+				err = h.reminderScheduler.UpdateOrCreateReminderEvent(ctx, account, subscription, event)
 				if err != nil {
 					return
 				}
@@ -102,7 +103,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 			// user was recently invited to the event
 			for range inviteSubscriptions {
 				// TODO(marcel): use subscription convid
-				err = h.handler.sendEventInvite(account, channel, event)
+				err = h.handler.sendEventInvite(ctx, account, channel, event)
 				if err != nil {
 					return
 				}
@@ -117,7 +118,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 		err = srv.Events.
 			List(channel.CalendarID).
 			SyncToken(syncToken).
-			Pages(context.Background(), func(page *calendar.Events) error {
+			Pages(ctx, func(page *calendar.Events) error {
 				if page.NextPageToken == "" {
 					// set the sync token when the page token is empty
 					nextSyncToken = page.NextSyncToken
@@ -154,7 +155,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 
 		if status == EventStatusCancelled {
 			for _, subscription := range reminderSubscriptions {
-				err = h.reminderScheduler.UpdateOrCreateReminderEvent(account, subscription, event)
+				err = h.reminderScheduler.UpdateOrCreateReminderEvent(ctx, account, subscription, event)
 				if err != nil {
 					return
 				}
@@ -190,7 +191,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	err = h.db.UpdateChannelNextSyncToken(channelID, nextSyncToken)
+	err = h.db.UpdateChannelNextSyncToken(ctx, channelID, nextSyncToken)
 	if err != nil {
 		return
 	}
@@ -202,7 +203,7 @@ func (h *HTTPSrv) handleEventUpdateWebhook(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *Handler) createSubscription(
-	account *Account, subscription Subscription,
+	ctx context.Context, account *Account, subscription Subscription,
 ) (exists bool, err error) {
 	exists, err = h.db.ExistsSubscription(account, subscription)
 	if err != nil || exists {
@@ -210,12 +211,12 @@ func (h *Handler) createSubscription(
 		return exists, err
 	}
 
-	err = h.createEventChannel(account, subscription.CalendarID)
+	err = h.createEventChannel(ctx, account, subscription.CalendarID)
 	if err != nil {
 		return exists, err
 	}
 
-	err = h.db.InsertSubscription(account, subscription)
+	err = h.db.InsertSubscription(ctx, account, subscription)
 	if err != nil {
 		return exists, err
 	}
@@ -226,9 +227,9 @@ func (h *Handler) createSubscription(
 }
 
 func (h *Handler) removeSubscription(
-	account *Account, subscription Subscription,
+	ctx context.Context, account *Account, subscription Subscription,
 ) error {
-	err := h.db.DeleteSubscription(account, subscription)
+	err := h.db.DeleteSubscription(ctx, account, subscription)
 	if err != nil {
 		// if no error, subscription doesn't exist, short circuit
 		return err
@@ -249,7 +250,7 @@ func (h *Handler) removeSubscription(
 		}
 
 		if channel != nil {
-			srv, err := GetCalendarService(account, h.oauth, h.db)
+			srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 			if err != nil {
 				return err
 			}
@@ -268,7 +269,7 @@ func (h *Handler) removeSubscription(
 				return err
 			}
 
-			err = h.db.DeleteChannelByChannelID(channel.ChannelID)
+			err = h.db.DeleteChannelByChannelID(ctx, channel.ChannelID)
 			if err != nil {
 				return err
 			}
@@ -278,8 +279,8 @@ func (h *Handler) removeSubscription(
 	return nil
 }
 
-func (h *Handler) createEventChannel(account *Account, calendarID string) error {
-	srv, err := GetCalendarService(account, h.oauth, h.db)
+func (h *Handler) createEventChannel(ctx context.Context, account *Account, calendarID string) error {
+	srv, err := GetCalendarService(ctx, account, h.oauth, h.db, h.httpClient)
 	if err != nil {
 		return err
 	}
@@ -305,7 +306,7 @@ func (h *Handler) createEventChannel(account *Account, calendarID string) error
 		return err
 	}
 
-	err = h.db.InsertChannel(account, Channel{
+	err = h.db.InsertChannel(ctx, account, Channel{
 		ChannelID:  channelID,
 		CalendarID: calendarID,
 		ResourceID: res.ResourceId,
@@ -332,6 +333,7 @@ type RenewChannelScheduler struct {
 	stats      *base.StatsRegistry
 	db         *DB
 	config     *oauth2.Config
+	httpClient *base.HTTPClient
 	httpPrefix string
 }
 
@@ -340,6 +342,7 @@ func NewRenewChannelScheduler(
 	debugConfig *base.ChatDebugOutputConfig,
 	db *DB,
 	config *oauth2.Config,
+	httpClient *base.HTTPClient,
 	httpPrefix string,
 ) *RenewChannelScheduler {
 	return &RenewChannelScheduler{
@@ -347,6 +350,7 @@ func NewRenewChannelScheduler(
 		DebugOutput: base.NewDebugOutput("RenewChannelScheduler", debugConfig),
 		db:          db,
 		config:      config,
+		httpClient:  httpClient,
 		httpPrefix:  httpPrefix,
 		shutdownCh:  make(chan struct{}),
 	}
@@ -394,7 +398,7 @@ func (r *RenewChannelScheduler) renewScheduler(shutdownCh chan struct{}) {
 					return
 				default:
 				}
-				err = r.renewChannel(&pair.Account, &pair.Channel)
+				err = r.renewChannel(context.Background(), &pair.Account, &pair.Channel)
 				if err != nil {
 					r.Debug("error renewing channel '%s': %s", pair.Channel.ChannelID, err)
 				}
@@ -404,9 +408,9 @@ func (r *RenewChannelScheduler) renewScheduler(shutdownCh chan struct{}) {
 	}
 }
 
-func (r *RenewChannelScheduler) renewChannel(account *Account, channel *Channel) error {
+func (r *RenewChannelScheduler) renewChannel(ctx context.Context, account *Account, channel *Channel) error {
 	r.stats.Count("renewChannel")
-	srv, err := GetCalendarService(account, r.config, r.db)
+	srv, err := GetCalendarService(ctx, account, r.config, r.db, r.httpClient)
 	switch err.(type) {
 	case nil:
 	case *oauth2.RetrieveError:
@@ -431,7 +435,7 @@ func (r *RenewChannelScheduler) renewChannel(account *Account, channel *Channel)
 		return err
 	}
 
-	err = r.db.UpdateChannel(channel.ChannelID, newChannelID, time.Unix(res.Expiration/1e3, 0))
+	err = r.db.UpdateChannel(ctx, channel.ChannelID, newChannelID, time.Unix(res.Expiration/1e3, 0))
 	if err != nil {
 		return err
 	}
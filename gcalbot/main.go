@@ -46,7 +46,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("gcalbot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("gcalbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -128,6 +128,8 @@ Examples:%s
 		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
 	}
 
+	commands = append(commands, base.OnboardingAdvertisements("gcal", false)...)
+	commands = append(commands, base.SettingsAdvertisement("gcal"))
 	return kbchat.Advertisement{
 		Alias: "Google Calendar",
 		Advertisements: []chat1.AdvertiseCommandAPIParam{
@@ -211,19 +213,26 @@ func (s *BotServer) Go() (err error) {
 	db := gcalbot.NewDB(sdb, debugConfig)
 
 	stats = stats.SetPrefix(s.Name())
-	renewScheduler := gcalbot.NewRenewChannelScheduler(stats, debugConfig, db, config, s.opts.HTTPPrefix)
-	reminderScheduler := reminderscheduler.NewReminderScheduler(stats, debugConfig, db, config)
-	scheduleScheduler := schedulescheduler.NewScheduleScheduler(stats, debugConfig, db, config)
-	handler := gcalbot.NewHandler(stats, s.kbc, debugConfig, db, config, reminderScheduler, secret, s.opts.HTTPPrefix)
-	httpSrv := gcalbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, config, reminderScheduler, handler)
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	renewScheduler := gcalbot.NewRenewChannelScheduler(stats, debugConfig, db, config, httpClient, s.opts.HTTPPrefix)
+	settings := gcalbot.NewSettings(s.kbc, debugConfig, db)
+	reminderScheduler := reminderscheduler.NewReminderScheduler(stats, debugConfig, db, settings, config, httpClient)
+	scheduleScheduler := schedulescheduler.NewScheduleScheduler(stats, debugConfig, db, settings, config, httpClient)
+	handler := gcalbot.NewHandler(stats, s.kbc, debugConfig, db, settings, config, httpClient, reminderScheduler, secret, s.opts.HTTPPrefix)
+	httpSrv := gcalbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, config, httpClient, reminderScheduler, handler)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
 	s.GoWithRecover(eg, renewScheduler.Run)
 	s.GoWithRecover(eg, reminderScheduler.Run)
 	s.GoWithRecover(eg, scheduleScheduler.Run)
 	s.GoWithRecover(eg, func() error {
-		return s.HandleSignals(httpSrv, stats, renewScheduler, reminderScheduler, scheduleScheduler)
+		return s.HandleSignals(httpSrv, stats, renewScheduler, reminderScheduler, scheduleScheduler, cleaner)
 	})
 	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
 	if err := eg.Wait(); err != nil {
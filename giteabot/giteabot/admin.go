@@ -0,0 +1,11 @@
+package giteabot
+
+import (
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+func init() {
+	base.RegisterAdminTables("giteabot", git.WebhookAdminTables()...)
+	base.RegisterAdminTables("giteabot", base.OnboardingAdminTable("giteabot"))
+}
@@ -0,0 +1,190 @@
+package giteabot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/keybase/managed-bots/base/git"
+)
+
+/*
+Gitea (and Forgejo, which sends the same payloads) webhooks:
+https://docs.gitea.com/usage/webhooks
+
+Only the fields the bot uses are decoded.
+*/
+
+type user struct {
+	Login    string `json:"login"`
+	Username string `json:"username"`
+}
+
+func (u *user) name() string {
+	if u == nil {
+		return ""
+	}
+	if u.Login != "" {
+		return u.Login
+	}
+	return u.Username
+}
+
+type repository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type commit struct {
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+type issue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	HTMLURL     string    `json:"html_url"`
+	User        *user     `json:"user"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+type pullRequest struct {
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"html_url"`
+	User     *user  `json:"user"`
+	Merged   bool   `json:"merged"`
+	MergedBy *user  `json:"merged_by"`
+	Base     struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+type payload struct {
+	Action     string      `json:"action"`
+	Repository *repository `json:"repository"`
+	Sender     *user       `json:"sender"`
+
+	// push
+	Ref        string   `json:"ref"`
+	CompareURL string   `json:"compare_url"`
+	Commits    []commit `json:"commits"`
+	Pusher     *user    `json:"pusher"`
+
+	// create and delete
+	RefType string `json:"ref_type"`
+
+	Issue       *issue       `json:"issue"`
+	PullRequest *pullRequest `json:"pull_request"`
+	Comment     *struct {
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		User    *user  `json:"user"`
+	} `json:"comment"`
+	Release *struct {
+		TagName string `json:"tag_name"`
+		Name    string `json:"name"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	} `json:"release"`
+}
+
+// parseEvent translates the webhook payload of the given X-Gitea-Event type,
+// it returns nil for events that aren't supported.
+func parseEvent(eventType string, data []byte) (*git.Event, error) {
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("could not parse %s webhook: %s", eventType, err)
+	}
+	if p.Repository == nil || p.Repository.HTMLURL == "" {
+		return nil, nil
+	}
+
+	res := &git.Event{
+		Provider: git.GITEA,
+		Action:   p.Action,
+		Repo:     repoFromURL(p.Repository.HTMLURL),
+		RepoName: p.Repository.FullName,
+		Author:   p.Sender.name(),
+	}
+	switch eventType {
+	case "push":
+		if len(p.Commits) == 0 || git.IsTagRef(p.Ref) {
+			return nil, nil
+		}
+		res.Kind = git.EventPush
+		res.Branch = git.RefToName(p.Ref)
+		if pusher := p.Pusher.name(); pusher != "" {
+			res.Author = pusher
+		}
+		for _, commit := range p.Commits {
+			res.Commits = append(res.Commits, commit.Message)
+		}
+		res.URL = p.CompareURL
+		if res.URL == "" || len(p.Commits) == 1 {
+			res.URL = p.Commits[len(p.Commits)-1].URL
+		}
+	case "create", "delete":
+		if p.RefType != "tag" {
+			return nil, nil
+		}
+		res.Kind = git.EventTag
+		res.Action = eventType + "d"
+		res.Tag = strings.TrimPrefix(p.Ref, "refs/tags/")
+		if eventType == "create" {
+			res.URL = fmt.Sprintf("%s/src/tag/%s", p.Repository.HTMLURL, res.Tag)
+		}
+	case "issues":
+		if p.Issue == nil {
+			return nil, nil
+		}
+		res.Kind = git.EventIssue
+		res.Number = p.Issue.Number
+		res.Title = p.Issue.Title
+		res.URL = p.Issue.HTMLURL
+		if res.Action == "opened" {
+			res.Author = p.Issue.User.name()
+		}
+	case "pull_request":
+		if p.PullRequest == nil {
+			return nil, nil
+		}
+		res.Kind = git.EventPullRequest
+		res.Number = p.PullRequest.Number
+		res.Title = p.PullRequest.Title
+		res.URL = p.PullRequest.HTMLURL
+		res.TargetBranch = p.PullRequest.Base.Ref
+		if res.Action == "opened" {
+			res.Author = p.PullRequest.User.name()
+		}
+		if res.Action == "closed" && p.PullRequest.Merged {
+			res.Action = "merged"
+			if mergedBy := p.PullRequest.MergedBy.name(); mergedBy != "" {
+				res.Author = mergedBy
+			}
+		}
+	case "issue_comment":
+		if p.Issue == nil || p.Comment == nil {
+			return nil, nil
+		}
+		res.Kind = git.EventComment
+		res.Author = p.Comment.User.name()
+		res.Number = p.Issue.Number
+		res.Title = p.Issue.Title
+		res.IsPullRequest = p.Issue.PullRequest != nil
+		res.Body = p.Comment.Body
+		res.URL = p.Comment.HTMLURL
+	case "release":
+		if p.Release == nil {
+			return nil, nil
+		}
+		res.Kind = git.EventRelease
+		res.Tag = p.Release.TagName
+		res.Name = p.Release.Name
+		res.Body = p.Release.Body
+		res.URL = p.Release.HTMLURL
+	default:
+		return nil, nil
+	}
+	return res, nil
+}
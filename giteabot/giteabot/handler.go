@@ -0,0 +1,20 @@
+package giteabot
+
+import (
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// NewHandler handles the `!gitea` commands.
+func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
+	db *git.WebhookDB, httpPrefix string, secret string) *git.WebhookHandler {
+	return git.NewWebhookHandler(stats, kbc, debugConfig, db, git.WebhookProvider{
+		Name:              "giteabot",
+		Cmd:               "gitea",
+		Welcome:           "Hi! I can notify you whenever something happens on a Gitea or Forgejo repository. To get started, set up a repository by sending `!gitea subscribe <url/owner/repo>`",
+		RepoUsage:         "`https://domain.com/owner/repo`",
+		ParseRepo:         parseRepoInput,
+		SetupInstructions: formatSetupInstructions,
+	}, httpPrefix, secret)
+}
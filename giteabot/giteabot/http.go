@@ -0,0 +1,85 @@
+package giteabot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+type HTTPSrv struct {
+	*base.HTTPSrv
+
+	kbc      *kbchat.API
+	db       *git.WebhookDB
+	handler  *git.WebhookHandler
+	notifier *git.Notifier
+	secret   string
+}
+
+func NewHTTPSrv(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
+	db *git.WebhookDB, handler *git.WebhookHandler, secret string) *HTTPSrv {
+	h := &HTTPSrv{
+		kbc:      kbc,
+		db:       db,
+		handler:  handler,
+		notifier: git.NewNotifier(stats, debugConfig, db.Store),
+		secret:   secret,
+	}
+	h.HTTPSrv = base.NewHTTPSrv(stats, debugConfig)
+	http.HandleFunc("/giteabot", h.handleHealthCheck)
+	http.HandleFunc("/giteabot/webhook", h.handleWebhook)
+	return h
+}
+
+func (h *HTTPSrv) handleHealthCheck(w http.ResponseWriter, _ *http.Request) {
+	fmt.Fprintf(w, "beep boop! :)")
+}
+
+// webhookHeader returns the Gitea header, or the one Forgejo uses instead.
+func webhookHeader(r *http.Request, name string) string {
+	if value := r.Header.Get("X-Gitea-" + name); value != "" {
+		return value
+	}
+	return r.Header.Get("X-Forgejo-" + name)
+}
+
+func (h *HTTPSrv) handleWebhook(_ http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Errorf("Error reading payload: %s", err)
+		return
+	}
+	defer r.Body.Close()
+
+	eventType := webhookHeader(r, "Event")
+	event, err := parseEvent(eventType, payload)
+	if err != nil {
+		h.Errorf("%s", err)
+		return
+	}
+	if event == nil {
+		return
+	}
+	signature := webhookHeader(r, "Signature")
+
+	convs, err := h.db.GetSubscribedConvs(event.Repo)
+	if err != nil {
+		h.Errorf("Error getting subscriptions for repo: %s", err)
+		return
+	}
+
+	var validConvs []chat1.ConvIDStr
+	for _, convID := range convs {
+		if !validateSignature(signature, payload, base.MakeSecret(event.Repo, convID, h.secret)) {
+			h.Debug("Error validating payload signature for conversation %s", convID)
+			continue
+		}
+		validConvs = append(validConvs, convID)
+	}
+	h.notifier.Notify(event, validConvs, nil)
+}
@@ -0,0 +1,93 @@
+package giteabot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+
+	"github.com/keybase/managed-bots/base"
+)
+
+var repoRegex = regexp.MustCompile(`^[a-zA-Z0-9_\.-]*$`)
+
+func formatSetupInstructions(repo string, repoURL string, msg chat1.MsgSummary, httpAddress string, secret string) (res string) {
+	back := "`"
+	message := fmt.Sprintf(`
+To configure your repository to send notifications, go to %s/settings/hooks and add a new Gitea (or Forgejo) webhook.
+For “Target URL”, enter %s%s/giteabot/webhook%s.
+For “HTTP Method”, choose %sPOST%s and for “POST Content Type” choose %sapplication/json%s.
+For “Secret”, enter %s%s%s.
+Remember to check all the triggers you would like me to update you on.
+Note that I currently support the following Webhook Events: Push, Create, Delete, Issues, Issue Comment, Pull Request, Release
+
+Happy coding!`,
+		repoURL, back, httpAddress, back, back, back, back, back, back, base.MakeSecret(repo, msg.ConvID, secret), back)
+	return message
+}
+
+// parseRepoInput parses `https://domain.com/owner/repo`, the scheme is
+// optional. Gitea is self-hosted, so repo includes the domain to tell the
+// instances apart.
+func parseRepoInput(input string) (repoURL string, repo string, err error) {
+	input = strings.TrimSuffix(strings.TrimSuffix(input, "/"), ".git")
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+	parsedURL, err := url.ParseRequestURI(input)
+	if err != nil || parsedURL.Host == "" {
+		return "", "", fmt.Errorf("invalid arguments, expected `https://domain.com/owner/repo`")
+	}
+
+	splitRepo := strings.Split(strings.TrimPrefix(parsedURL.Path, "/"), "/")
+	if !isValidArgs(splitRepo) {
+		return "", "", fmt.Errorf("invalid arguments, expected `https://domain.com/owner/repo`")
+	}
+
+	repoURL = parsedURL.Scheme + "://" + parsedURL.Host + parsedURL.Path
+	return repoURL, repoFromURL(repoURL), nil
+}
+
+// repoFromURL returns the identifier subscriptions use for the repository
+// with the given web URL.
+func repoFromURL(repoURL string) string {
+	if i := strings.Index(repoURL, "://"); i >= 0 {
+		repoURL = repoURL[i+3:]
+	}
+	return strings.ToLower(strings.TrimSuffix(repoURL, "/"))
+}
+
+func isValidArgs(args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+
+	for _, arg := range args {
+		if arg == "" {
+			return false
+		}
+		match := repoRegex.MatchString(arg)
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateSignature checks the hex encoded HMAC-SHA256 of the payload Gitea
+// signs with the webhook's secret.
+func validateSignature(signature string, payload []byte, secret string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
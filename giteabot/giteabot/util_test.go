@@ -0,0 +1,100 @@
+package giteabot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keybase/managed-bots/base/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoInput(t *testing.T) {
+	repoURL, repo, err := parseRepoInput("https://Gitea.example.com/Owner/Repo.git")
+	require.NoError(t, err)
+	require.Equal(t, "https://Gitea.example.com/Owner/Repo", repoURL)
+	require.Equal(t, "gitea.example.com/owner/repo", repo)
+
+	repoURL, repo, err = parseRepoInput("codeberg.org/forgejo/forgejo/")
+	require.NoError(t, err)
+	require.Equal(t, "https://codeberg.org/forgejo/forgejo", repoURL)
+	require.Equal(t, "codeberg.org/forgejo/forgejo", repo)
+
+	for _, input := range []string{"owner/repo", "https://gitea.com/owner", "https://gitea.com/owner/repo/issues",
+		"https:gitea.com/owner/repo"} {
+		_, _, err = parseRepoInput(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(payload)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	require.True(t, validateSignature(signature, payload, "secret"))
+	require.False(t, validateSignature(signature, payload, "other secret"))
+	require.False(t, validateSignature(signature, []byte(`{}`), "secret"))
+	require.False(t, validateSignature("", payload, "secret"))
+}
+
+func TestParseEvent(t *testing.T) {
+	cases := []struct {
+		eventType string
+		fixture   string
+		output    string
+	}{
+		{
+			eventType: "push",
+			fixture:   "push.json",
+			output: "alice pushed 2 commits to keybase/client/main:\n- `Update the README`\n- `Fix the build on Windows`\n\n" +
+				"gitea.example.com/keybase/client/compare/28e1879d029cb852e4844d9c718537df08844e03...bffeb74224043ba2feb48d137756c8a9331c449a",
+		},
+		{
+			eventType: "create",
+			fixture:   "create.json",
+			output:    "alice pushed the tag v1.2.0 to keybase/client.\nhttps://gitea.example.com/keybase/client/src/tag/v1.2.0",
+		},
+		{
+			eventType: "issues",
+			fixture:   "issues.json",
+			output:    "bob opened issue #42 on keybase/client: “Crash when opening settings”\nhttps://gitea.example.com/keybase/client/issues/42",
+		},
+		{
+			eventType: "pull_request",
+			fixture:   "pull_request.json",
+			output:    "alice merged pull request #7 into keybase/client/main.\nhttps://gitea.example.com/keybase/client/pulls/7",
+		},
+		{
+			eventType: "issue_comment",
+			fixture:   "issue_comment.json",
+			output: "alice commented on pull request #7 on keybase/client: “Add dark mode”\n" +
+				"> Looks great, could you add a screenshot?\nhttps://gitea.example.com/keybase/client/pulls/7#issuecomment-1203",
+		},
+		{
+			eventType: "release",
+			fixture:   "release.json",
+			output: "alice published the release v1.2.0 (Summer release) on keybase/client: \n- Dark mode\n- Windows build fix\n" +
+				"https://gitea.example.com/keybase/client/releases/tag/v1.2.0",
+		},
+	}
+	for _, c := range cases {
+		payload, err := os.ReadFile(filepath.Join("testdata", c.fixture))
+		require.NoError(t, err)
+		event, err := parseEvent(c.eventType, payload)
+		require.NoError(t, err)
+		require.NotNil(t, event, c.fixture)
+		require.Equal(t, "gitea.example.com/keybase/client", event.Repo)
+		require.Equal(t, c.output, git.Render(event, nil), c.fixture)
+	}
+
+	// branches created with the create event aren't tags
+	event, err := parseEvent("create", []byte(`{"ref":"main","ref_type":"branch",
+		"repository":{"full_name":"keybase/client","html_url":"https://gitea.example.com/keybase/client"}}`))
+	require.NoError(t, err)
+	require.Nil(t, event)
+}
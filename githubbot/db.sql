@@ -38,9 +38,61 @@ CREATE TABLE `features` (
   `commits` boolean NOT NULL DEFAULT 0,
   `statuses` boolean NOT NULL DEFAULT 1,
   `releases` boolean NOT NULL DEFAULT 1,
+  `comments` boolean NOT NULL DEFAULT 0,
+  `tags` boolean NOT NULL DEFAULT 0,
+  `reviews` boolean NOT NULL DEFAULT 0,
+  `deployments` boolean NOT NULL DEFAULT 0,
+  `workflows` boolean NOT NULL DEFAULT 0,
   UNIQUE KEY unique_subscription (`conv_id`, `repo`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
+CREATE TABLE `filter_rules` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `rule` varchar(255) NOT NULL,
+  UNIQUE KEY unique_subscription (`conv_id`, `repo`, `rule`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `check_boards` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `sha` char(40) NOT NULL,
+  `msg_id` int(11) NOT NULL,
+  `checks` text NOT NULL,
+  `expire_time` datetime NOT NULL,
+  PRIMARY KEY (`conv_id`, `repo`, `sha`),
+  KEY `expire_time` (`expire_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `digests` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `frequency` varchar(16) NOT NULL,
+  `at_time` char(5) NOT NULL,
+  `timezone` varchar(64) NOT NULL,
+  PRIMARY KEY (`conv_id`, `repo`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `digest_events` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `event` mediumtext NOT NULL,
+  `ctime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `subscription` (`conv_id`, `repo`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `reminders` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `threshold_secs` int(11) NOT NULL,
+  `weekdays` boolean NOT NULL DEFAULT 1,
+  `at_time` char(5) NOT NULL,
+  `timezone` varchar(64) NOT NULL,
+  PRIMARY KEY (`conv_id`, `repo`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
 CREATE TABLE `user_prefs` (
   `username` varchar(128) NOT NULL,
   `conv_id` char(64) NOT NULL,
@@ -0,0 +1,174 @@
+package githubbot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// parseIssueRef parses `owner/repo#123`.
+func parseIssueRef(ref string) (repo string, number int, err error) {
+	toks := strings.Split(ref, "#")
+	if len(toks) != 2 || len(strings.Split(toks[0], "/")) != 2 {
+		return "", 0, fmt.Errorf("`%s` doesn't look like `<owner/repo>#<number>`", ref)
+	}
+	number, err = strconv.Atoi(toks[1])
+	if err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("`%s` doesn't look like `<owner/repo>#<number>`", ref)
+	}
+	return strings.ToLower(toks[0]), number, nil
+}
+
+// handleAction runs `!github issue create|comment|close|reopen|label|merge`
+// with the sender's own token, after the same checks as subscribing.
+func (h *Handler) handleAction(ctx context.Context, action string, msg chat1.MsgSummary) (err error) {
+	switch action {
+	case "issue", "comment", "close", "reopen", "label", "merge":
+	default:
+		h.Debug("ignoring unknown command %q", action)
+		return nil
+	}
+	// titles and comments keep their case, so the original message is parsed
+	toks, userErr, err := base.SplitTokens(strings.TrimSpace(msg.Content.Text.Body))
+	if err != nil {
+		return err
+	} else if userErr != "" {
+		h.ChatEcho(msg.ConvID, "%s", userErr)
+		return nil
+	}
+	args := toks[2:]
+	if action == "issue" {
+		if len(args) < 1 || strings.ToLower(args[0]) != "create" {
+			h.ChatEcho(msg.ConvID, "I don't understand! Try `!github issue create <owner/repo> \"title\" \"body\"`")
+			return nil
+		}
+		action, args = "create", args[1:]
+	}
+
+	var usage string
+	switch action {
+	case "create":
+		usage = "`!github issue create <owner/repo> \"title\" \"body\"`"
+		if len(args) != 2 && len(args) != 3 {
+			h.ChatEcho(msg.ConvID, "I don't understand! Try %s", usage)
+			return nil
+		}
+	case "comment":
+		usage = "`!github comment <owner/repo>#123 \"text\"`"
+		if len(args) != 2 {
+			h.ChatEcho(msg.ConvID, "I don't understand! Try %s", usage)
+			return nil
+		}
+	case "label":
+		usage = "`!github label <owner/repo>#123 <label>`"
+		if len(args) < 2 {
+			h.ChatEcho(msg.ConvID, "I don't understand! Try %s", usage)
+			return nil
+		}
+	default:
+		usage = fmt.Sprintf("`!github %s <owner/repo>#123`", action)
+		if len(args) != 1 {
+			h.ChatEcho(msg.ConvID, "I don't understand! Try %s", usage)
+			return nil
+		}
+	}
+
+	var repo string
+	var number int
+	if action == "create" {
+		repo = strings.ToLower(args[0])
+		if git.IsRepoPattern(repo) || len(strings.Split(repo, "/")) != 2 {
+			h.ChatEcho(msg.ConvID, "`%s` doesn't look like a repository to me! Try %s", args[0], usage)
+			return nil
+		}
+	} else if repo, number, err = parseIssueRef(args[0]); err != nil {
+		h.ChatEcho(msg.ConvID, "%s! Try %s", err, usage)
+		return nil
+	}
+
+	isAllowed, err := base.IsAtLeastWriter(h.kbc, msg.Sender.Username, msg.Channel)
+	if err != nil {
+		return fmt.Errorf("Error getting role status: %s", err)
+	}
+	if !isAllowed {
+		h.ChatEcho(msg.ConvID, "You must be at least a writer to act on GitHub through me!")
+		return nil
+	}
+	userClient, _, err := h.authorizeUser(ctx, repo, "act on", msg, h.client)
+	if err != nil {
+		if _, ok := err.(base.OAuthRequiredError); ok {
+			return nil
+		}
+		return err
+	} else if userClient == nil {
+		return nil
+	}
+
+	parsedRepo := strings.Split(repo, "/")
+	owner, name := parsedRepo[0], parsedRepo[1]
+
+	switch action {
+	case "create":
+		req := &github.IssueRequest{Title: github.String(args[1])}
+		if len(args) == 3 {
+			req.Body = github.String(args[2])
+		}
+		issue, _, err := userClient.Issues.Create(ctx, owner, name, req)
+		if err != nil {
+			return h.handleActionError(msg, "create the issue", err)
+		}
+		h.ChatEcho(msg.ConvID, "Opened issue #%d on %s: “%s”\n%s", issue.GetNumber(), repo, issue.GetTitle(), issue.GetHTMLURL())
+	case "comment":
+		comment, _, err := userClient.Issues.CreateComment(ctx, owner, name, number, &github.IssueComment{
+			Body: github.String(args[1]),
+		})
+		if err != nil {
+			return h.handleActionError(msg, "comment", err)
+		}
+		h.ChatEcho(msg.ConvID, "Commented on %s#%d.\n%s", repo, number, comment.GetHTMLURL())
+	case "close", "reopen":
+		state, done := "closed", "Closed"
+		if action == "reopen" {
+			state, done = "open", "Reopened"
+		}
+		issue, _, err := userClient.Issues.Edit(ctx, owner, name, number, &github.IssueRequest{State: github.String(state)})
+		if err != nil {
+			return h.handleActionError(msg, action+" it", err)
+		}
+		h.ChatEcho(msg.ConvID, "%s %s#%d: “%s”", done, repo, number, issue.GetTitle())
+	case "label":
+		labels, _, err := userClient.Issues.AddLabelsToIssue(ctx, owner, name, number, args[1:])
+		if err != nil {
+			return h.handleActionError(msg, "label it", err)
+		}
+		h.ChatEcho(msg.ConvID, "%s#%d is now labeled %s.", repo, number, strings.Join(getLabelNames(labels), ", "))
+	case "merge":
+		res, _, err := userClient.PullRequests.Merge(ctx, owner, name, number, "", nil)
+		if err != nil {
+			return h.handleActionError(msg, "merge it", err)
+		}
+		if !res.GetMerged() {
+			h.ChatEcho(msg.ConvID, "Unable to merge %s#%d: %s", repo, number, res.GetMessage())
+			return nil
+		}
+		h.ChatEcho(msg.ConvID, "Merged %s#%d.", repo, number)
+	}
+	return nil
+}
+
+// handleActionError tells the sender why GitHub refused the action, other
+// errors are returned.
+func (h *Handler) handleActionError(msg chat1.MsgSummary, action string, err error) error {
+	errResp, ok := err.(*github.ErrorResponse)
+	if !ok {
+		return err
+	}
+	h.ChatEcho(msg.ConvID, "Unable to %s, GitHub said: %s", action, errResp.Message)
+	return nil
+}
@@ -0,0 +1,19 @@
+package githubbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIssueRef(t *testing.T) {
+	repo, number, err := parseIssueRef("Keybase/Client#123")
+	require.NoError(t, err)
+	require.Equal(t, "keybase/client", repo)
+	require.Equal(t, 123, number)
+
+	for _, ref := range []string{"keybase/client", "client#1", "keybase/client#", "keybase/client#-1", "a/b/c#1"} {
+		_, _, err = parseIssueRef(ref)
+		require.Error(t, err, ref)
+	}
+}
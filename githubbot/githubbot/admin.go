@@ -0,0 +1,19 @@
+package githubbot
+
+import (
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+func init() {
+	base.RegisterAdminTables("githubbot", append(base.OAuthAdminTables(),
+		base.AdminTable{Name: "subscriptions", ConvColumn: "conv_id", KeyColumns: []string{"repo", "installation_id"}},
+		base.AdminTable{Name: "user_prefs", ConvColumn: "conv_id", KeyColumns: []string{"username"}},
+		base.AdminTable{Name: "check_boards", ConvColumn: "conv_id", KeyColumns: []string{"repo", "sha"}},
+		base.AdminTable{Name: "digests", ConvColumn: "conv_id", KeyColumns: []string{"repo"}},
+		base.AdminTable{Name: "digest_events", ConvColumn: "conv_id", KeyColumns: []string{"repo", "id"}},
+		base.AdminTable{Name: "reminders", ConvColumn: "conv_id", KeyColumns: []string{"repo"}},
+	)...)
+	base.RegisterAdminTables("githubbot", git.FilterAdminTables()...)
+	base.RegisterAdminTables("githubbot", base.OnboardingAdminTable("githubbot"))
+}
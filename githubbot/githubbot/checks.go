@@ -0,0 +1,151 @@
+package githubbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// checkBoardExpiry is how long the checks of a commit keep being edited into
+// the same message, later ones start a new one.
+const checkBoardExpiry = 24 * time.Hour
+
+type boardCheck struct {
+	Name         string   `json:"name"`
+	State        string   `json:"state"`
+	URL          string   `json:"url,omitempty"`
+	FailedChecks []string `json:"failed_checks,omitempty"`
+}
+
+// checkBoard is the message showing the state of every check of a commit
+// in a conversation.
+type checkBoard struct {
+	MsgID  chat1.MessageID
+	Checks []boardCheck
+}
+
+func (b *checkBoard) update(event *git.Event) {
+	check := boardCheck{
+		Name:         event.CheckName,
+		State:        event.CheckState,
+		URL:          event.URL,
+		FailedChecks: event.FailedChecks,
+	}
+	for i, existing := range b.Checks {
+		if existing.Name == check.Name {
+			b.Checks[i] = check
+			return
+		}
+	}
+	b.Checks = append(b.Checks, check)
+}
+
+func checkStateEmoji(state string) string {
+	switch state {
+	case "success":
+		return ":white_check_mark:"
+	case "failure":
+		return ":x:"
+	case "cancelled":
+		return ":warning:"
+	default:
+		return ":hourglass_flowing_sand:"
+	}
+}
+
+func formatCheckBoard(event *git.Event, checks []boardCheck, author string) string {
+	commit := event.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	subject := fmt.Sprintf("%s/%s", event.RepoName, event.Branch)
+	if event.IsPullRequest {
+		subject = fmt.Sprintf("pull request #%d on %s", event.Number, event.RepoName)
+	}
+	res := fmt.Sprintf("Checks for %s at `%s`:", subject, commit)
+	for _, check := range checks {
+		name := "Tests"
+		if check.Name != "" {
+			name = fmt.Sprintf("*%s*", check.Name)
+		}
+		res += fmt.Sprintf("\n%s %s", checkStateEmoji(check.State), name)
+		if check.State != "failure" {
+			continue
+		}
+		if len(check.FailedChecks) > 0 {
+			res += fmt.Sprintf(" (failed: %s)", strings.Join(check.FailedChecks, ", "))
+		}
+		// failures link to the logs, skip the unfurl prompt
+		if url := strings.Split(check.URL, "://"); len(url) == 2 {
+			res += " " + url[1]
+		}
+	}
+	if event.IsPullRequest && event.Author != "" {
+		if strings.HasPrefix(author, "@") {
+			res += "\n" + author
+		} else {
+			res += fmt.Sprintf("\n(for %s)", author)
+		}
+	}
+	return res
+}
+
+// updateCheckBoards edits the check into the board of its commit in each of
+// the conversations, posting the board if there's none yet. The board is
+// updated in the database first, so concurrent checks of the same commit,
+// even on other instances, add to one board, and only the check which created
+// it posts it.
+func (h *HTTPSrv) updateCheckBoards(ctx context.Context, event *git.Event, convIDs []chat1.ConvIDStr) {
+	for _, convID := range convIDs {
+		board, created, err := h.db.UpdateCheckBoard(ctx, convID, event.Repo, event.Commit, event,
+			time.Now().Add(checkBoardExpiry))
+		if err != nil {
+			h.Errorf("unable to update check board: %s", err)
+			continue
+		}
+		if !created && board.MsgID == 0 {
+			// the check which created the board posts it, including this one
+			continue
+		}
+		var author string
+		if event.Author != "" {
+			author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.Author, convID).String()
+		}
+		text := formatCheckBoard(event, board.Checks, author)
+		h.Stats.Count("check board")
+		if !created {
+			_, err := h.kbc.EditByConvID(convID, board.MsgID, text)
+			if err == nil {
+				continue
+			}
+			h.Debug("unable to edit check board, posting a new one: %s", err)
+		}
+		res, err := h.kbc.SendMessageByConvID(convID, "%s", text)
+		if err == nil && res.Result.MessageID == nil {
+			err = fmt.Errorf("no message ID")
+		}
+		if err != nil {
+			h.Errorf("unable to send check board: %s", err)
+			if created {
+				if err := h.db.DeleteCheckBoard(ctx, convID, event.Repo, event.Commit); err != nil {
+					h.Errorf("unable to delete check board: %s", err)
+				}
+			}
+			continue
+		}
+		if board, err = h.db.SetCheckBoardMsgID(ctx, convID, event.Repo, event.Commit, *res.Result.MessageID); err != nil {
+			h.Errorf("unable to save check board: %s", err)
+			continue
+		}
+		// checks which came in while posting were only saved
+		if updated := formatCheckBoard(event, board.Checks, author); updated != text {
+			if _, err := h.kbc.EditByConvID(convID, board.MsgID, updated); err != nil {
+				h.Errorf("unable to edit check board: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package githubbot
+
+import (
+	"testing"
+
+	"github.com/keybase/managed-bots/base/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBoard(t *testing.T) {
+	board := &checkBoard{}
+	event := &git.Event{Kind: git.EventCheck, RepoName: "client", Branch: "master",
+		Commit: "bffeb74224043ba2feb48d137756c8a9331c449a", CheckName: "build", CheckState: "pending"}
+	board.update(event)
+	event.CheckName = "lint"
+	board.update(event)
+	require.Equal(t, "Checks for client/master at `bffeb74`:\n:hourglass_flowing_sand: *build*\n:hourglass_flowing_sand: *lint*",
+		formatCheckBoard(event, board.Checks, ""))
+
+	event.CheckState = "failure"
+	event.URL = "https://github.com/keybase/client/runs/1"
+	board.update(event)
+	event.IsPullRequest = true
+	event.Number = 3
+	event.Author = "alice"
+	require.Equal(t, "Checks for pull request #3 on client at `bffeb74`:\n:hourglass_flowing_sand: *build*\n"+
+		":x: *lint* github.com/keybase/client/runs/1\n@alice",
+		formatCheckBoard(event, board.Checks, "@alice"))
+}
@@ -1,28 +1,34 @@
 package githubbot
 
 import (
+	"context"
 	"database/sql"
-	"strings"
+	"encoding/json"
+	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 	"golang.org/x/oauth2"
 )
 
 type DB struct {
 	*base.BaseOAuthDB
+	*git.Store
 }
 
 func NewDB(db *sql.DB) *DB {
+	oauthDB := base.NewBaseOAuthDB(db)
 	return &DB{
-		BaseOAuthDB: base.NewBaseOAuthDB(db),
+		BaseOAuthDB: oauthDB,
+		Store:       git.NewStore(oauthDB.DB),
 	}
 }
 
 // webhook subscription methods
 
-func (d *DB) CreateSubscription(convID chat1.ConvIDStr, repo string, installationID int64) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CreateSubscription(ctx context.Context, convID chat1.ConvIDStr, repo string, installationID int64) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO subscriptions
 			(conv_id, repo, installation_id)
@@ -35,8 +41,8 @@ func (d *DB) CreateSubscription(convID chat1.ConvIDStr, repo string, installatio
 	})
 }
 
-func (d *DB) DeleteSubscription(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteSubscription(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM subscriptions
 			WHERE conv_id = ? AND repo = ?
@@ -45,30 +51,8 @@ func (d *DB) DeleteSubscription(convID chat1.ConvIDStr, repo string) error {
 	})
 }
 
-func (d *DB) WatchBranch(convID chat1.ConvIDStr, repo string, branch string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
-		_, err := tx.Exec(`
-			INSERT IGNORE INTO branches
-			(conv_id, repo, branch)
-			VALUES
-			(?, ?, ?)
-		`, convID, repo, branch)
-		return err
-	})
-}
-
-func (d *DB) UnwatchBranch(convID chat1.ConvIDStr, repo string, branch string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
-		_, err := tx.Exec(`
-			DELETE FROM branches
-			WHERE conv_id = ? AND repo = ? AND branch = ?
-		`, convID, repo, branch)
-		return err
-	})
-}
-
-func (d *DB) DeleteSubscriptionsForRepo(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteSubscriptionsForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM subscriptions
 			WHERE conv_id = ? AND repo = ?
@@ -77,54 +61,39 @@ func (d *DB) DeleteSubscriptionsForRepo(convID chat1.ConvIDStr, repo string) err
 	})
 }
 
-func (d *DB) DeleteBranchesForRepo(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
-		_, err := tx.Exec(`
-			DELETE FROM subscriptions
-			WHERE conv_id = ? AND repo = ?
-		`, convID, repo)
-		return err
-	})
-}
-
-func (d *DB) GetConvIDsFromRepoInstallation(repo string, installationID int64) (res []chat1.ConvIDStr, err error) {
+// GetSubscriptionsForRepoInstallation returns the subscriptions to the repo
+// or to a pattern matching it, one per conversation. A conversation
+// subscribed to both gets the repo's own subscription.
+func (d *DB) GetSubscriptionsForRepoInstallation(repo string, installationID int64) (res []git.Subscription, err error) {
 	rows, err := d.DB.Query(`
-		SELECT conv_id
+		SELECT conv_id, repo
 		FROM subscriptions
-		WHERE repo = ? AND installation_id = ?
-		GROUP BY conv_id
+		WHERE (repo = ? OR repo LIKE '%*%') AND installation_id = ?
+		ORDER BY conv_id
 	`, repo, installationID)
 	if err != nil {
 		return res, err
 	}
 	defer rows.Close()
+	byConvID := map[chat1.ConvIDStr]int{}
 	for rows.Next() {
-		var convID chat1.ConvIDStr
-		if err := rows.Scan(&convID); err != nil {
+		var sub git.Subscription
+		if err := rows.Scan(&sub.ConvID, &sub.Repo); err != nil {
 			return res, err
 		}
-		res = append(res, convID)
-	}
-	return res, nil
-}
-
-func (d *DB) GetSubscriptionForBranchExists(convID chat1.ConvIDStr, repo string, branch string) (exists bool, err error) {
-	row := d.DB.QueryRow(`
-	SELECT 1
-	FROM branches
-	WHERE conv_id = ? AND repo = ? AND branch = ?
-	GROUP BY conv_id
-	`, convID, repo, branch)
-	var rowRes string
-	scanErr := row.Scan(&rowRes)
-	switch scanErr {
-	case sql.ErrNoRows:
-		return false, nil
-	case nil:
-		return true, nil
-	default:
-		return false, scanErr
+		if !git.MatchRepo(sub.Repo, repo) {
+			continue
+		}
+		if i, ok := byConvID[sub.ConvID]; ok {
+			if !git.IsRepoPattern(sub.Repo) {
+				res[i] = sub
+			}
+			continue
+		}
+		byConvID[sub.ConvID] = len(res)
+		res = append(res, sub)
 	}
+	return res, rows.Err()
 }
 
 func (d *DB) GetSubscriptionForRepoExists(convID chat1.ConvIDStr, repo string) (exists bool, err error) {
@@ -145,129 +114,52 @@ func (d *DB) GetSubscriptionForRepoExists(convID chat1.ConvIDStr, repo string) (
 	}
 }
 
-func (d *DB) GetAllBranchesForRepo(convID chat1.ConvIDStr, repo string) ([]string, error) {
-	rows, err := d.DB.Query(`SELECT branch
-		FROM branches
-		WHERE conv_id = ? AND repo = ?`, convID, repo)
+// GetSubscriptionInstallationID returns 0 if the conversation isn't
+// subscribed to the repo, or to a pattern matching it.
+func (d *DB) GetSubscriptionInstallationID(convID chat1.ConvIDStr, repo string) (installationID int64, err error) {
+	rows, err := d.DB.Query(`
+	SELECT repo, installation_id
+	FROM subscriptions
+	WHERE conv_id = ? AND (repo = ? OR repo LIKE '%*%')
+	`, convID, repo)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	res := []string{}
 	defer rows.Close()
 	for rows.Next() {
-		var branch string
-		if err := rows.Scan(&branch); err != nil {
-			return res, err
+		var pattern string
+		var id int64
+		if err := rows.Scan(&pattern, &id); err != nil {
+			return 0, err
+		}
+		if git.MatchRepo(pattern, repo) {
+			installationID = id
 		}
-		res = append(res, branch)
-	}
-	return res, nil
-}
-
-// subscription preferences
-
-type Features struct {
-	Issues       bool
-	PullRequests bool
-	Commits      bool
-	Statuses     bool
-	Releases     bool
-}
-
-func (f *Features) String() string {
-	if f == nil {
-		return "all events"
-	}
-	var res []string
-	if f.Issues {
-		res = append(res, "issues")
-	}
-	if f.PullRequests {
-		res = append(res, "pull requests")
-	}
-	if f.Commits {
-		res = append(res, "commits")
-	}
-	if f.Statuses {
-		res = append(res, "commit statuses")
-	}
-	if f.Releases {
-		res = append(res, "releases")
-	}
-	if len(res) == 0 {
-		return "no events"
-	} else if len(res) == 5 {
-		return "all events"
-	}
-	return strings.Join(res, ", ")
-}
-
-func (d *DB) SetFeatures(convID chat1.ConvIDStr, repo string, features *Features) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
-		_, err := tx.Exec(`
-			INSERT INTO features
-			(conv_id, repo, issues, pull_requests, commits, statuses, releases)
-			VALUES
-			(?, ?, ?, ?, ?, ?, ?)
-			ON DUPLICATE KEY UPDATE
-			issues=VALUES(issues),
-			pull_requests=VALUES(pull_requests),
-			commits=VALUES(commits),
-			statuses=VALUES(statuses),
-			releases=VALUES(releases)
-		`, convID, repo, features.Issues, features.PullRequests, features.Commits, features.Statuses, features.Releases)
-		return err
-	})
-}
-
-func (d *DB) GetFeatures(convID chat1.ConvIDStr, repo string) (*Features, error) {
-	row := d.DB.QueryRow(`SELECT issues, pull_requests, commits, statuses, releases
-		FROM features
-		WHERE conv_id = ? AND repo = ?`, convID, repo)
-	features := &Features{}
-	err := row.Scan(&features.Issues, &features.PullRequests, &features.Commits, &features.Statuses, &features.Releases)
-	switch err {
-	case nil:
-		return features, nil
-	case sql.ErrNoRows:
-		return nil, nil
-	default:
-		return nil, err
 	}
+	return installationID, rows.Err()
 }
 
-func (d *DB) GetFeaturesForAllRepos(convID chat1.ConvIDStr) (map[string]Features, error) {
-	rows, err := d.DB.Query(`SELECT repo, COALESCE(issues, true), COALESCE(pull_requests, true),
-		COALESCE(commits, true), COALESCE(statuses, true), COALESCE(releases, true)
+func (d *DB) GetAllSubscriptionsForConvID(convID chat1.ConvIDStr) (res []string, err error) {
+	rows, err := d.DB.Query(`
+		SELECT repo
 		FROM subscriptions
-		LEFT JOIN features USING(conv_id, repo)
-		WHERE conv_id = ?`, convID)
+		WHERE conv_id = ?
+		ORDER BY repo
+	`, convID)
 	if err != nil {
 		return nil, err
 	}
-	res := make(map[string]Features)
 	defer rows.Close()
 	for rows.Next() {
 		var repo string
-		var features Features
-		if err := rows.Scan(&repo, &features.Issues, &features.PullRequests, &features.Commits, &features.Statuses, &features.Releases); err != nil {
-			return nil, err
+		if err := rows.Scan(&repo); err != nil {
+			return res, err
 		}
-		res[repo] = features
+		res = append(res, repo)
 	}
 	return res, nil
 }
 
-func (d *DB) DeleteFeaturesForRepo(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
-		_, err := tx.Exec(`
-			DELETE FROM features
-			WHERE conv_id = ? AND repo = ?
-		`, convID, repo)
-		return err
-	})
-}
-
 // OAuth2 token methods
 
 func (d *DB) GetToken(identifier string) (*oauth2.Token, error) {
@@ -286,8 +178,8 @@ func (d *DB) GetToken(identifier string) (*oauth2.Token, error) {
 	}
 }
 
-func (d *DB) PutToken(identifier string, token *oauth2.Token) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) PutToken(ctx context.Context, identifier string, token *oauth2.Token) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`INSERT INTO oauth
 		(identifier, access_token, token_type, ctime, mtime)
 		VALUES (?, ?, ?, NOW(), NOW())
@@ -300,8 +192,8 @@ func (d *DB) PutToken(identifier string, token *oauth2.Token) error {
 	return err
 }
 
-func (d *DB) DeleteToken(identifier string) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteToken(ctx context.Context, identifier string) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec("DELETE FROM oauth WHERE identifier = ?", identifier)
 		return err
 	})
@@ -333,8 +225,8 @@ func (d *DB) GetUserPreferences(username string, convID chat1.ConvIDStr) (*UserP
 	}
 }
 
-func (d *DB) SetUserPreferences(username string, convID chat1.ConvIDStr, prefs *UserPreferences) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) SetUserPreferences(ctx context.Context, username string, convID chat1.ConvIDStr, prefs *UserPreferences) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`INSERT INTO user_prefs
 		(username, conv_id, mention)
 		VALUES (?, ?, ?)
@@ -346,6 +238,237 @@ func (d *DB) SetUserPreferences(username string, convID chat1.ConvIDStr, prefs *
 	return err
 }
 
+// check boards
+
+// selectCheckBoard reads the board, locking its row until the transaction
+// ends.
+func selectCheckBoard(tx *sql.Tx, convID chat1.ConvIDStr, repo string, sha string) (*checkBoard, error) {
+	row := tx.QueryRow(`SELECT msg_id, checks
+		FROM check_boards
+		WHERE conv_id = ? AND repo = ? AND sha = ?
+		FOR UPDATE`, convID, repo, sha)
+	board := &checkBoard{}
+	var checks string
+	if err := row.Scan(&board.MsgID, &checks); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(checks), &board.Checks); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// UpdateCheckBoard edits the event's check into the commit's board, creating
+// the board if there's none or it expired, deleting the expired ones. created
+// is true for the one caller which created it, and is left to post it.
+func (d *DB) UpdateCheckBoard(ctx context.Context, convID chat1.ConvIDStr, repo string, sha string, event *git.Event,
+	expireTime time.Time) (board *checkBoard, created bool, err error) {
+	err = d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM check_boards WHERE expire_time <= NOW()`); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`INSERT IGNORE INTO check_boards
+		(conv_id, repo, sha, msg_id, checks, expire_time)
+		VALUES (?, ?, ?, 0, '[]', ?)
+	`, convID, repo, sha, expireTime)
+		if err != nil {
+			return err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		created = inserted > 0
+		if board, err = selectCheckBoard(tx, convID, repo, sha); err != nil {
+			return err
+		}
+		board.update(event)
+		checks, err := json.Marshal(board.Checks)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE check_boards
+		SET checks = ?
+		WHERE conv_id = ? AND repo = ? AND sha = ?
+	`, string(checks), convID, repo, sha)
+		return err
+	})
+	return board, created, err
+}
+
+// SetCheckBoardMsgID records the message the board was posted as, returning
+// the board as it is now, which may have had checks added since it was
+// posted.
+func (d *DB) SetCheckBoardMsgID(ctx context.Context, convID chat1.ConvIDStr, repo string, sha string,
+	msgID chat1.MessageID) (board *checkBoard, err error) {
+	err = d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		if board, err = selectCheckBoard(tx, convID, repo, sha); err != nil {
+			return err
+		}
+		board.MsgID = msgID
+		_, err = tx.Exec(`UPDATE check_boards
+		SET msg_id = ?
+		WHERE conv_id = ? AND repo = ? AND sha = ?
+	`, msgID, convID, repo, sha)
+		return err
+	})
+	return board, err
+}
+
+// DeleteCheckBoard forgets a board which couldn't be posted, so the next
+// check creates it again.
+func (d *DB) DeleteCheckBoard(ctx context.Context, convID chat1.ConvIDStr, repo string, sha string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM check_boards
+		WHERE conv_id = ? AND repo = ? AND sha = ?
+	`, convID, repo, sha)
+		return err
+	})
+}
+
+// digests
+
+// GetDigestSchedule returns nil if the subscription is notified immediately.
+func (d *DB) GetDigestSchedule(convID chat1.ConvIDStr, repo string) (*digestSchedule, error) {
+	row := d.DB.QueryRow(`SELECT frequency, at_time, timezone
+		FROM digests
+		WHERE conv_id = ? AND repo = ?`, convID, repo)
+	schedule := &digestSchedule{}
+	err := row.Scan(&schedule.Frequency, &schedule.At, &schedule.Timezone)
+	switch err {
+	case nil:
+		return schedule, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (d *DB) SetDigestSchedule(ctx context.Context, convID chat1.ConvIDStr, repo string, schedule *digestSchedule) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO digests
+		(conv_id, repo, frequency, at_time, timezone)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		frequency=VALUES(frequency),
+		at_time=VALUES(at_time),
+		timezone=VALUES(timezone)
+	`, convID, repo, schedule.Frequency, schedule.At, schedule.Timezone)
+		return err
+	})
+}
+
+// DeleteDigestSchedule goes back to immediate notifications, the queued
+// events are kept so they can still be sent.
+func (d *DB) DeleteDigestSchedule(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM digests WHERE conv_id = ? AND repo = ?`, convID, repo)
+		return err
+	})
+}
+
+func (d *DB) AddDigestEvent(ctx context.Context, convID chat1.ConvIDStr, repo string, event *git.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO digest_events
+		(conv_id, repo, event, ctime)
+		VALUES (?, ?, ?, NOW())
+	`, convID, repo, string(data))
+		return err
+	})
+}
+
+// GetDigestEvents returns the queued events of the subscription in the order
+// they happened, and the ID of the last one.
+func (d *DB) GetDigestEvents(convID chat1.ConvIDStr, repo string) (lastID int64, res []*git.Event, err error) {
+	rows, err := d.DB.Query(`SELECT id, event
+		FROM digest_events
+		WHERE conv_id = ? AND repo = ?
+		ORDER BY id`, convID, repo)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&lastID, &data); err != nil {
+			return 0, nil, err
+		}
+		var event git.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return 0, nil, err
+		}
+		res = append(res, &event)
+	}
+	return lastID, res, rows.Err()
+}
+
+// DeleteDigestEvents deletes the queued events up to lastID, the ones queued
+// while the digest was sent are kept for the next one.
+func (d *DB) DeleteDigestEvents(ctx context.Context, convID chat1.ConvIDStr, repo string, lastID int64) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM digest_events
+		WHERE conv_id = ? AND repo = ? AND id <= ?`, convID, repo, lastID)
+		return err
+	})
+}
+
+// DeleteDigestEventsForRepo deletes all of the subscription's queued events.
+func (d *DB) DeleteDigestEventsForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM digest_events
+		WHERE conv_id = ? AND repo = ?`, convID, repo)
+		return err
+	})
+}
+
+// review reminders
+
+// GetReminderSchedule returns nil if the subscription has no reminders.
+func (d *DB) GetReminderSchedule(convID chat1.ConvIDStr, repo string) (*reminderSchedule, error) {
+	row := d.DB.QueryRow(`SELECT threshold_secs, weekdays, at_time, timezone
+		FROM reminders
+		WHERE conv_id = ? AND repo = ?`, convID, repo)
+	schedule := &reminderSchedule{}
+	var thresholdSecs int64
+	err := row.Scan(&thresholdSecs, &schedule.Weekdays, &schedule.At, &schedule.Timezone)
+	switch err {
+	case nil:
+		schedule.Threshold = time.Duration(thresholdSecs) * time.Second
+		return schedule, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (d *DB) SetReminderSchedule(ctx context.Context, convID chat1.ConvIDStr, repo string, schedule *reminderSchedule) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO reminders
+		(conv_id, repo, threshold_secs, weekdays, at_time, timezone)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		threshold_secs=VALUES(threshold_secs),
+		weekdays=VALUES(weekdays),
+		at_time=VALUES(at_time),
+		timezone=VALUES(timezone)
+	`, convID, repo, int64(schedule.Threshold.Seconds()), schedule.Weekdays, schedule.At, schedule.Timezone)
+		return err
+	})
+}
+
+func (d *DB) DeleteReminderSchedule(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM reminders WHERE conv_id = ? AND repo = ?`, convID, repo)
+		return err
+	})
+}
+
 // util
 type DBSubscription struct {
 	ConvID         chat1.ConvIDStr
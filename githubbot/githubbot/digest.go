@@ -0,0 +1,296 @@
+package githubbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// DigestJobName is the scheduler job sending the digest of a subscription.
+const DigestJobName = "githubbot.digest"
+
+type digestFrequency string
+
+const (
+	digestImmediate digestFrequency = "immediate"
+	digestHourly    digestFrequency = "hourly"
+	digestDaily     digestFrequency = "daily"
+)
+
+// digestSchedule is when a subscription in a digest mode gets its events,
+// subscriptions without one are notified immediately.
+type digestSchedule struct {
+	Frequency digestFrequency
+	// At is the "HH:MM" daily digests are sent at.
+	At       string
+	Timezone string
+}
+
+// parseDigestSchedule parses `immediate|hourly|daily [HH:MM] [timezone]`, it
+// returns nil for immediate.
+func parseDigestSchedule(args []string) (*digestSchedule, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected one of `immediate`, `hourly` or `daily`")
+	}
+	schedule := &digestSchedule{
+		Frequency: digestFrequency(strings.ToLower(args[0])),
+		At:        "09:00",
+		Timezone:  "UTC",
+	}
+	args = args[1:]
+	switch schedule.Frequency {
+	case digestImmediate:
+		if len(args) > 0 {
+			return nil, fmt.Errorf("immediate notifications don't take a time")
+		}
+		return nil, nil
+	case digestHourly, digestDaily:
+	default:
+		return nil, fmt.Errorf("expected one of `immediate`, `hourly` or `daily`")
+	}
+	if len(args) > 0 && schedule.Frequency == digestHourly && strings.Contains(args[0], ":") {
+		return nil, fmt.Errorf("hourly digests don't take a time")
+	}
+	if err := parseTimeAndZone(args, &schedule.At, &schedule.Timezone); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s digestSchedule) cronSpec() string {
+	if s.Frequency == digestHourly {
+		return fmt.Sprintf("CRON_TZ=%s 0 * * * *", s.Timezone)
+	}
+	at, _ := time.Parse("15:04", s.At)
+	return fmt.Sprintf("CRON_TZ=%s %d %d * * *", s.Timezone, at.Minute(), at.Hour())
+}
+
+func (s digestSchedule) String() string {
+	if s.Frequency == digestHourly {
+		return "an hourly digest"
+	}
+	return fmt.Sprintf("a daily digest at %s (%s)", s.At, s.Timezone)
+}
+
+type digestJobPayload struct {
+	ConvID chat1.ConvIDStr
+	Repo   string
+}
+
+// queueDigests stores the event for the subscriptions in a digest mode, it
+// returns the conversations to notify immediately.
+func (h *HTTPSrv) queueDigests(ctx context.Context, event *git.Event, subs []git.Subscription) (immediate []chat1.ConvIDStr) {
+	for _, sub := range subs {
+		schedule, err := h.db.GetDigestSchedule(sub.ConvID, sub.Repo)
+		if err != nil {
+			h.Errorf("unable to get digest schedule: %s", err)
+			immediate = append(immediate, sub.ConvID)
+			continue
+		}
+		if schedule == nil {
+			immediate = append(immediate, sub.ConvID)
+			continue
+		}
+		if git.Render(event, nil) == "" {
+			// not worth a notification, so not worth a digest line either
+			continue
+		}
+		if err := h.db.AddDigestEvent(ctx, sub.ConvID, sub.Repo, event); err != nil {
+			h.Errorf("unable to queue digest event: %s", err)
+			continue
+		}
+		h.Stats.Count("digest - queued")
+	}
+	return immediate
+}
+
+// RunDigestJob sends the digest of the subscription in the job's payload.
+func (h *Handler) RunDigestJob(job base.Job) error {
+	var payload digestJobPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	schedule, err := h.db.GetDigestSchedule(payload.ConvID, payload.Repo)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		// the subscription is gone or back to immediate notifications
+		return h.scheduler.Cancel(job.ID)
+	}
+	title := fmt.Sprintf("Daily digest for %s:", formatRepo(payload.Repo))
+	if schedule.Frequency == digestHourly {
+		title = fmt.Sprintf("Hourly digest for %s:", formatRepo(payload.Repo))
+	}
+	return h.sendDigest(context.Background(), payload.ConvID, payload.Repo, title)
+}
+
+// sendDigest sends the queued events of the subscription, if any.
+func (h *Handler) sendDigest(ctx context.Context, convID chat1.ConvIDStr, repo, title string) error {
+	lastID, events, err := h.db.GetDigestEvents(convID, repo)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	err = base.SendLongMessageByConvID(h.kbc, h.DebugOutput, convID, "", formatDigest(title, events))
+	if h.CheckDeletedConv(convID, err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	h.stats.Count("digest - sent")
+	return h.db.DeleteDigestEvents(ctx, convID, repo, lastID)
+}
+
+// digestLine collects the references of one kind of event, such as the pull
+// requests which were merged.
+type digestLine struct {
+	singular, plural string
+	refs             []string
+	count            int
+}
+
+func (l *digestLine) add(ref string) {
+	l.count++
+	for _, existing := range l.refs {
+		if existing == ref {
+			return
+		}
+	}
+	if ref != "" {
+		l.refs = append(l.refs, ref)
+	}
+}
+
+func (l *digestLine) String() string {
+	noun := l.singular
+	if l.count != 1 {
+		noun = l.plural
+	}
+	res := fmt.Sprintf("• %d %s", l.count, noun)
+	if len(l.refs) > 0 {
+		res += ": " + strings.Join(l.refs, ", ")
+	}
+	return res
+}
+
+// formatDigest summarizes the events, in the order they happened. The repo
+// of each reference is shown when the events are from several repos, as for
+// subscriptions to a pattern.
+func formatDigest(title string, events []*git.Event) string {
+	var multiRepo bool
+	for _, event := range events {
+		if !strings.EqualFold(event.Repo, events[0].Repo) {
+			multiRepo = true
+			break
+		}
+	}
+
+	lines := map[string]*digestLine{}
+	var order []string
+	line := func(key, singular, plural string) *digestLine {
+		if _, ok := lines[key]; !ok {
+			lines[key] = &digestLine{singular: singular, plural: plural}
+			order = append(order, key)
+		}
+		return lines[key]
+	}
+
+	type checkKey struct{ name, target string }
+	checkStates := map[checkKey]string{}
+	var checkOrder []checkKey
+	deployStates := map[string]string{}
+	var deployOrder []string
+
+	for _, event := range events {
+		ref := fmt.Sprintf("#%d", event.Number)
+		branch, tag, env := event.Branch, event.Tag, event.Environment
+		if multiRepo {
+			ref = event.Repo + ref
+			branch = fmt.Sprintf("%s in %s", event.Branch, event.Repo)
+			tag = fmt.Sprintf("%s@%s", event.Repo, event.Tag)
+			env = fmt.Sprintf("%s in %s", event.Environment, event.Repo)
+		}
+		switch event.Kind {
+		case git.EventPush:
+			l := line("push:"+branch, "commit pushed to "+branch, "commits pushed to "+branch)
+			for range event.Commits {
+				l.add("")
+			}
+		case git.EventPullRequest:
+			line("pr:"+event.Action, "pull request "+event.Action, "pull requests "+event.Action).add(ref)
+		case git.EventIssue:
+			line("issue:"+event.Action, "issue "+event.Action, "issues "+event.Action).add(ref)
+		case git.EventReview:
+			switch event.Action {
+			case "review_requested":
+				line("review:requested", "review requested", "reviews requested").add(ref)
+			case "approved":
+				line("review:approved", "approval", "approvals").add(ref)
+			case "changes_requested":
+				line("review:changes", "change request", "change requests").add(ref)
+			default:
+				line("review:commented", "review comment", "review comments").add(ref)
+			}
+		case git.EventComment:
+			if event.Number == 0 {
+				ref = ""
+			}
+			line("comment", "comment", "comments").add(ref)
+		case git.EventRelease:
+			line("release", "release", "releases").add(tag)
+		case git.EventTag:
+			line("tag:"+event.Action, "tag "+event.Action, "tags "+event.Action).add(tag)
+		case git.EventCheck:
+			key := checkKey{name: event.CheckName, target: branch}
+			if event.IsPullRequest {
+				key.target = ref
+			}
+			if _, ok := checkStates[key]; !ok {
+				checkOrder = append(checkOrder, key)
+			}
+			checkStates[key] = event.CheckState
+		case git.EventDeployment:
+			if _, ok := deployStates[env]; !ok {
+				deployOrder = append(deployOrder, env)
+			}
+			deployStates[env] = event.CheckState
+		}
+	}
+
+	res := []string{title}
+	for _, key := range order {
+		res = append(res, lines[key].String())
+	}
+	for _, env := range deployOrder {
+		if deployStates[env] == "success" {
+			res = append(res, fmt.Sprintf("• Deployed to %s", env))
+		} else {
+			res = append(res, fmt.Sprintf("• Deployment to %s failed", env))
+		}
+	}
+	// only the checks still failing at the end of the digest are worth a
+	// mention
+	var failing []string
+	for _, key := range checkOrder {
+		if checkStates[key] != "failure" {
+			continue
+		}
+		name := "Tests"
+		if key.name != "" {
+			name = fmt.Sprintf("*%s*", key.name)
+		}
+		failing = append(failing, fmt.Sprintf("%s on %s", name, key.target))
+	}
+	if len(failing) > 0 {
+		res = append(res, ":x: Failing: "+strings.Join(failing, ", "))
+	}
+	return strings.Join(res, "\n")
+}
@@ -0,0 +1,69 @@
+package githubbot
+
+import (
+	"testing"
+
+	"github.com/keybase/managed-bots/base/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDigestSchedule(t *testing.T) {
+	schedule, err := parseDigestSchedule([]string{"immediate"})
+	require.NoError(t, err)
+	require.Nil(t, schedule)
+
+	schedule, err = parseDigestSchedule([]string{"daily", "17:30", "America/New_York"})
+	require.NoError(t, err)
+	require.Equal(t, "CRON_TZ=America/New_York 30 17 * * *", schedule.cronSpec())
+	require.Equal(t, "a daily digest at 17:30 (America/New_York)", schedule.String())
+
+	schedule, err = parseDigestSchedule([]string{"Hourly"})
+	require.NoError(t, err)
+	require.Equal(t, "CRON_TZ=UTC 0 * * * *", schedule.cronSpec())
+
+	_, err = parseDigestSchedule([]string{"weekly"})
+	require.Error(t, err)
+	_, err = parseDigestSchedule([]string{"daily", "25:00"})
+	require.Error(t, err)
+	_, err = parseDigestSchedule([]string{"daily", "Mars/Olympus_Mons"})
+	require.Error(t, err)
+}
+
+func TestFormatDigest(t *testing.T) {
+	events := []*git.Event{
+		{Kind: git.EventPullRequest, Action: "opened", Number: 4},
+		{Kind: git.EventPush, Branch: "master", Commits: []string{"a", "b"}},
+		{Kind: git.EventPullRequest, Action: "opened", Number: 5},
+		{Kind: git.EventCheck, CheckName: "lint", CheckState: "failure", IsPullRequest: true, Number: 5},
+		{Kind: git.EventCheck, CheckName: "build", CheckState: "failure", Branch: "master"},
+		{Kind: git.EventPullRequest, Action: "merged", Number: 4},
+		{Kind: git.EventReview, Action: "approved", Number: 4},
+		{Kind: git.EventComment, Number: 5},
+		{Kind: git.EventComment, Number: 5},
+		{Kind: git.EventCheck, CheckName: "build", CheckState: "success", Branch: "master"},
+		{Kind: git.EventDeployment, Environment: "production", CheckState: "success"},
+	}
+	require.Equal(t, "Daily digest for *keybase/client*:\n"+
+		"• 2 pull requests opened: #4, #5\n"+
+		"• 2 commits pushed to master\n"+
+		"• 1 pull request merged: #4\n"+
+		"• 1 approval: #4\n"+
+		"• 2 comments: #5\n"+
+		"• Deployed to production\n"+
+		":x: Failing: *lint* on #5",
+		formatDigest("Daily digest for *keybase/client*:", events))
+}
+
+func TestFormatDigestRepos(t *testing.T) {
+	events := []*git.Event{
+		{Kind: git.EventPullRequest, Action: "opened", Number: 4, Repo: "keybase/client"},
+		{Kind: git.EventPullRequest, Action: "opened", Number: 4, Repo: "keybase/kbfs"},
+		{Kind: git.EventPush, Branch: "master", Commits: []string{"a"}, Repo: "keybase/kbfs"},
+		{Kind: git.EventRelease, Tag: "v1.0", Repo: "keybase/client"},
+	}
+	require.Equal(t, "Daily digest for `keybase/*`:\n"+
+		"• 2 pull requests opened: keybase/client#4, keybase/kbfs#4\n"+
+		"• 1 commit pushed to master in keybase/kbfs\n"+
+		"• 1 release: keybase/client@v1.0",
+		formatDigest("Daily digest for `keybase/*`:", events))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/bradleyfalzon/ghinstallation"
 
@@ -12,85 +13,142 @@ import (
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 	"golang.org/x/oauth2"
 )
 
 type Handler struct {
 	*base.DebugOutput
+	sync.Mutex
 
 	stats       *base.StatsRegistry
 	kbc         *kbchat.API
 	db          *DB
+	onboarding  *base.Onboarding
+	scheduler   *base.Scheduler
 	oauthConfig *oauth2.Config
 	atr         *ghinstallation.AppsTransport
+	httpClient  *base.HTTPClient
 	httpPrefix  string
 	appName     string
+
+	client              *github.Client
+	installationClients map[int64]*github.Client
+	unfurlCache         map[issueRef]cachedIssueSummary
 }
 
 var _ base.Handler = (*Handler)(nil)
 
-func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB,
-	oauthConfig *oauth2.Config, atr *ghinstallation.AppsTransport,
-	httpPrefix, appName string) *Handler {
-	return &Handler{
-		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
-		stats:       stats.SetPrefix("Handler"),
-		kbc:         kbc,
-		db:          db,
-		oauthConfig: oauthConfig,
-		atr:         atr,
-		httpPrefix:  httpPrefix,
-		appName:     appName,
-	}
-}
+// patternBranches are watched by new subscriptions to a pattern, since the
+// matching repos' default branches may differ.
+var patternBranches = []string{"main", "master"}
 
-func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
+func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB,
+	oauthConfig *oauth2.Config, atr *ghinstallation.AppsTransport, httpClient *base.HTTPClient,
+	scheduler *base.Scheduler, httpPrefix, appName string) *Handler {
 	welcomeMsg := fmt.Sprintf(
 		"Hi! I can notify you whenever something happens on a GitHub repository. To get started, install the Keybase integration on your repository, then send `!github subscribe <owner/repo>`\n\ngithub.com/apps/%s/installations/new",
-		h.appName,
+		appName,
 	)
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	h := &Handler{
+		DebugOutput:         base.NewDebugOutput("Handler", debugConfig),
+		stats:               stats.SetPrefix("Handler"),
+		kbc:                 kbc,
+		db:                  db,
+		scheduler:           scheduler,
+		oauthConfig:         oauthConfig,
+		atr:                 atr,
+		httpClient:          httpClient,
+		httpPrefix:          httpPrefix,
+		appName:             appName,
+		client:              github.NewClient(&http.Client{Transport: atr}),
+		installationClients: make(map[int64]*github.Client),
+		unfurlCache:         make(map[issueRef]cachedIssueSummary),
+	}
+	h.onboarding = base.NewOnboarding(stats, kbc, debugConfig, db.DB, "githubbot", "github", welcomeMsg)
+	h.onboarding.SetWizard(h.HandleCommand, h.setupSteps()...)
+	return h
+}
+
+// getInstallationClient returns a client authenticated as the given app
+// installation. Clients are cached so installation tokens are reused until
+// they expire.
+func (h *Handler) getInstallationClient(installationID int64) *github.Client {
+	h.Lock()
+	defer h.Unlock()
+	client, ok := h.installationClients[installationID]
+	if !ok {
+		itr := ghinstallation.NewFromAppsTransport(h.atr, installationID)
+		client = github.NewClient(&http.Client{Transport: itr})
+		h.installationClients[installationID] = client
+	}
+	return client
+}
+
+func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleAuth(msg chat1.MsgSummary, _ string) error {
-	return h.HandleCommand(msg)
+func (h *Handler) HandleAuth(ctx context.Context, msg chat1.MsgSummary, _ string) error {
+	return h.HandleCommand(ctx, msg)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.ToLower(strings.TrimSpace(msg.Content.Text.Body))
 	if !strings.HasPrefix(cmd, "!github") {
-		// ignore non-command message
-		return nil
+		// non-command messages may reference issues
+		return h.handleUnfurl(ctx, msg)
 	}
 
 	if strings.HasPrefix(cmd, "!github mentions") {
 		// handle user preferences without needing oauth
 		h.stats.Count("mentions")
-		return h.handleMentionPref(cmd, msg)
+		return h.handleMentionPref(ctx, cmd, msg)
 	}
 
-	client := github.NewClient(&http.Client{Transport: h.atr})
+	client := h.client
 	switch {
 	case strings.HasPrefix(cmd, "!github subscribe"):
 		h.stats.Count("subscribe")
-		return h.handleSubscribe(cmd, msg, true, client)
+		return h.handleSubscribe(ctx, cmd, msg, true, client)
 	case strings.HasPrefix(cmd, "!github unsubscribe"):
 		h.stats.Count("unsubscribe")
-		return h.handleSubscribe(cmd, msg, false, client)
+		return h.handleSubscribe(ctx, cmd, msg, false, client)
 	case strings.HasPrefix(cmd, "!github list"):
 		h.stats.Count("list")
 		return h.handleListSubscriptions(msg)
+	case strings.HasPrefix(cmd, "!github filter"):
+		h.stats.Count("filter")
+		return h.handleFilter(ctx, cmd, msg)
+	case strings.HasPrefix(cmd, "!github digest"):
+		h.stats.Count("digest")
+		return h.handleDigest(ctx, msg)
+	case strings.HasPrefix(cmd, "!github reminders"):
+		h.stats.Count("reminders")
+		return h.handleReminders(ctx, msg)
+	case strings.HasPrefix(cmd, "!github issue"),
+		strings.HasPrefix(cmd, "!github comment"),
+		strings.HasPrefix(cmd, "!github close"),
+		strings.HasPrefix(cmd, "!github reopen"),
+		strings.HasPrefix(cmd, "!github label"),
+		strings.HasPrefix(cmd, "!github merge"):
+		action := strings.Fields(cmd)[1]
+		h.stats.Count(action)
+		return h.handleAction(ctx, action, msg)
 	default:
 		h.Debug("ignoring unknown command %q", cmd)
 	}
 	return nil
 }
 
-func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool, client *github.Client) (err error) {
+func (h *Handler) handleSubscribe(ctx context.Context, cmd string, msg chat1.MsgSummary, create bool, client *github.Client) (err error) {
 	toks, userErr, err := base.SplitTokens(cmd)
 	if err != nil {
 		return err
@@ -118,7 +176,7 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 		return nil
 	}
 
-	repo := args[0]
+	repo := subscriptionRepo(args[0])
 	// Check if command is subscribing to a branch
 	alreadyExists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
 	if err != nil {
@@ -127,7 +185,7 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 	if len(args) == 2 {
 		if !alreadyExists {
 			if create {
-				if created, err := h.handleNewSubscription(repo, msg, client); err != nil {
+				if created, err := h.handleNewSubscription(ctx, repo, msg, client); err != nil {
 					if _, ok := err.(base.OAuthRequiredError); ok {
 						return nil
 					}
@@ -140,12 +198,7 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 				return nil
 			}
 		}
-		switch args[1] {
-		case "issues", "pulls", "statuses", "commits", "releases":
-			return h.handleSubscribeToFeature(repo, args[1], msg, create)
-		default:
-			return h.handleSubscribeToBranch(repo, args[1], msg, create)
-		}
+		return h.handleSubscribeToFilter(ctx, repo, args[1], msg, create)
 	}
 
 	if create {
@@ -153,7 +206,7 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 			h.ChatEcho(msg.ConvID, "You're already receiving notifications for `%s` here!", repo)
 			return nil
 		}
-		created, err := h.handleNewSubscription(repo, msg, client)
+		created, err := h.handleNewSubscription(ctx, repo, msg, client)
 		if err != nil {
 			if _, ok := err.(base.OAuthRequiredError); ok {
 				return nil
@@ -162,6 +215,11 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 		} else if !created {
 			return nil
 		}
+		if git.IsRepoPattern(repo) {
+			h.ChatEcho(msg.ConvID, "Okay, you'll receive updates for every repository matching `%s` here, including new ones. Pushes are sent for the %s branches, watch others with `!github subscribe %s <branch>`.",
+				repo, strings.Join(patternBranches, " and "), repo)
+			return nil
+		}
 		h.ChatEcho(msg.ConvID, "Okay, you'll receive updates for `%s` here.", repo)
 		return nil
 	}
@@ -172,117 +230,140 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool,
 		return nil
 	}
 
-	err = h.db.DeleteSubscriptionsForRepo(msg.ConvID, repo)
+	err = h.db.DeleteSubscriptionsForRepo(ctx, msg.ConvID, repo)
 	if err != nil {
 		return fmt.Errorf("error deleting subscriptions: %s", err)
 	}
 
-	err = h.db.DeleteBranchesForRepo(msg.ConvID, repo)
-	if err != nil {
-		return fmt.Errorf("error deleting branches: %s", err)
+	if err = h.db.DeleteFilter(ctx, msg.ConvID, repo); err != nil {
+		return err
 	}
-
-	err = h.db.DeleteFeaturesForRepo(msg.ConvID, repo)
-	if err != nil {
-		return fmt.Errorf("error deleting features: %s", err)
+	if err = h.db.DeleteDigestSchedule(ctx, msg.ConvID, repo); err != nil {
+		return fmt.Errorf("error deleting digest schedule: %s", err)
+	}
+	if err = h.scheduler.Cancel(subscriptionJobID(DigestJobName, msg.ConvID, repo)); err != nil {
+		return fmt.Errorf("error canceling digest: %s", err)
+	}
+	if err = h.db.DeleteDigestEventsForRepo(ctx, msg.ConvID, repo); err != nil {
+		return fmt.Errorf("error deleting queued digest events: %s", err)
+	}
+	if err = h.db.DeleteReminderSchedule(ctx, msg.ConvID, repo); err != nil {
+		return fmt.Errorf("error deleting reminders: %s", err)
+	}
+	if err = h.scheduler.Cancel(subscriptionJobID(ReminderJobName, msg.ConvID, repo)); err != nil {
+		return fmt.Errorf("error canceling reminders: %s", err)
 	}
 	h.ChatEcho(msg.ConvID, "Okay, you won't receive updates for `%s` here.", repo)
 	return nil
 }
 
 func (h *Handler) handleListSubscriptions(msg chat1.MsgSummary) (err error) {
-	features, err := h.db.GetFeaturesForAllRepos(msg.ConvID)
+	repos, err := h.db.GetAllSubscriptionsForConvID(msg.ConvID)
 	if err != nil {
-		return fmt.Errorf("Error getting current features: %s", err)
+		return fmt.Errorf("error getting current repos: %s", err)
 	}
 
-	if len(features) == 0 {
+	if len(repos) == 0 {
 		h.ChatEcho(msg.ConvID, "Not subscribed to any repos yet.")
 		return nil
 	}
 
-	var res string
-	for repo, f := range features {
-		res += fmt.Sprintf("- *%s* (%s)\n", repo, &f)
-		if f.Commits {
-			branches, err := h.db.GetAllBranchesForRepo(msg.ConvID, repo)
-			if err != nil {
-				return fmt.Errorf("error getting branches for repo: %s", err)
-			}
-
-			for _, branch := range branches {
-				res += fmt.Sprintf("   - %s\n", branch)
-			}
-		}
+	res, err := h.db.FormatSubscriptions(msg.ConvID, repos)
+	if err != nil {
+		return fmt.Errorf("error getting current features: %s", err)
 	}
 	h.ChatEcho(msg.ConvID, "%s", res)
 	return nil
 }
 
-func (h *Handler) handleNewSubscription(repo string, msg chat1.MsgSummary, client *github.Client) (created bool, err error) {
+// authorizeUser checks that the app is installed on the repo and that the
+// sender can access the installation, returning a client acting as the
+// sender. The client is nil if the sender was told why they can't, action
+// describes what they tried in those replies.
+func (h *Handler) authorizeUser(ctx context.Context, repo, action string, msg chat1.MsgSummary, client *github.Client) (userClient *github.Client, installationID int64, err error) {
 	parsedRepo := strings.Split(repo, "/")
-	if len(parsedRepo) != 2 {
+	if len(parsedRepo) != 2 || git.IsRepoPattern(parsedRepo[0]) {
 		h.ChatEcho(msg.ConvID, "`%s` doesn't look like a repository to me! Try sending `!github subscribe <owner/repo>`", repo)
-		return false, nil
+		return nil, 0, nil
+	}
+	var repoInstallation *github.Installation
+	var res *github.Response
+	if git.IsRepoPattern(repo) {
+		// patterns match the repos of the owner's installation
+		repoInstallation, res, err = client.Apps.FindOrganizationInstallation(ctx, parsedRepo[0])
+		if err != nil && res != nil && res.StatusCode == http.StatusNotFound {
+			repoInstallation, res, err = client.Apps.FindUserInstallation(ctx, parsedRepo[0])
+		}
+	} else {
+		repoInstallation, res, err = client.Apps.FindRepositoryInstallation(ctx, parsedRepo[0], parsedRepo[1])
 	}
-	repoInstallation, res, err := client.Apps.FindRepositoryInstallation(context.TODO(), parsedRepo[0], parsedRepo[1])
 	if err != nil {
-		switch res.StatusCode {
-		case http.StatusNotFound:
-			h.ChatEcho(msg.ConvID, "I couldn't subscribe to updates on `%s`! Make sure the Keybase integration is installed on your repository, and that the repository exists.\n\ngithub.com/apps/%s/installations/new", repo, h.appName)
-			return false, nil
-		default:
-			return false, fmt.Errorf("error getting installation: %s", err)
+		// res is nil when the request never got a response, such as when
+		// GitHub's circuit is open
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			h.ChatEcho(msg.ConvID, "I couldn't %s `%s`! Make sure the Keybase integration is installed on your repository, and that the repository exists.\n\ngithub.com/apps/%s/installations/new", action, repo, h.appName)
+			return nil, 0, nil
 		}
+		return nil, 0, fmt.Errorf("error getting installation: %s", err)
 	}
 
 	// check that user has authorization
-	tc, err := base.GetOAuthClient(msg.Sender.Username, msg, h.kbc, h.oauthConfig, h.db,
+	tc, err := base.GetOAuthClient(ctx, msg.Sender.Username, msg, h.kbc, h.oauthConfig, h.db,
 		base.GetOAuthOpts{
 			AuthMessageTemplate: "Authorize me by clicking this link:\n%s",
+			HTTPClient:          h.httpClient.Client(),
 		})
 	if err != nil || tc == nil {
-		return false, err
+		return nil, 0, err
 	}
-	userClient := github.NewClient(tc)
-	installations, _, err := userClient.Apps.ListUserInstallations(context.TODO(), nil)
+	userClient = github.NewClient(tc)
+	installations, _, err := userClient.Apps.ListUserInstallations(ctx, nil)
 	if err != nil {
-		return false, fmt.Errorf("Error getting installations for current user: %s", err)
+		return nil, 0, fmt.Errorf("Error getting installations for current user: %s", err)
 	}
 
 	// search through all user installations to see if they have permission to access the repo's installation
-	hasPermission := false
 	for _, i := range installations {
 		if i.GetID() == repoInstallation.GetID() {
-			hasPermission = true
-			break
+			return userClient, repoInstallation.GetID(), nil
 		}
 	}
+	h.ChatEcho(msg.ConvID, "You don't have permission to %s `%s`.", action, repo)
+	return nil, 0, fmt.Errorf("unauthorized for %s", repo)
+}
 
-	if !hasPermission {
-		h.ChatEcho(msg.ConvID, "You don't have permission to subscribe to `%s`.", repo)
-		return false, fmt.Errorf("unauthorized for subscription")
+func (h *Handler) handleNewSubscription(ctx context.Context, repo string, msg chat1.MsgSummary, client *github.Client) (created bool, err error) {
+	userClient, installationID, err := h.authorizeUser(ctx, repo, "subscribe to", msg, client)
+	if err != nil || userClient == nil {
+		return false, err
 	}
 
 	// auth checked, now we create the subscription
-	defaultBranch, err := GetDefaultBranch(repo, userClient)
-	if err != nil {
-		return false, fmt.Errorf("error getting default branch: %s", err)
+	branches := patternBranches
+	if !git.IsRepoPattern(repo) {
+		defaultBranch, err := GetDefaultBranch(ctx, repo, userClient)
+		if err != nil {
+			return false, fmt.Errorf("error getting default branch: %s", err)
+		}
+		branches = []string{defaultBranch}
 	}
 
-	err = h.db.WatchBranch(msg.ConvID, repo, defaultBranch)
-	if err != nil {
-		return false, fmt.Errorf("error watching branch: %s", err)
+	for _, branch := range branches {
+		if err = h.db.WatchBranch(ctx, msg.ConvID, repo, branch); err != nil {
+			return false, fmt.Errorf("error watching branch: %s", err)
+		}
 	}
 
-	err = h.db.CreateSubscription(msg.ConvID, repo, repoInstallation.GetID())
+	err = h.db.CreateSubscription(ctx, msg.ConvID, repo, installationID)
 	if err != nil {
 		return false, fmt.Errorf("error creating subscription: %s", err)
 	}
 	return true, nil
 }
 
-func (h *Handler) handleSubscribeToFeature(repo, feature string, msg chat1.MsgSummary, enable bool) (err error) {
+// handleSubscribeToFilter toggles one of the subscription's features, or
+// watches or unwatches a branch.
+func (h *Handler) handleSubscribeToFilter(ctx context.Context, repo, arg string, msg chat1.MsgSummary, enable bool) (err error) {
 	exists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
 	if err != nil {
 		return fmt.Errorf("error getting subscription: %s", err)
@@ -295,75 +376,246 @@ func (h *Handler) handleSubscribeToFeature(repo, feature string, msg chat1.MsgSu
 		return nil
 	}
 
-	currentFeatures, err := h.db.GetFeatures(msg.ConvID, repo)
+	reply, err := h.db.ToggleFilter(ctx, msg.ConvID, repo, arg, enable)
 	if err != nil {
-		return fmt.Errorf("Error getting current features: %s", err)
-	}
-	if currentFeatures == nil {
-		currentFeatures = &Features{}
-	}
-	// "issues", "pulls", "statuses", "commits", "releases"
-	switch feature {
-	case "issues":
-		currentFeatures.Issues = enable
-	case "pulls":
-		currentFeatures.PullRequests = enable
-	case "statuses":
-		currentFeatures.Statuses = enable
-	case "commits":
-		currentFeatures.Commits = enable
-	case "releases":
-		currentFeatures.Releases = enable
-	default:
-		// Should never get here if check in handleSubscribe is correct
-		return fmt.Errorf("Error subscribing to feature: %s is not a valid feature", feature)
+		return err
 	}
+	h.ChatEcho(msg.ConvID, "%s", reply)
+	return nil
+}
 
-	err = h.db.SetFeatures(msg.ConvID, repo, currentFeatures)
+// handleFilter adds or removes a label, author, path or draft rule of a
+// subscription, or lists its rules.
+func (h *Handler) handleFilter(ctx context.Context, cmd string, msg chat1.MsgSummary) (err error) {
+	toks, userErr, err := base.SplitTokens(cmd)
 	if err != nil {
-		return fmt.Errorf("Error setting features: %s", err)
+		return err
+	} else if userErr != "" {
+		h.ChatEcho(msg.ConvID, "%s", userErr)
+		return nil
 	}
-	if enable {
-		h.ChatEcho(msg.ConvID, "Okay, you'll receive notifications for `%s` on `%s`!", feature, repo)
-	} else {
-		h.ChatEcho(msg.ConvID, "Okay, you won't receive notifications for `%s` for `%s`.", repo, feature)
+	args := toks[2:]
+	if len(args) != 1 && (len(args) != 3 || (args[1] != "add" && args[1] != "remove")) {
+		h.ChatEcho(msg.ConvID, "I don't understand! Try `!github filter <owner/repo> add label:backend`")
+		return nil
+	}
+
+	repo := subscriptionRepo(args[0])
+	exists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
+	if err != nil {
+		return fmt.Errorf("error getting subscription: %s", err)
+	} else if !exists {
+		h.ChatEcho(msg.ConvID, "You aren't subscribed to updates for `%s`!", repo)
+		return nil
+	}
+
+	if len(args) == 1 {
+		rules, err := h.db.GetRules(msg.ConvID, repo)
+		if err != nil {
+			return fmt.Errorf("error getting filter rules: %s", err)
+		}
+		if len(rules) == 0 {
+			h.ChatEcho(msg.ConvID, "There are no filters on `%s`.", repo)
+			return nil
+		}
+		res := fmt.Sprintf("Filters on `%s`:", repo)
+		for _, rule := range rules {
+			res += fmt.Sprintf("\n- `%s`", rule)
+		}
+		h.ChatEcho(msg.ConvID, "%s", res)
+		return nil
+	}
+
+	isAllowed, err := base.IsAtLeastWriter(h.kbc, msg.Sender.Username, msg.Channel)
+	if err != nil {
+		return fmt.Errorf("Error getting role status: %s", err)
+	}
+	if !isAllowed {
+		h.ChatEcho(msg.ConvID, "You must be at least a writer to configure me!")
+		return nil
+	}
+
+	rule, err := git.ParseRule(args[2])
+	if err != nil {
+		h.ChatEcho(msg.ConvID, "Invalid filter `%s`: %s", args[2], err)
+		return nil
+	}
+	if args[1] == "add" {
+		if err := h.db.AddRule(ctx, msg.ConvID, repo, rule); err != nil {
+			return fmt.Errorf("error adding filter rule: %s", err)
+		}
+		h.ChatEcho(msg.ConvID, "Okay, notifications for `%s` are now filtered by `%s`.", repo, rule)
+		return nil
+	}
+	removed, err := h.db.RemoveRule(ctx, msg.ConvID, repo, rule)
+	if err != nil {
+		return fmt.Errorf("error removing filter rule: %s", err)
+	} else if !removed {
+		h.ChatEcho(msg.ConvID, "Notifications for `%s` aren't filtered by `%s`!", repo, rule)
+		return nil
 	}
+	h.ChatEcho(msg.ConvID, "Okay, notifications for `%s` are no longer filtered by `%s`.", repo, rule)
 	return nil
 }
 
-func (h *Handler) handleSubscribeToBranch(repo, branch string, msg chat1.MsgSummary, create bool) (err error) {
+// handleDigest sets whether a subscription is notified immediately or
+// through hourly or daily digests, or shows which it is.
+func (h *Handler) handleDigest(ctx context.Context, msg chat1.MsgSummary) (err error) {
+	// timezones are case sensitive, so the original message is parsed
+	toks, userErr, err := base.SplitTokens(strings.TrimSpace(msg.Content.Text.Body))
+	if err != nil {
+		return err
+	} else if userErr != "" {
+		h.ChatEcho(msg.ConvID, "%s", userErr)
+		return nil
+	}
+	args := toks[2:]
+	if len(args) < 1 {
+		h.ChatEcho(msg.ConvID, "I don't understand! Try `!github digest <owner/repo> daily 09:00 America/New_York`")
+		return nil
+	}
+
+	repo := subscriptionRepo(args[0])
 	exists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
 	if err != nil {
 		return fmt.Errorf("error getting subscription: %s", err)
 	} else if !exists {
-		if create {
-			h.ChatEcho(msg.ConvID, "You aren't subscribed to updates yet!\nSend this first: `!github subscribe %s`", repo)
+		h.ChatEcho(msg.ConvID, "You aren't subscribed to updates for `%s`!", repo)
+		return nil
+	}
+
+	if len(args) == 1 {
+		schedule, err := h.db.GetDigestSchedule(msg.ConvID, repo)
+		if err != nil {
+			return fmt.Errorf("error getting digest schedule: %s", err)
+		}
+		if schedule == nil {
+			h.ChatEcho(msg.ConvID, "You receive notifications for `%s` as they happen.", repo)
 		} else {
-			h.ChatEcho(msg.ConvID, "You aren't subscribed to notifications for `%s`!", repo)
+			h.ChatEcho(msg.ConvID, "You receive %s of `%s`.", schedule, repo)
 		}
 		return nil
 	}
 
-	if create {
-		err = h.db.WatchBranch(msg.ConvID, repo, branch)
-		if err != nil {
-			return fmt.Errorf("error creating branch subscription: %s", err)
+	isAllowed, err := base.IsAtLeastWriter(h.kbc, msg.Sender.Username, msg.Channel)
+	if err != nil {
+		return fmt.Errorf("Error getting role status: %s", err)
+	}
+	if !isAllowed {
+		h.ChatEcho(msg.ConvID, "You must be at least a writer to configure me!")
+		return nil
+	}
+
+	schedule, err := parseDigestSchedule(args[1:])
+	if err != nil {
+		h.ChatEcho(msg.ConvID, "I don't understand! %s", err)
+		return nil
+	}
+	jobID := subscriptionJobID(DigestJobName, msg.ConvID, repo)
+	if schedule == nil {
+		if err := h.db.DeleteDigestSchedule(ctx, msg.ConvID, repo); err != nil {
+			return fmt.Errorf("error deleting digest schedule: %s", err)
+		}
+		if err := h.scheduler.Cancel(jobID); err != nil {
+			return fmt.Errorf("error canceling digest: %s", err)
+		}
+		// don't leave the queued events behind
+		if err := h.sendDigest(ctx, msg.ConvID, repo, fmt.Sprintf("Digest for %s:", formatRepo(repo))); err != nil {
+			return fmt.Errorf("error sending digest: %s", err)
 		}
+		h.ChatEcho(msg.ConvID, "Okay, you'll receive notifications for `%s` as they happen.", repo)
+		return nil
+	}
 
-		h.ChatEcho(msg.ConvID, "Now subscribed to notifications for `%s/%s`.", repo, branch)
+	if err := h.db.SetDigestSchedule(ctx, msg.ConvID, repo, schedule); err != nil {
+		return fmt.Errorf("error setting digest schedule: %s", err)
+	}
+	if _, err := h.scheduler.ScheduleCron(jobID, DigestJobName, schedule.cronSpec(),
+		digestJobPayload{ConvID: msg.ConvID, Repo: repo}); err != nil {
+		return fmt.Errorf("error scheduling digest: %s", err)
+	}
+	h.ChatEcho(msg.ConvID, "Okay, you'll receive %s of `%s` instead of each notification.", schedule, repo)
+	return nil
+}
+
+// handleReminders sets when a subscription is reminded of the pull requests
+// waiting for review, turns the reminders off, or shows their schedule.
+func (h *Handler) handleReminders(ctx context.Context, msg chat1.MsgSummary) (err error) {
+	// timezones are case sensitive, so the original message is parsed
+	toks, userErr, err := base.SplitTokens(strings.TrimSpace(msg.Content.Text.Body))
+	if err != nil {
+		return err
+	} else if userErr != "" {
+		h.ChatEcho(msg.ConvID, "%s", userErr)
+		return nil
+	}
+	args := toks[2:]
+	if len(args) < 1 {
+		h.ChatEcho(msg.ConvID, "I don't understand! Try `!github reminders <owner/repo> 24h weekdays 10:00 America/New_York`")
+		return nil
+	}
+
+	repo := subscriptionRepo(args[0])
+	exists, err := h.db.GetSubscriptionForRepoExists(msg.ConvID, repo)
+	if err != nil {
+		return fmt.Errorf("error getting subscription: %s", err)
+	} else if !exists {
+		h.ChatEcho(msg.ConvID, "You aren't subscribed to updates for `%s`!", repo)
+		return nil
+	}
+
+	if len(args) == 1 {
+		schedule, err := h.db.GetReminderSchedule(msg.ConvID, repo)
+		if err != nil {
+			return fmt.Errorf("error getting reminders: %s", err)
+		}
+		if schedule == nil {
+			h.ChatEcho(msg.ConvID, "There are no review reminders for `%s`.", repo)
+		} else {
+			h.ChatEcho(msg.ConvID, "I remind you of %s on `%s`.", schedule, repo)
+		}
 		return nil
 	}
-	err = h.db.UnwatchBranch(msg.ConvID, repo, branch)
+
+	isAllowed, err := base.IsAtLeastWriter(h.kbc, msg.Sender.Username, msg.Channel)
 	if err != nil {
-		return fmt.Errorf("error deleting branch subscription: %s", err)
+		return fmt.Errorf("Error getting role status: %s", err)
+	}
+	if !isAllowed {
+		h.ChatEcho(msg.ConvID, "You must be at least a writer to configure me!")
+		return nil
 	}
 
-	h.ChatEcho(msg.ConvID, "Okay, you won't receive notifications for `%s/%s`.", repo, branch)
+	jobID := subscriptionJobID(ReminderJobName, msg.ConvID, repo)
+	if strings.ToLower(args[1]) == "off" && len(args) == 2 {
+		if err := h.db.DeleteReminderSchedule(ctx, msg.ConvID, repo); err != nil {
+			return fmt.Errorf("error deleting reminders: %s", err)
+		}
+		if err := h.scheduler.Cancel(jobID); err != nil {
+			return fmt.Errorf("error canceling reminders: %s", err)
+		}
+		h.ChatEcho(msg.ConvID, "Okay, I won't remind you of pull requests waiting for review on `%s`.", repo)
+		return nil
+	}
+
+	schedule, err := parseReminderSchedule(args[1:])
+	if err != nil {
+		h.ChatEcho(msg.ConvID, "I don't understand! %s", err)
+		return nil
+	}
+	if err := h.db.SetReminderSchedule(ctx, msg.ConvID, repo, schedule); err != nil {
+		return fmt.Errorf("error setting reminders: %s", err)
+	}
+	if _, err := h.scheduler.ScheduleCron(jobID, ReminderJobName, schedule.cronSpec(),
+		reminderJobPayload{ConvID: msg.ConvID, Repo: repo}); err != nil {
+		return fmt.Errorf("error scheduling reminders: %s", err)
+	}
+	h.ChatEcho(msg.ConvID, "Okay, I'll remind you of %s on `%s`.", schedule, repo)
 	return nil
 }
 
 // user preferences
-func (h *Handler) handleMentionPref(cmd string, msg chat1.MsgSummary) (err error) {
+func (h *Handler) handleMentionPref(ctx context.Context, cmd string, msg chat1.MsgSummary) (err error) {
 	toks, userErr, err := base.SplitTokens(cmd)
 	if err != nil {
 		return err
@@ -378,7 +630,7 @@ func (h *Handler) handleMentionPref(cmd string, msg chat1.MsgSummary) (err error
 	}
 
 	allowMentions := args[0] == "enable"
-	err = h.db.SetUserPreferences(msg.Sender.Username, msg.ConvID, &UserPreferences{Mention: allowMentions})
+	err = h.db.SetUserPreferences(ctx, msg.Sender.Username, msg.ConvID, &UserPreferences{Mention: allowMentions})
 	if err != nil {
 		return fmt.Errorf("error setting user preference: %s", err)
 	}
@@ -21,21 +21,23 @@ import (
 type HTTPSrv struct {
 	*base.OAuthHTTPSrv
 
-	kbc     *kbchat.API
-	db      *DB
-	handler *Handler
-	atr     *ghinstallation.AppsTransport
-	secret  string
+	kbc      *kbchat.API
+	db       *DB
+	handler  *Handler
+	notifier *git.Notifier
+	atr      *ghinstallation.AppsTransport
+	secret   string
 }
 
 func NewHTTPSrv(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB, handler *Handler,
 	oauthConfig *oauth2.Config, atr *ghinstallation.AppsTransport, secret string) *HTTPSrv {
 	h := &HTTPSrv{
-		kbc:     kbc,
-		db:      db,
-		handler: handler,
-		atr:     atr,
-		secret:  secret,
+		kbc:      kbc,
+		db:       db,
+		handler:  handler,
+		notifier: git.NewNotifier(stats, debugConfig, db.Store),
+		atr:      atr,
+		secret:   secret,
 	}
 	h.OAuthHTTPSrv = base.NewOAuthHTTPSrv(stats, kbc, debugConfig, oauthConfig, h.db, h.handler.HandleAuth,
 		"githubbot", base.Images["logo"], "/githubbot")
@@ -49,6 +51,7 @@ func (h *HTTPSrv) handleHealthCheck(w http.ResponseWriter, _ *http.Request) {
 }
 
 func (h *HTTPSrv) handleWebhook(_ http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	payload, err := github.ValidatePayload(r, []byte(h.secret))
 	if err != nil {
 		h.Debug("Error validating payload (%s): %v\n", r.Header.Get("X-GitHub-Delivery"), err)
@@ -56,7 +59,7 @@ func (h *HTTPSrv) handleWebhook(_ http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	event, err := parseWebHook(github.WebHookType(r), payload)
 	if err != nil {
 		h.Debug("could not parse webhook: type:%s %s\n", github.WebHookType(r), err)
 		return
@@ -83,192 +86,421 @@ func (h *HTTPSrv) handleWebhook(_ http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	itr := ghinstallation.NewFromAppsTransport(h.atr, installationID)
-	client := github.NewClient(&http.Client{Transport: itr})
-
 	if repo == "" {
 		return
 	}
 
-	convs, err := h.db.GetConvIDsFromRepoInstallation(repo, installationID)
+	subs, err := h.db.GetSubscriptionsForRepoInstallation(repo, installationID)
 	if err != nil {
 		h.Errorf("Error getting subscriptions for repo: %s", err)
 		return
 	}
+	if len(subs) == 0 {
+		return
+	}
 
-	for _, convID := range convs {
-		features, err := h.db.GetFeatures(convID, repo)
-		if err != nil {
-			h.Errorf("Error getting features for repo and convID: %s", err)
-			return
-		}
-
-		if !shouldParseEvent(event, features) {
-			// If a conversation is not subscribed to the feature an event is part of, bail
-			h.Stats.Count("webhook - disabled feature")
-			continue
-		}
-
-		message, branch := h.formatMessage(convID, event, repo, client)
-		if message == "" {
-			// if we don't have a message to send, bail
-			continue
-		}
-
-		if branch != "" {
-			// if the event has a branch associated with it, check if we're subscribed to that branch
-			subscriptionExists, err := h.db.GetSubscriptionForBranchExists(convID, repo, branch)
-			if err != nil {
-				h.Errorf("could not get subscription: %s\n", err)
-				return
-			}
-
-			if !subscriptionExists {
-				continue
-			}
-		}
-
-		h.Stats.Count("webhook - success")
-		h.ChatEcho(convID, "%s", message)
+	client := h.handler.getInstallationClient(installationID)
+	gitEvent := h.toEvent(ctx, event, repo, client)
+	if gitEvent == nil {
+		// if we don't have a message to send, bail
+		return
+	}
+	convs := h.queueDigests(ctx, gitEvent, h.notifier.AllowedSubscriptions(gitEvent, subs))
+	if gitEvent.Kind == git.EventCheck && gitEvent.Commit != "" {
+		h.updateCheckBoards(ctx, gitEvent, convs)
+		return
 	}
+	h.notifier.Deliver(gitEvent, convs, func(convID chat1.ConvIDStr, login string) string {
+		return getPossibleKBUser(h.kbc, h.db, h.DebugOutput, login, convID).String()
+	})
 }
 
-func (h *HTTPSrv) formatMessage(convID chat1.ConvIDStr, event interface{}, repo string, client *github.Client) (message string, branch string) {
+// toEvent translates the webhook event, it returns nil for events that
+// aren't supported.
+func (h *HTTPSrv) toEvent(ctx context.Context, event interface{}, repo string, client *github.Client) *git.Event {
 	parsedRepo := strings.Split(repo, "/")
 	if len(parsedRepo) != 2 {
 		h.Debug("invalid repo: %s", repo)
-		return
+		return nil
 	}
 	switch event := event.(type) {
 	case *github.IssuesEvent:
-		author := getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.GetSender().GetLogin(), convID)
-		return git.FormatIssueMsg(
-			*event.Action,
-			author.String(),
-			event.GetRepo().GetName(),
-			event.GetIssue().GetNumber(),
-			event.GetIssue().GetTitle(),
-			event.GetIssue().GetHTMLURL(),
-		), ""
+		return &git.Event{
+			Kind:     git.EventIssue,
+			Provider: git.GITHUB,
+			Action:   event.GetAction(),
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Author:   event.GetSender().GetLogin(),
+			Number:   event.GetIssue().GetNumber(),
+			Title:    event.GetIssue().GetTitle(),
+			URL:      event.GetIssue().GetHTMLURL(),
+			Labels:   getLabelNames(event.GetIssue().Labels),
+		}
 	case *github.ReleaseEvent:
-		author := getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.GetSender().GetLogin(), convID)
-		return git.FormatReleaseMsg(
-			*event.Action,
-			author.String(),
-			event.GetRepo().GetName(),
-			event.GetRelease().GetTagName(),
-			event.GetRelease().GetName(),
-			event.GetRelease().GetURL(),
-			event.GetRelease().GetBody(),
-		), ""
+		return &git.Event{
+			Kind:     git.EventRelease,
+			Provider: git.GITHUB,
+			Action:   event.GetAction(),
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Author:   event.GetSender().GetLogin(),
+			Tag:      event.GetRelease().GetTagName(),
+			Name:     event.GetRelease().GetName(),
+			Body:     event.GetRelease().GetBody(),
+			URL:      event.GetRelease().GetHTMLURL(),
+		}
 	case *github.PullRequestEvent:
-		var author username
-		if event.GetPullRequest().GetMerged() {
-			author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.GetPullRequest().GetMergedBy().GetLogin(), convID)
-		} else {
-			author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.GetPullRequest().GetUser().GetLogin(), convID)
+		pr := event.GetPullRequest()
+		if event.GetAction() == "review_requested" {
+			gitEvent := &git.Event{
+				Kind:     git.EventReview,
+				Provider: git.GITHUB,
+				Action:   event.GetAction(),
+				Repo:     repo,
+				RepoName: event.GetRepo().GetName(),
+				Author:   event.GetSender().GetLogin(),
+				Number:   event.GetNumber(),
+				Title:    pr.GetTitle(),
+				Reviewer: event.GetRequestedReviewer().GetLogin(),
+				URL:      pr.GetHTMLURL(),
+				Labels:   getLabelNames(pr.Labels),
+				Draft:    pr.GetDraft(),
+			}
+			if team := event.GetRequestedTeam(); team != nil {
+				gitEvent.Reviewer = fmt.Sprintf("%s/%s", event.GetRepo().GetOwner().GetLogin(), team.GetSlug())
+			}
+			return gitEvent
 		}
-
-		action := *event.Action
-		if event.GetPullRequest().GetMerged() {
-			action = "merged"
+		gitEvent := &git.Event{
+			Kind:         git.EventPullRequest,
+			Provider:     git.GITHUB,
+			Action:       event.GetAction(),
+			Repo:         repo,
+			RepoName:     event.GetRepo().GetName(),
+			Author:       pr.GetUser().GetLogin(),
+			Number:       event.GetNumber(),
+			Title:        pr.GetTitle(),
+			TargetBranch: pr.GetBase().GetRef(),
+			URL:          pr.GetHTMLURL(),
+			Labels:       getLabelNames(pr.Labels),
+			Draft:        pr.GetDraft(),
+		}
+		if pr.GetMerged() {
+			gitEvent.Action = "merged"
+			gitEvent.Author = pr.GetMergedBy().GetLogin()
+		}
+		switch event.GetAction() {
+		case "opened", "reopened", "closed":
+			// only the announced actions are worth the API calls, which are
+			// only made if a subscription has a path rule
+			gitEvent.SetPathsLoader(func() []string {
+				return h.getPullRequestFiles(ctx, parsedRepo[0], parsedRepo[1], event.GetNumber(), client)
+			})
+		}
+		return gitEvent
+	case *github.PullRequestReviewEvent:
+		if event.GetAction() != "submitted" {
+			return nil
+		}
+		review := event.GetReview()
+		return &git.Event{
+			Kind:     git.EventReview,
+			Provider: git.GITHUB,
+			Action:   strings.ToLower(review.GetState()),
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Author:   review.GetUser().GetLogin(),
+			Number:   event.GetPullRequest().GetNumber(),
+			Title:    event.GetPullRequest().GetTitle(),
+			Body:     review.GetBody(),
+			URL:      review.GetHTMLURL(),
+			Labels:   getLabelNames(event.GetPullRequest().Labels),
+			Draft:    event.GetPullRequest().GetDraft(),
+		}
+	case *github.IssueCommentEvent:
+		return &git.Event{
+			Kind:          git.EventComment,
+			Provider:      git.GITHUB,
+			Action:        event.GetAction(),
+			Repo:          repo,
+			RepoName:      event.GetRepo().GetName(),
+			Author:        event.GetComment().GetUser().GetLogin(),
+			Number:        event.GetIssue().GetNumber(),
+			Title:         event.GetIssue().GetTitle(),
+			IsPullRequest: event.GetIssue().IsPullRequest(),
+			Body:          event.GetComment().GetBody(),
+			URL:           event.GetComment().GetHTMLURL(),
+			Labels:        getLabelNames(event.GetIssue().Labels),
+		}
+	case *github.PullRequestReviewCommentEvent:
+		return &git.Event{
+			Kind:          git.EventComment,
+			Provider:      git.GITHUB,
+			Action:        event.GetAction(),
+			Repo:          repo,
+			RepoName:      event.GetRepo().GetName(),
+			Author:        event.GetComment().GetUser().GetLogin(),
+			Number:        event.GetPullRequest().GetNumber(),
+			Title:         event.GetPullRequest().GetTitle(),
+			IsPullRequest: true,
+			Body:          event.GetComment().GetBody(),
+			URL:           event.GetComment().GetHTMLURL(),
+			Labels:        getLabelNames(event.GetPullRequest().Labels),
 		}
-
-		return git.FormatPullRequestMsg(
-			git.GITHUB,
-			action,
-			author.String(),
-			event.GetRepo().GetName(),
-			event.GetNumber(),
-			event.GetPullRequest().GetTitle(),
-			event.GetPullRequest().GetHTMLURL(),
-			event.GetRepo().GetName(),
-		), ""
 	case *github.PushEvent:
-		if len(event.Commits) == 0 {
-			break
+		if len(event.Commits) == 0 || git.IsTagRef(event.GetRef()) {
+			// tags are announced by their CreateEvent
+			return nil
+		}
+		return &git.Event{
+			Kind:     git.EventPush,
+			Provider: git.GITHUB,
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Branch:   git.RefToName(event.GetRef()),
+			Author:   event.GetSender().GetLogin(),
+			Commits:  getCommitMessages(event),
+			URL:      event.GetCompare(),
+			Paths:    getChangedPaths(event),
+		}
+	case *github.CreateEvent:
+		if event.GetRefType() != "tag" {
+			return nil
+		}
+		return &git.Event{
+			Kind:     git.EventTag,
+			Provider: git.GITHUB,
+			Action:   "created",
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Author:   event.GetSender().GetLogin(),
+			Tag:      event.GetRef(),
+			URL:      fmt.Sprintf("%s/tree/%s", event.GetRepo().GetHTMLURL(), event.GetRef()),
+		}
+	case *github.DeleteEvent:
+		if event.GetRefType() != "tag" {
+			return nil
+		}
+		return &git.Event{
+			Kind:     git.EventTag,
+			Provider: git.GITHUB,
+			Action:   "deleted",
+			Repo:     repo,
+			RepoName: event.GetRepo().GetName(),
+			Author:   event.GetSender().GetLogin(),
+			Tag:      event.GetRef(),
 		}
-
-		branch = git.RefToName(event.GetRef())
-		commitMsgs := getCommitMessages(event)
-
-		return git.FormatPushMsg(
-			event.GetSender().GetLogin(),
-			event.GetRepo().GetName(),
-			branch,
-			len(event.Commits),
-			commitMsgs,
-			event.GetCompare()), branch
-
 	case *github.CheckRunEvent:
-		var author username
+		run := event.GetCheckRun()
+		gitEvent := &git.Event{
+			Kind:       git.EventCheck,
+			Provider:   git.GITHUB,
+			Repo:       repo,
+			RepoName:   event.GetRepo().GetName(),
+			Commit:     run.GetHeadSHA(),
+			CheckName:  run.GetName(),
+			CheckState: checkState(run.GetConclusion()),
+			URL:        run.GetHTMLURL(),
+		}
+		if run.GetApp().GetSlug() == "github-actions" {
+			// the jobs of workflows, which subscriptions hear about one by
+			// one unless they want a summary per workflow run instead
+			gitEvent.CheckScope = git.CheckScopeJob
+		}
+		switch event.GetAction() {
+		case "created":
+			gitEvent.CheckState = "pending"
+		case "completed":
+		default:
+			return nil
+		}
+		h.setCheckTarget(ctx, gitEvent, event.GetRepo(), run.PullRequests, run.GetCheckSuite().GetHeadBranch(), client)
+		return gitEvent
+	case *workflowRunEvent:
+		run := event.WorkflowRun
+		gitEvent := &git.Event{
+			Kind:       git.EventCheck,
+			Provider:   git.GITHUB,
+			Repo:       repo,
+			RepoName:   event.GetRepo().GetName(),
+			Commit:     run.HeadSHA,
+			CheckName:  run.Name,
+			CheckState: checkState(run.Conclusion),
+			CheckScope: git.CheckScopeWorkflow,
+			URL:        run.HTMLURL,
+		}
+		switch event.Action {
+		case "requested", "in_progress":
+			gitEvent.CheckState = "pending"
+		case "completed":
+		default:
+			return nil
+		}
+		if gitEvent.CheckState == "failure" {
+			gitEvent.FailedChecks = h.getFailedJobs(ctx, run.JobsURL, client)
+		}
+		h.setCheckTarget(ctx, gitEvent, event.GetRepo(), run.PullRequests, run.HeadBranch, client)
+		return gitEvent
+	case *workflowJobEvent:
+		job := event.WorkflowJob
+		// named like the job's check run, apps getting both events then
+		// show the job once on the commit's board
+		gitEvent := &git.Event{
+			Kind:       git.EventCheck,
+			Provider:   git.GITHUB,
+			Repo:       repo,
+			RepoName:   event.GetRepo().GetName(),
+			Commit:     job.HeadSHA,
+			CheckName:  job.Name,
+			CheckState: checkState(job.Conclusion),
+			CheckScope: git.CheckScopeJob,
+			URL:        job.HTMLURL,
+		}
+		switch event.Action {
+		case "queued", "in_progress":
+			gitEvent.CheckState = "pending"
+		case "completed":
+		default:
+			return nil
+		}
+		if gitEvent.CheckState == "" {
+			return nil
+		}
+		var prs []*github.PullRequest
+		if pr := h.findPullRequest(ctx, event.GetRepo(), job.HeadSHA, client); pr != nil {
+			prs = append(prs, pr)
+		}
+		h.setCheckTarget(ctx, gitEvent, event.GetRepo(), prs, job.HeadBranch, client)
+		return gitEvent
+	case *github.StatusEvent:
+		gitEvent := &git.Event{
+			Kind:       git.EventCheck,
+			Provider:   git.GITHUB,
+			Repo:       repo,
+			RepoName:   event.GetRepo().GetName(),
+			Commit:     event.GetSHA(),
+			CheckName:  event.GetContext(),
+			CheckState: checkState(event.GetState()),
+			URL:        event.GetTargetURL(),
+		}
+		if gitEvent.CheckState == "" || len(event.Branches) < 1 {
+			return nil
+		}
+		var prs []*github.PullRequest
+		if pr := h.findPullRequest(ctx, event.GetRepo(), event.GetSHA(), client); pr != nil {
+			prs = append(prs, pr)
+		}
+		h.setCheckTarget(ctx, gitEvent, event.GetRepo(), prs, event.Branches[0].GetName(), client)
+		return gitEvent
+	case *github.DeploymentStatusEvent:
+		deployment := event.GetDeployment()
+		return &git.Event{
+			Kind:        git.EventDeployment,
+			Provider:    git.GITHUB,
+			Repo:        repo,
+			RepoName:    event.GetRepo().GetName(),
+			Branch:      deployment.GetRef(),
+			Author:      deployment.GetCreator().GetLogin(),
+			CheckState:  checkState(event.GetDeploymentStatus().GetState()),
+			Environment: deployment.GetEnvironment(),
+			URL:         event.GetDeploymentStatus().GetTargetURL(),
+		}
+	}
+	return nil
+}
 
+// setCheckTarget sets whether the check is for one of prs, which are
+// opened against the repo, or for a branch. Only failures link to the
+// check's logs, checks of pull requests link to it otherwise.
+func (h *HTTPSrv) setCheckTarget(ctx context.Context, gitEvent *git.Event, repo *github.Repository, prs []*github.PullRequest,
+	branch string, client *github.Client) {
+	// the repo objects of check runs are very sparse, so we really only can check against the api url
+	var checkPR *github.PullRequest
+	repoAPIUrl := fmt.Sprintf("https://api.github.com/repos/%s", repo.GetFullName())
+	for _, pr := range prs {
+		if pr.GetBase().GetRepo().GetURL() == repoAPIUrl || pr.GetBase().GetRepo().GetFullName() == repo.GetFullName() {
+			checkPR = pr
+			break
+		}
+	}
+	if checkPR == nil {
 		// this is a branch test, not associated with a PR
-		var runPR *github.PullRequest
-		// the repo object returned by the GetCheckRun call is very sparse, so we really only can check against the api url
-		repoAPIUrl := fmt.Sprintf("https://api.github.com/repos/%s", repo)
-		for _, pr := range event.GetCheckRun().PullRequests {
-			if pr.GetBase().GetRepo().GetURL() == repoAPIUrl {
-				runPR = pr
-				break
-			}
+		gitEvent.Branch = branch
+		if gitEvent.CheckState != "failure" {
+			gitEvent.URL = ""
 		}
-		if runPR == nil {
-			// this is a branch test, not associated with a PR
-			branch = event.GetCheckRun().GetCheckSuite().GetHeadBranch()
-			// don't provide an author since it's not a PR
-			return formatCheckRunMessage(event, ""), branch
+		return
+	}
+	gitEvent.IsPullRequest = true
+	gitEvent.Number = checkPR.GetNumber()
+	if gitEvent.CheckState != "failure" {
+		gitEvent.URL = fmt.Sprintf("%s/pull/%d", repo.GetHTMLURL(), checkPR.GetNumber())
+	}
+	if login := checkPR.GetUser().GetLogin(); login != "" {
+		gitEvent.Author = login
+		return
+	}
+
+	// fetch the pull request object so we can get the right author
+	pr, _, err := client.PullRequests.Get(ctx, repo.GetOwner().GetLogin(), repo.GetName(), checkPR.GetNumber())
+	if err != nil {
+		if !strings.Contains(err.Error(), "401 Bad credentials") {
+			h.Errorf("Error getting pull request object: %s", err)
 		}
+		return
+	}
+	gitEvent.Author = pr.GetUser().GetLogin()
+}
 
-		// fetch the pull request object so we can get the right author
-		pr, _, err := client.PullRequests.Get(context.TODO(), parsedRepo[0], parsedRepo[1], runPR.GetNumber())
+// getPullRequestFiles returns the paths of the files changed by the pull
+// request, for path filters.
+func (h *HTTPSrv) getPullRequestFiles(ctx context.Context, owner, repo string, number int, client *github.Client) (res []string) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
 		if err != nil {
 			if !strings.Contains(err.Error(), "401 Bad credentials") {
-				h.Errorf("Error getting pull request object: %s", err)
+				h.Errorf("error getting pull request files: %s", err)
 			}
-			return formatCheckRunMessage(event, ""), branch
+			return res
 		}
-		author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, pr.GetUser().GetLogin(), convID)
-		return formatCheckRunMessage(event, author.String()), branch
-
-	case *github.StatusEvent:
-		var author username
-		pullRequests, _, err := client.PullRequests.ListPullRequestsWithCommit(
-			context.TODO(),
-			event.GetRepo().GetOwner().GetLogin(),
-			event.GetRepo().GetName(),
-			event.GetSHA(),
-			&github.PullRequestListOptions{
-				State:     "open",
-				Sort:      "updated",
-				Direction: "desc",
-			},
-		)
-		if err != nil && !strings.Contains(err.Error(), "401 Bad credentials") {
-			h.Errorf("error getting pull requests from commit: %s", err)
+		for _, file := range files {
+			res = append(res, file.GetFilename())
 		}
-
-		// look for PR where the base is the provided repo
-		var runPR *github.PullRequest
-		for _, pr := range pullRequests {
-			if pr.GetBase().GetRepo().GetFullName() == repo {
-				runPR = pr
-				break
-			}
+		if resp.NextPage == 0 {
+			return res
 		}
+		opts.Page = resp.NextPage
+	}
+}
 
-		if runPR != nil {
-			author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, runPR.GetUser().GetLogin(), convID)
-		} else if len(event.Branches) >= 1 {
-			// this is a branch test, not associated with a PR
-			branch = event.Branches[0].GetName()
-			author = getPossibleKBUser(h.kbc, h.db, h.DebugOutput, event.GetCommit().GetAuthor().GetLogin(), convID)
-		} else {
-			h.Debug("status event had no pull requests or branches")
-			return "", ""
-		}
+// findPullRequest returns the most recently updated open pull request with
+// the commit, if any.
+func (h *HTTPSrv) findPullRequest(ctx context.Context, repo *github.Repository, sha string, client *github.Client) *github.PullRequest {
+	if sha == "" {
+		return nil
+	}
+	pullRequests, _, err := client.PullRequests.ListPullRequestsWithCommit(
+		ctx,
+		repo.GetOwner().GetLogin(),
+		repo.GetName(),
+		sha,
+		&github.PullRequestListOptions{
+			State:     "open",
+			Sort:      "updated",
+			Direction: "desc",
+		},
+	)
+	if err != nil && !strings.Contains(err.Error(), "401 Bad credentials") {
+		h.Errorf("error getting pull requests from commit: %s", err)
+	}
 
-		return formatStatusMessage(event, pullRequests, author.String()), branch
+	// look for PR where the base is the provided repo
+	for _, pr := range pullRequests {
+		if pr.GetBase().GetRepo().GetFullName() == repo.GetFullName() {
+			return pr
+		}
 	}
-	return "", ""
+	return nil
 }
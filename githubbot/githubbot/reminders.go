@@ -0,0 +1,277 @@
+package githubbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+// ReminderJobName is the scheduler job nudging a subscription's reviewers.
+const ReminderJobName = "githubbot.reminders"
+
+// reminderSchedule is when a subscription is reminded of the pull requests
+// waiting for review for longer than Threshold.
+type reminderSchedule struct {
+	Threshold time.Duration
+	// Weekdays skips the weekend if set, reminders are sent every day
+	// otherwise.
+	Weekdays bool
+	At       string
+	Timezone string
+}
+
+// parseThreshold parses a duration such as `24h`, also accepting days such
+// as `2d`.
+func parseThreshold(arg string) (time.Duration, error) {
+	var threshold time.Duration
+	var err error
+	if days := strings.TrimSuffix(arg, "d"); days != arg {
+		var n int
+		n, err = strconv.Atoi(days)
+		threshold = time.Duration(n) * 24 * time.Hour
+	} else {
+		threshold, err = time.ParseDuration(arg)
+	}
+	if err != nil || threshold < time.Hour {
+		return 0, fmt.Errorf("`%s` isn't a duration of at least an hour such as `24h` or `2d`", arg)
+	}
+	return threshold, nil
+}
+
+// parseReminderSchedule parses `<threshold> [weekdays|daily] [HH:MM]
+// [timezone]`.
+func parseReminderSchedule(args []string) (*reminderSchedule, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected how long pull requests may wait, such as `24h`")
+	}
+	threshold, err := parseThreshold(strings.ToLower(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	schedule := &reminderSchedule{
+		Threshold: threshold,
+		Weekdays:  true,
+		At:        "10:00",
+		Timezone:  "UTC",
+	}
+	args = args[1:]
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "weekdays":
+			args = args[1:]
+		case "daily":
+			schedule.Weekdays = false
+			args = args[1:]
+		}
+	}
+	if err := parseTimeAndZone(args, &schedule.At, &schedule.Timezone); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s reminderSchedule) cronSpec() string {
+	at, _ := time.Parse("15:04", s.At)
+	days := "*"
+	if s.Weekdays {
+		days = "1-5"
+	}
+	return fmt.Sprintf("CRON_TZ=%s %d %d * * %s", s.Timezone, at.Minute(), at.Hour(), days)
+}
+
+func (s reminderSchedule) String() string {
+	days := "every day"
+	if s.Weekdays {
+		days = "on weekdays"
+	}
+	return fmt.Sprintf("pull requests waiting for review for over %s, %s at %s (%s)",
+		formatWaiting(s.Threshold), days, s.At, s.Timezone)
+}
+
+// formatWaiting rounds the duration to days, or hours under two days.
+func formatWaiting(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours < 48 {
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d days", hours/24)
+}
+
+type reminderJobPayload struct {
+	ConvID chat1.ConvIDStr
+	Repo   string
+}
+
+// stalePullRequest is a pull request with pending review requests and no
+// activity since UpdatedAt.
+type stalePullRequest struct {
+	Repo      string
+	Number    int
+	Title     string
+	Author    string
+	Reviewers []string
+	UpdatedAt time.Time
+}
+
+// formatReminder lists the stale pull requests, mention shows a reviewer's
+// GitHub username in the conversation. The repo of each pull request is
+// shown for subscriptions to a pattern.
+func formatReminder(repo string, threshold time.Duration, prs []stalePullRequest, now time.Time,
+	mention func(username string) string) string {
+	res := fmt.Sprintf("Pull requests on %s waiting for review for over %s:", formatRepo(repo), formatWaiting(threshold))
+	for _, pr := range prs {
+		ref := fmt.Sprintf("#%d", pr.Number)
+		if git.IsRepoPattern(repo) {
+			ref = pr.Repo + ref
+		}
+		var reviewers []string
+		for _, reviewer := range pr.Reviewers {
+			if strings.Contains(reviewer, "/") {
+				// teams aren't keybase users
+				reviewers = append(reviewers, reviewer)
+			} else {
+				reviewers = append(reviewers, mention(reviewer))
+			}
+		}
+		res += fmt.Sprintf("\n• %s “%s” by %s, waiting %s on %s", ref, pr.Title, pr.Author,
+			formatWaiting(now.Sub(pr.UpdatedAt)), strings.Join(reviewers, ", "))
+	}
+	return res
+}
+
+// RunReminderJob nudges the reviewers of the stale pull requests of the
+// subscription in the job's payload.
+func (h *Handler) RunReminderJob(job base.Job) error {
+	var payload reminderJobPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	schedule, err := h.db.GetReminderSchedule(payload.ConvID, payload.Repo)
+	if err != nil {
+		return err
+	}
+	installationID, err := h.db.GetSubscriptionInstallationID(payload.ConvID, payload.Repo)
+	if err != nil {
+		return err
+	}
+	if schedule == nil || installationID == 0 {
+		// reminders were turned off or the subscription is gone
+		return h.scheduler.Cancel(job.ID)
+	}
+
+	prs, err := h.getStalePullRequests(context.Background(), payload.Repo, schedule.Threshold, job.RunAt, h.getInstallationClient(installationID))
+	if err != nil {
+		return err
+	}
+	if len(prs) == 0 {
+		return nil
+	}
+	text := formatReminder(payload.Repo, schedule.Threshold, prs, job.RunAt, func(username string) string {
+		return getPossibleKBUser(h.kbc, h.db, h.DebugOutput, username, payload.ConvID).String()
+	})
+	err = base.SendLongMessageByConvID(h.kbc, h.DebugOutput, payload.ConvID, "", text)
+	if h.CheckDeletedConv(payload.ConvID, err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	h.stats.Count("reminders - sent")
+	return nil
+}
+
+// getStalePullRequests returns the open pull requests with pending review
+// requests which weren't updated within threshold of now, of the repo or of
+// the installation's repos matching the pattern.
+func (h *Handler) getStalePullRequests(ctx context.Context, repo string, threshold time.Duration, now time.Time,
+	client *github.Client) (res []stalePullRequest, err error) {
+	if !git.IsRepoPattern(repo) {
+		return h.getRepoStalePullRequests(ctx, repo, threshold, now, client)
+	}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := client.Apps.ListRepos(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			if r.GetArchived() || !git.MatchRepo(repo, r.GetFullName()) {
+				continue
+			}
+			prs, err := h.getRepoStalePullRequests(ctx, strings.ToLower(r.GetFullName()), threshold, now, client)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, prs...)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].UpdatedAt.Before(res[j].UpdatedAt)
+	})
+	return res, nil
+}
+
+func (h *Handler) getRepoStalePullRequests(ctx context.Context, repo string, threshold time.Duration, now time.Time,
+	client *github.Client) (res []stalePullRequest, err error) {
+	parsedRepo := strings.Split(repo, "/")
+	if len(parsedRepo) != 2 {
+		return nil, fmt.Errorf("invalid repo: %s", repo)
+	}
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		Sort:        "updated",
+		Direction:   "asc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		prs, resp, err := client.PullRequests.List(ctx, parsedRepo[0], parsedRepo[1], opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			if now.Sub(pr.GetUpdatedAt()) < threshold {
+				// sorted by update, the rest are more recent
+				return res, nil
+			}
+			if pr.GetDraft() {
+				continue
+			}
+			var reviewers []string
+			for _, user := range pr.RequestedReviewers {
+				reviewers = append(reviewers, user.GetLogin())
+			}
+			for _, team := range pr.RequestedTeams {
+				reviewers = append(reviewers, fmt.Sprintf("%s/%s", parsedRepo[0], team.GetSlug()))
+			}
+			if len(reviewers) == 0 {
+				continue
+			}
+			res = append(res, stalePullRequest{
+				Repo:      repo,
+				Number:    pr.GetNumber(),
+				Title:     pr.GetTitle(),
+				Author:    pr.GetUser().GetLogin(),
+				Reviewers: reviewers,
+				UpdatedAt: pr.GetUpdatedAt(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return res, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,50 @@
+package githubbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderSchedule(t *testing.T) {
+	schedule, err := parseReminderSchedule([]string{"24h", "weekdays", "10:00", "America/New_York"})
+	require.NoError(t, err)
+	require.Equal(t, "CRON_TZ=America/New_York 0 10 * * 1-5", schedule.cronSpec())
+	require.Equal(t, "pull requests waiting for review for over 24 hours, on weekdays at 10:00 (America/New_York)",
+		schedule.String())
+
+	schedule, err = parseReminderSchedule([]string{"3d", "daily"})
+	require.NoError(t, err)
+	require.Equal(t, 72*time.Hour, schedule.Threshold)
+	require.Equal(t, "CRON_TZ=UTC 0 10 * * *", schedule.cronSpec())
+
+	for _, args := range [][]string{{}, {"10m"}, {"soon"}, {"24h", "monthly"}, {"24h", "10:00", "Nowhere/Town"}} {
+		_, err = parseReminderSchedule(args)
+		require.Error(t, err, "%v", args)
+	}
+}
+
+func TestFormatReminder(t *testing.T) {
+	now := time.Date(2020, 4, 6, 10, 0, 0, 0, time.UTC)
+	prs := []stalePullRequest{
+		{Number: 3, Title: "Fix crash", Author: "alice", Reviewers: []string{"bob", "keybase/design"},
+			UpdatedAt: now.Add(-72 * time.Hour)},
+		{Number: 5, Title: "Add tests", Author: "bob", Reviewers: []string{"carol"}, UpdatedAt: now.Add(-30 * time.Hour)},
+	}
+	require.Equal(t, "Pull requests on *keybase/client* waiting for review for over 24 hours:\n"+
+		"• #3 “Fix crash” by alice, waiting 3 days on @bob, keybase/design\n"+
+		"• #5 “Add tests” by bob, waiting 30 hours on @carol",
+		formatReminder("keybase/client", 24*time.Hour, prs, now, func(username string) string { return "@" + username }))
+}
+
+func TestFormatReminderPattern(t *testing.T) {
+	now := time.Date(2020, 4, 6, 10, 0, 0, 0, time.UTC)
+	prs := []stalePullRequest{
+		{Repo: "keybase/kbfs", Number: 3, Title: "Fix crash", Author: "alice", Reviewers: []string{"bob"},
+			UpdatedAt: now.Add(-72 * time.Hour)},
+	}
+	require.Equal(t, "Pull requests on `keybase/*` waiting for review for over 24 hours:\n"+
+		"• keybase/kbfs#3 “Fix crash” by alice, waiting 3 days on @bob",
+		formatReminder("keybase/*", 24*time.Hour, prs, now, func(username string) string { return "@" + username }))
+}
@@ -0,0 +1,68 @@
+package githubbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+var setupFeatures = git.FeatureNames()
+
+// setupSteps asks which repository to subscribe to and which of its events
+// to hear about.
+func (h *Handler) setupSteps() []base.WizardStep {
+	return []base.WizardStep{
+		{
+			Prompt: fmt.Sprintf("Which repository should I notify you about? Make sure the Keybase integration is installed on it (github.com/apps/%s/installations/new), then reply with `owner/repo`.", h.appName),
+			Commands: func(answers []string) ([]string, error) {
+				repo := answers[len(answers)-1]
+				if toks := strings.Split(repo, "/"); len(toks) != 2 || toks[0] == "" || toks[1] == "" ||
+					strings.ContainsAny(repo, " \t") {
+					return nil, base.WizardAnswerError("That doesn't look like a repository, it should be something like `keybase/client`.")
+				}
+				return []string{fmt.Sprintf("!github subscribe %s", repo)}, nil
+			},
+			Check: func(msg chat1.MsgSummary, answers []string) (bool, error) {
+				return h.db.GetSubscriptionForRepoExists(msg.ConvID, subscriptionRepo(answers[len(answers)-1]))
+			},
+		},
+		{
+			Prompt: fmt.Sprintf("Which events do you want to hear about? Reply with any of `%s`, or `all`.",
+				strings.Join(setupFeatures, " ")),
+			Commands: func(answers []string) ([]string, error) {
+				repo := answers[0]
+				if strings.EqualFold(repo, "skip") {
+					return nil, nil
+				}
+				answer := strings.ToLower(answers[len(answers)-1])
+				if answer == "all" {
+					return nil, nil
+				}
+				// a subscription starts out with every feature, enabling the
+				// first one turns the rest off
+				var cmds []string
+				for _, feature := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+					found := false
+					for _, known := range setupFeatures {
+						found = found || feature == known
+					}
+					if !found {
+						return nil, base.WizardAnswerError(fmt.Sprintf("I don't know about `%s` events.", feature))
+					}
+					cmds = append(cmds, fmt.Sprintf("!github subscribe %s %s", repo, feature))
+				}
+				if len(cmds) == 0 {
+					return nil, base.WizardAnswerError("Pick at least one kind of event.")
+				}
+				return cmds, nil
+			},
+			Check: func(msg chat1.MsgSummary, answers []string) (bool, error) {
+				features, err := h.db.GetFeatures(msg.ConvID, subscriptionRepo(answers[0]))
+				return features != nil, err
+			},
+		},
+	}
+}
@@ -0,0 +1,272 @@
+package githubbot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+)
+
+const (
+	// unfurlCacheExpiry is how long looked up issues are reused, so a busy
+	// conversation doesn't hit the API for every mention.
+	unfurlCacheExpiry = 5 * time.Minute
+	// unfurlMaxRefs is the most references unfurled per message.
+	unfurlMaxRefs = 3
+)
+
+var (
+	issueRefRegex = regexp.MustCompile(`(?:^|[\s(\[])([\w.-]+/[\w.-]+)#(\d+)\b`)
+	issueURLRegex = regexp.MustCompile(`(?:https?://)?github\.com/([\w.-]+/[\w.-]+)/(?:issues|pull)/(\d+)`)
+	codeRegex     = regexp.MustCompile("(?s)```.*?```|`[^`]*`")
+)
+
+type issueRef struct {
+	Repo   string
+	Number int
+}
+
+func (r issueRef) String() string {
+	return fmt.Sprintf("%s#%d", r.Repo, r.Number)
+}
+
+// findIssueRefs returns the `owner/repo#123` references and issue and pull
+// request URLs in the message, leaving out code.
+func findIssueRefs(text string) (res []issueRef) {
+	text = codeRegex.ReplaceAllString(text, " ")
+	seen := map[issueRef]bool{}
+	for _, re := range []*regexp.Regexp{issueURLRegex, issueRefRegex} {
+		for _, match := range re.FindAllStringSubmatch(text, -1) {
+			number, err := strconv.Atoi(match[2])
+			if err != nil {
+				continue
+			}
+			ref := issueRef{Repo: strings.ToLower(match[1]), Number: number}
+			if !seen[ref] {
+				seen[ref] = true
+				res = append(res, ref)
+			}
+		}
+	}
+	return res
+}
+
+// issueSummary is what's shown of an unfurled issue or pull request.
+type issueSummary struct {
+	Title         string
+	State         string
+	Author        string
+	Labels        []string
+	IsPullRequest bool
+	Draft         bool
+	Merged        bool
+	// Approvals and ChangesRequested count the latest review of each
+	// reviewer.
+	Approvals        int
+	ChangesRequested int
+	// CIState is the combined state of the head commit's statuses and
+	// checks, empty if it has none.
+	CIState string
+}
+
+type cachedIssueSummary struct {
+	summary    *issueSummary
+	expireTime time.Time
+}
+
+func formatIssueSummary(ref issueRef, summary *issueSummary) string {
+	kind := "issue"
+	if summary.IsPullRequest {
+		kind = "pull request"
+	}
+	state := summary.State
+	switch {
+	case summary.Merged:
+		state = "merged"
+	case summary.Draft && state == "open":
+		state = "draft"
+	}
+	res := fmt.Sprintf("*%s* “%s”\n%s%s %s by %s", ref, summary.Title,
+		strings.ToUpper(state[:1]), state[1:], kind, summary.Author)
+	if len(summary.Labels) > 0 {
+		res += fmt.Sprintf(" · Labels: %s", strings.Join(summary.Labels, ", "))
+	}
+	if !summary.IsPullRequest {
+		return res
+	}
+	var reviews []string
+	if summary.Approvals > 0 {
+		reviews = append(reviews, fmt.Sprintf("%d approved", summary.Approvals))
+	}
+	if summary.ChangesRequested > 0 {
+		reviews = append(reviews, fmt.Sprintf("%d changes requested", summary.ChangesRequested))
+	}
+	if len(reviews) == 0 {
+		reviews = append(reviews, "none yet")
+	}
+	res += fmt.Sprintf("\nReviews: %s", strings.Join(reviews, ", "))
+	switch summary.CIState {
+	case "success":
+		res += " · Checks: :white_check_mark: passing"
+	case "failure":
+		res += " · Checks: :x: failing"
+	case "pending":
+		res += " · Checks: :hourglass_flowing_sand: running"
+	}
+	return res
+}
+
+// combineCIStates returns failure if any of the states failed, pending if
+// any is still running and success if they all passed.
+func combineCIStates(states []string) (res string) {
+	for _, state := range states {
+		switch state {
+		case "failure":
+			return "failure"
+		case "pending":
+			res = "pending"
+		case "success":
+			if res == "" {
+				res = "success"
+			}
+		}
+	}
+	return res
+}
+
+// handleUnfurl replies with a summary of the issues and pull requests
+// referenced in the message, for repos the conversation is subscribed to.
+func (h *Handler) handleUnfurl(ctx context.Context, msg chat1.MsgSummary) error {
+	if msg.Sender.Username == h.kbc.GetUsername() {
+		// notifications link to issues too
+		return nil
+	}
+	refs := findIssueRefs(msg.Content.Text.Body)
+	if len(refs) == 0 {
+		return nil
+	}
+	var summaries []string
+	for _, ref := range refs {
+		if len(summaries) == unfurlMaxRefs {
+			break
+		}
+		installationID, err := h.db.GetSubscriptionInstallationID(msg.ConvID, ref.Repo)
+		if err != nil {
+			return fmt.Errorf("error getting subscription: %s", err)
+		} else if installationID == 0 {
+			continue
+		}
+		summary, err := h.getIssueSummary(ctx, ref, h.getInstallationClient(installationID))
+		if err != nil {
+			h.Debug("unable to unfurl %s: %s", ref, err)
+			continue
+		}
+		summaries = append(summaries, formatIssueSummary(ref, summary))
+	}
+	if len(summaries) == 0 {
+		return nil
+	}
+	h.stats.Count("unfurl")
+	h.ChatEcho(msg.ConvID, "%s", strings.Join(summaries, "\n\n"))
+	return nil
+}
+
+// getIssueSummary looks up the issue or pull request, reusing recent
+// lookups.
+func (h *Handler) getIssueSummary(ctx context.Context, ref issueRef, client *github.Client) (*issueSummary, error) {
+	h.Lock()
+	cached, ok := h.unfurlCache[ref]
+	h.Unlock()
+	if ok && time.Now().Before(cached.expireTime) {
+		return cached.summary, nil
+	}
+
+	summary, err := fetchIssueSummary(ctx, ref, client)
+	if err != nil {
+		return nil, err
+	}
+	h.Lock()
+	defer h.Unlock()
+	for key, cached := range h.unfurlCache {
+		if time.Now().After(cached.expireTime) {
+			delete(h.unfurlCache, key)
+		}
+	}
+	h.unfurlCache[ref] = cachedIssueSummary{summary: summary, expireTime: time.Now().Add(unfurlCacheExpiry)}
+	return summary, nil
+}
+
+func fetchIssueSummary(ctx context.Context, ref issueRef, client *github.Client) (*issueSummary, error) {
+	parsedRepo := strings.Split(ref.Repo, "/")
+	owner, repo := parsedRepo[0], parsedRepo[1]
+
+	issue, _, err := client.Issues.Get(ctx, owner, repo, ref.Number)
+	if err != nil {
+		return nil, err
+	}
+	summary := &issueSummary{
+		Title:         issue.GetTitle(),
+		State:         issue.GetState(),
+		Author:        issue.GetUser().GetLogin(),
+		Labels:        getLabelNames(issue.Labels),
+		IsPullRequest: issue.IsPullRequest(),
+	}
+	if !summary.IsPullRequest {
+		return summary, nil
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, ref.Number)
+	if err != nil {
+		return nil, err
+	}
+	summary.Draft = pr.GetDraft()
+	summary.Merged = pr.GetMerged()
+
+	reviews, _, err := client.PullRequests.ListReviews(ctx, owner, repo, ref.Number, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]string{}
+	for _, review := range reviews {
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.GetUser().GetLogin()] = state
+		}
+	}
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			summary.Approvals++
+		case "CHANGES_REQUESTED":
+			summary.ChangesRequested++
+		}
+	}
+
+	sha := pr.GetHead().GetSHA()
+	var states []string
+	status, _, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range status.Statuses {
+		states = append(states, checkState(status.GetState()))
+	}
+	checkRuns, _, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range checkRuns.CheckRuns {
+		if run.GetStatus() != "completed" {
+			states = append(states, "pending")
+			continue
+		}
+		states = append(states, checkState(run.GetConclusion()))
+	}
+	summary.CIState = combineCIStates(states)
+	return summary, nil
+}
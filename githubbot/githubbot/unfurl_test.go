@@ -0,0 +1,28 @@
+package githubbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindIssueRefs(t *testing.T) {
+	refs := findIssueRefs("see Keybase/client#12 and https://github.com/keybase/client/pull/34, " +
+		"not `keybase/client#56` or foo#78, (keybase/go-keybase-chat-bot#9) keybase/client#12")
+	require.Equal(t, []issueRef{
+		{Repo: "keybase/client", Number: 34},
+		{Repo: "keybase/client", Number: 12},
+		{Repo: "keybase/go-keybase-chat-bot", Number: 9},
+	}, refs)
+}
+
+func TestFormatIssueSummary(t *testing.T) {
+	ref := issueRef{Repo: "keybase/client", Number: 12}
+	require.Equal(t, "*keybase/client#12* “Crash”\nClosed issue by alice · Labels: bug",
+		formatIssueSummary(ref, &issueSummary{Title: "Crash", State: "closed", Author: "alice", Labels: []string{"bug"}}))
+	require.Equal(t, "*keybase/client#12* “Fix crash”\nDraft pull request by bob\nReviews: 1 approved, 1 changes requested · Checks: :x: failing",
+		formatIssueSummary(ref, &issueSummary{Title: "Fix crash", State: "open", Author: "bob", IsPullRequest: true,
+			Draft: true, Approvals: 1, ChangesRequested: 1, CIState: combineCIStates([]string{"success", "pending", "failure"})}))
+	require.Equal(t, "pending", combineCIStates([]string{"success", "pending", ""}))
+	require.Equal(t, "", combineCIStates(nil))
+}
@@ -2,15 +2,18 @@ package githubbot
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 
 	"github.com/google/go-github/v31/github"
 )
@@ -23,119 +26,99 @@ func getCommitMessages(event *github.PushEvent) []string {
 	return commitMsgs
 }
 
-func formatPRUsername(isPullRequest bool, res, username string) string {
-	if isPullRequest && res != "" && username != "" {
-		if strings.HasPrefix(username, "@") {
-			return fmt.Sprintf("%s\n%s", res, username)
+// getChangedPaths returns the files added, modified or removed by the
+// pushed commits.
+func getChangedPaths(event *github.PushEvent) (res []string) {
+	seen := map[string]bool{}
+	for _, commit := range event.Commits {
+		for _, files := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range files {
+				if !seen[file] {
+					seen[file] = true
+					res = append(res, file)
+				}
+			}
 		}
-		return fmt.Sprintf("%s\n(for %s)", res, username)
 	}
 	return res
 }
 
-// formatters
-
-func formatCheckRunMessage(evt *github.CheckRunEvent, username string) (res string) {
-	action := evt.Action
-	if action == nil || *action != "completed" {
-		return ""
+func getLabelNames(labels []*github.Label) (res []string) {
+	for _, label := range labels {
+		res = append(res, label.GetName())
 	}
+	return res
+}
 
-	run := evt.GetCheckRun()
-	repo := evt.GetRepo().GetName()
-	isPullRequest := len(run.PullRequests) > 0
-	var testName string
-	if run.GetName() != "" {
-		testName = fmt.Sprintf("*%s*", run.GetName())
-	} else {
-		testName = "Test"
+// parseTimeAndZone parses the optional `[HH:MM] [timezone]` ending a
+// command, leaving the defaults of the missing ones.
+func parseTimeAndZone(args []string, at, timezone *string) error {
+	if len(args) > 0 && strings.Contains(args[0], ":") {
+		parsed, err := time.Parse("15:04", args[0])
+		if err != nil {
+			return fmt.Errorf("`%s` isn't a time such as `09:00`", args[0])
+		}
+		*at = parsed.Format("15:04")
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		if _, err := time.LoadLocation(args[0]); err != nil {
+			return fmt.Errorf("`%s` isn't a timezone such as `America/New_York`", args[0])
+		}
+		*timezone = args[0]
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected `%s`", strings.Join(args, " "))
 	}
+	return nil
+}
 
-	defer func() {
-		res = formatPRUsername(isPullRequest, res, username)
-	}()
+// subscriptionJobID is unique per job and subscription, the repo is hashed
+// to fit the jobs table's key. The jobs are canceled with the conversation's
+// other state once it's gone.
+func subscriptionJobID(jobName string, convID chat1.ConvIDStr, repo string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(repo)))
+	return base.ConvJobID("githubbot", convID, fmt.Sprintf("%s:%x", jobName, hash[:8]))
+}
 
-	switch run.GetConclusion() {
+// checkState translates check conclusions and commit status states, it
+// returns an empty string for the ones not worth a message.
+func checkState(state string) string {
+	switch state {
 	case "success":
-		if !isPullRequest {
-			return fmt.Sprintf(":white_check_mark: %s passed for %s/%s.", testName, repo, run.GetCheckSuite().GetHeadBranch())
-		}
-		pr := run.PullRequests[0]
-		return fmt.Sprintf(":white_check_mark: %s passed for pull request #%d on %s.\n%s/pull/%d", testName, pr.GetNumber(), repo, evt.GetRepo().GetHTMLURL(), pr.GetNumber())
-	case "failure", "timed_out", "action_required":
-		urlSplit := strings.Split(run.GetHTMLURL(), "://")
-		var targetURL string
-		if len(urlSplit) != 2 {
-			// if the CI target URL isn't formatted as expected, just skip it
-			targetURL = ""
-		} else {
-			targetURL = urlSplit[1]
-		}
-
-		if !isPullRequest {
-			return fmt.Sprintf(":x: %s failed for %s/%s.\n%s", testName, repo, run.GetCheckSuite().GetHeadBranch(), targetURL)
-		}
-		pr := run.PullRequests[0]
-		return fmt.Sprintf(":x: %s failed for pull request #%d on %s.\n%s", testName, pr.GetNumber(), repo, targetURL)
+		return "success"
+	case "failure", "error", "timed_out", "action_required":
+		return "failure"
 	case "cancelled":
-		if !isPullRequest {
-			return fmt.Sprintf(":warning: %s cancelled for %s/%s.", testName, repo, run.GetCheckSuite().GetHeadBranch())
-		}
-		pr := run.PullRequests[0]
-		return fmt.Sprintf(":warning: %s cancelled for pull request #%d on %s.\n%s/pull/%d", testName, pr.GetNumber(), repo, evt.GetRepo().GetHTMLURL(), pr.GetNumber())
+		return "cancelled"
+	case "pending", "queued", "in_progress":
+		return "pending"
 	default:
 		return ""
 	}
 }
 
-func formatStatusMessage(evt *github.StatusEvent, pullRequests []*github.PullRequest, username string) (res string) {
-	state := evt.GetState()
-	repo := evt.GetRepo().GetName()
-	isPullRequest := len(pullRequests) > 0
-	var branch string
-	if len(evt.Branches) < 1 {
-		return ""
-	}
-	branch = evt.Branches[0].GetName()
-	var testName string
-	if evt.GetContext() != "" {
-		testName = fmt.Sprintf("*%s*", evt.GetContext())
-	} else {
-		testName = "Test"
+// subscriptionRepo returns the repo or pattern a subscription command is
+// about, an owner alone subscribes to all of its repos.
+func subscriptionRepo(arg string) string {
+	arg = strings.ToLower(arg)
+	if !strings.Contains(arg, "/") {
+		return arg + "/*"
 	}
+	return arg
+}
 
-	defer func() {
-		res = formatPRUsername(isPullRequest, res, username)
-	}()
-
-	switch state {
-	case "success":
-		if !isPullRequest {
-			return fmt.Sprintf(":white_check_mark: %s passed for %s/%s.", testName, repo, branch)
-		}
-		pr := pullRequests[0]
-		return fmt.Sprintf(":white_check_mark: %s passed for pull request #%d on %s.\n%s/pull/%d", testName, pr.GetNumber(), repo, evt.GetRepo().GetHTMLURL(), pr.GetNumber())
-	case "failure", "error":
-		var targetURL string
-		urlSplit := strings.Split(evt.GetTargetURL(), "://")
-		if len(urlSplit) != 2 {
-			// if the CI target URL isn't formatted as expected, just skip it
-			targetURL = ""
-		} else {
-			targetURL = urlSplit[1]
-		}
-
-		if !isPullRequest {
-			return fmt.Sprintf(":x: %s failed for %s/%s.\n%s", testName, repo, branch, targetURL)
-		}
-		pr := pullRequests[0]
-		return fmt.Sprintf(":x: %s failed for pull request #%d on %s.\n%s", testName, pr.GetNumber(), repo, targetURL)
-	default:
-		return ""
+// formatRepo emphasizes the repo in titles, patterns are shown as code since
+// their `*` would be taken for markdown.
+func formatRepo(repo string) string {
+	if git.IsRepoPattern(repo) {
+		return fmt.Sprintf("`%s`", repo)
 	}
+	return fmt.Sprintf("*%s*", repo)
 }
 
-func GetDefaultBranch(repo string, client *github.Client) (branch string, err error) {
+func GetDefaultBranch(ctx context.Context, repo string, client *github.Client) (branch string, err error) {
 	args := strings.Split(repo, "/")
 	if len(args) != 2 {
 		return "", fmt.Errorf("getDefaultBranch: invalid repo %s", repo)
@@ -145,7 +128,7 @@ func GetDefaultBranch(repo string, client *github.Client) (branch string, err er
 		return "", fmt.Errorf("getDefaultBranch: client is nil")
 	}
 
-	repoObject, res, err := client.Repositories.Get(context.TODO(), args[0], args[1])
+	repoObject, res, err := client.Repositories.Get(ctx, args[0], args[1])
 	if err != nil {
 		return "", err
 	}
@@ -203,24 +186,3 @@ func getPossibleKBUser(kbc *kbchat.API, d *DB, debug *base.DebugOutput, githubUs
 
 	return u
 }
-
-// pref checking
-func shouldParseEvent(event interface{}, features *Features) bool {
-	if features == nil {
-		return true
-	}
-	switch event.(type) {
-	case *github.IssuesEvent:
-		return features.Issues
-	case *github.PullRequestEvent:
-		return features.PullRequests
-	case *github.PushEvent:
-		return features.Commits
-	case *github.ReleaseEvent:
-		return features.Releases
-	case *github.CheckRunEvent, *github.StatusEvent:
-		return features.Statuses
-	default:
-		return false
-	}
-}
@@ -0,0 +1,105 @@
+package githubbot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// The GitHub Actions events aren't known to go-github yet, only the fields
+// the bot uses are decoded.
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads#workflow_run
+
+type workflowRunEvent struct {
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		Name         string                `json:"name"`
+		HeadBranch   string                `json:"head_branch"`
+		HeadSHA      string                `json:"head_sha"`
+		Conclusion   string                `json:"conclusion"`
+		HTMLURL      string                `json:"html_url"`
+		JobsURL      string                `json:"jobs_url"`
+		PullRequests []*github.PullRequest `json:"pull_requests"`
+	} `json:"workflow_run"`
+	Repo         *github.Repository   `json:"repository"`
+	Installation *github.Installation `json:"installation"`
+}
+
+func (e *workflowRunEvent) GetRepo() *github.Repository {
+	return e.Repo
+}
+
+func (e *workflowRunEvent) GetInstallation() *github.Installation {
+	return e.Installation
+}
+
+type workflowJobEvent struct {
+	Action      string `json:"action"`
+	WorkflowJob struct {
+		Name         string `json:"name"`
+		WorkflowName string `json:"workflow_name"`
+		HeadBranch   string `json:"head_branch"`
+		HeadSHA      string `json:"head_sha"`
+		Conclusion   string `json:"conclusion"`
+		HTMLURL      string `json:"html_url"`
+	} `json:"workflow_job"`
+	Repo         *github.Repository   `json:"repository"`
+	Installation *github.Installation `json:"installation"`
+}
+
+func (e *workflowJobEvent) GetRepo() *github.Repository {
+	return e.Repo
+}
+
+func (e *workflowJobEvent) GetInstallation() *github.Installation {
+	return e.Installation
+}
+
+// parseWebHook is github.ParseWebHook, with the GitHub Actions events.
+func parseWebHook(messageType string, payload []byte) (interface{}, error) {
+	var event interface{}
+	switch messageType {
+	case "workflow_run":
+		event = &workflowRunEvent{}
+	case "workflow_job":
+		event = &workflowJobEvent{}
+	default:
+		return github.ParseWebHook(messageType, payload)
+	}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// getFailedJobs returns the names of the failed jobs of a workflow run.
+func (h *HTTPSrv) getFailedJobs(ctx context.Context, jobsURL string, client *github.Client) (res []string) {
+	if jobsURL == "" {
+		return nil
+	}
+	req, err := client.NewRequest("GET", jobsURL+"?filter=latest", nil)
+	if err != nil {
+		h.Errorf("error creating jobs request: %s", err)
+		return nil
+	}
+	var jobs struct {
+		Jobs []struct {
+			Name       string `json:"name"`
+			Conclusion string `json:"conclusion"`
+		} `json:"jobs"`
+	}
+	if _, err := client.Do(ctx, req, &jobs); err != nil {
+		if !strings.Contains(err.Error(), "401 Bad credentials") {
+			h.Errorf("error getting workflow jobs: %s", err)
+		}
+		return nil
+	}
+	for _, job := range jobs.Jobs {
+		if checkState(job.Conclusion) == "failure" {
+			res = append(res, job.Name)
+		}
+	}
+	return res
+}
@@ -0,0 +1,32 @@
+package githubbot
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWebHook(t *testing.T) {
+	event, err := parseWebHook("workflow_job", []byte(`{
+		"action": "completed",
+		"workflow_job": {"name": "test", "workflow_name": "CI", "head_sha": "abc", "conclusion": "failure"},
+		"repository": {"full_name": "keybase/managed-bots"},
+		"installation": {"id": 1}
+	}`))
+	require.NoError(t, err)
+	job, ok := event.(*workflowJobEvent)
+	require.True(t, ok)
+	require.Equal(t, "completed", job.Action)
+	require.Equal(t, "test", job.WorkflowJob.Name)
+	require.Equal(t, "failure", job.WorkflowJob.Conclusion)
+	require.Equal(t, int64(1), job.GetInstallation().GetID())
+
+	event, err = parseWebHook("workflow_run", []byte(`{"action": "requested", "workflow_run": {"name": "CI"}}`))
+	require.NoError(t, err)
+	require.IsType(t, &workflowRunEvent{}, event)
+
+	event, err = parseWebHook("check_run", []byte(`{"action": "created"}`))
+	require.NoError(t, err)
+	require.IsType(t, &github.CheckRunEvent{}, event)
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 	"github.com/keybase/managed-bots/githubbot/githubbot"
 	"golang.org/x/oauth2"
 	oauth2github "golang.org/x/oauth2/github"
@@ -48,7 +50,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("githubbot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("githubbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -59,29 +61,35 @@ func NewBotServer(opts Options) *BotServer {
 const backs = "```"
 
 func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	features := strings.Join(git.FeatureNames(), ", ")
 	subExtended := fmt.Sprintf(`Enables posting updates from the provided GitHub repository to this conversation.
 
 Running this command without a branch or event type will subscribe you to all events on the specified repository's default branch.
 
-Event type must be one of %sissues, pulls, commits, statuses%s
+Subscribing to an owner, or to a pattern such as %skeybase/service-*%s, covers every repository of the owner's installation matching it, including ones created later, on their %smain%s and %smaster%s branches.
+
+Event type must be one of %s%s%s. Branches can be patterns such as %srelease/*%s. Subscribing to %sworkflows%s sends one message per GitHub Actions workflow run instead of one per check.
 
 Examples:%s
 !github subscribe keybase/client
+!github subscribe keybase
+!github subscribe keybase/service-* pulls
 !github subscribe microsoft/typescript pulls
+!github subscribe microsoft/typescript workflows
 !github subscribe facebook/react gh-pages%s`,
-		backs, backs, backs, backs)
+		"`", "`", "`", "`", "`", "`", backs, features, backs, "`", "`", "`", "`", backs, backs)
 
 	unsubExtended := fmt.Sprintf(`Disables updates from the provided GitHub repository to this conversation.
 
 Running this command without a branch or event type will unsubscribe you from all events on the specified repository.
 
-Event type must be one of %sissues, pulls, commits, statuses%s
+Event type must be one of %s%s%s
 
 Examples:%s
 !github unsubscribe keybase/client
 !github unsubscribe microsoft/typescript commits
 !github unsubscribe facebook/react gh-pages%s`,
-		backs, backs, backs, backs)
+		backs, features, backs, backs, backs)
 
 	mentionsExtended := fmt.Sprintf(`Enables or disables mentions in GitHub events that involve your proven GitHub username.
 
@@ -90,12 +98,63 @@ Examples:%s
 !github mentions enable%s
 	`, backs, backs)
 
+	filterExtended := fmt.Sprintf(`Narrows down the updates from a GitHub repository this conversation is subscribed to, or lists its filters when given only the repository.
+
+Filters are %slabel:<name>%s, %sauthor:<username>%s, %spath:<glob>%s for the files changed by pushes and pull requests, or %sdraft:<true/false>%s. Prefix a filter with %s-%s to exclude matching events. Events must match one of the filters of each kind, and filters only apply to the events they make sense for.
+
+Examples:%s
+!github filter keybase/client add label:backend
+!github filter keybase/client add path:go/chat/**
+!github filter keybase/client add -author:dependabot[bot]
+!github filter keybase/client remove draft:false
+!github filter keybase/client%s`,
+		"`", "`", "`", "`", "`", "`", "`", "`", "`", "`", backs, backs)
+
+	digestExtended := fmt.Sprintf(`Sets whether updates from a GitHub repository this conversation is subscribed to are sent as they happen, or summarized in an hourly or daily digest. Daily digests are sent at 09:00 UTC unless a time and timezone are given.
+
+Examples:%s
+!github digest keybase/client daily
+!github digest keybase/client daily 10:00 America/New_York
+!github digest keybase/client hourly
+!github digest keybase/client immediate
+!github digest keybase/client%s`, backs, backs)
+
+	actionsExtended := fmt.Sprintf(`Acts on a GitHub issue or pull request with your own GitHub account, which you'll be asked to authorize first. You must be at least a writer in the conversation and have access to the repository's Keybase integration.
+
+Examples:%s
+!github issue create keybase/client "Crash on startup" "Steps to reproduce..."
+!github comment keybase/client#123 "Fixed in the next release"
+!github close keybase/client#123
+!github reopen keybase/client#123
+!github label keybase/client#123 bug
+!github merge keybase/client#456%s`, backs, backs)
+	actionCmd := func(name, description, title string) chat1.UserBotCommandInput {
+		return chat1.UserBotCommandInput{
+			Name:        name,
+			Description: description,
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       title,
+				DesktopBody: actionsExtended,
+				MobileBody:  actionsExtended,
+			},
+		}
+	}
+
+	remindersExtended := fmt.Sprintf(`Reminds this conversation of the pull requests on a GitHub repository it is subscribed to which have been waiting for review, without any activity, for longer than the given duration. Requested reviewers are mentioned. Reminders are sent on weekdays at 10:00 UTC unless told otherwise.
+
+Examples:%s
+!github reminders keybase/client 24h
+!github reminders keybase/client 24h weekdays 10:00 America/New_York
+!github reminders keybase/client 2d daily 09:30 Europe/Paris
+!github reminders keybase/client off
+!github reminders keybase/client%s`, backs, backs)
+
 	cmds := []chat1.UserBotCommandInput{
 		{
 			Name:        "github subscribe",
 			Description: "Enable updates from GitHub repos",
 			ExtendedDescription: &chat1.UserBotExtendedDescription{
-				Title:       `*!github subscribe* <owner/repo> [branch or event type]`,
+				Title:       `*!github subscribe* <owner/repo, owner or pattern> [branch or event type]`,
 				DesktopBody: subExtended,
 				MobileBody:  subExtended,
 			},
@@ -118,12 +177,52 @@ Examples:%s
 				MobileBody:  mentionsExtended,
 			},
 		},
+		{
+			Name:        "github filter",
+			Description: "Filter updates from a GitHub repo by label, author, path or draft status",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!github filter* <owner/repo> [add/remove <filter>]`,
+				DesktopBody: filterExtended,
+				MobileBody:  filterExtended,
+			},
+		},
+		{
+			Name:        "github digest",
+			Description: "Batch updates from a GitHub repo into hourly or daily digests",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!github digest* <owner/repo> [immediate/hourly/daily] [HH:MM] [timezone]`,
+				DesktopBody: digestExtended,
+				MobileBody:  digestExtended,
+			},
+		},
+		{
+			Name:        "github reminders",
+			Description: "Remind the conversation of pull requests waiting for review",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!github reminders* <owner/repo> [<duration> [weekdays/daily] [HH:MM] [timezone] | off]`,
+				DesktopBody: remindersExtended,
+				MobileBody:  remindersExtended,
+			},
+		},
 		{
 			Name:        "github list",
 			Description: "List subscriptions for the current conversation.",
 		},
+		actionCmd("github issue create", "Open an issue on a GitHub repo",
+			`*!github issue create* <owner/repo> "title" ["body"]`),
+		actionCmd("github comment", "Comment on a GitHub issue or pull request",
+			`*!github comment* <owner/repo>#<number> "text"`),
+		actionCmd("github close", "Close a GitHub issue or pull request",
+			`*!github close* <owner/repo>#<number>`),
+		actionCmd("github reopen", "Reopen a GitHub issue or pull request",
+			`*!github reopen* <owner/repo>#<number>`),
+		actionCmd("github label", "Label a GitHub issue or pull request",
+			`*!github label* <owner/repo>#<number> <label> [label...]`),
+		actionCmd("github merge", "Merge a GitHub pull request",
+			`*!github merge* <owner/repo>#<number>`),
 		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
 	}
+	cmds = append(cmds, base.OnboardingAdvertisements("github", true)...)
 	return kbchat.Advertisement{
 		Alias: "GitHub",
 		Advertisements: []chat1.AdvertiseCommandAPIParam{
@@ -230,12 +329,6 @@ func (s *BotServer) Go() (err error) {
 	if err != nil {
 		s.Errorf("failed to get private key: %s", err)
 	}
-	tr := http.DefaultTransport
-	atr, err := ghinstallation.NewAppsTransport(tr, botConfig.AppID, appKey)
-	if err != nil {
-		s.Errorf("failed to make github apps transport: %s", err)
-		return err
-	}
 	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
 	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
 	if err != nil {
@@ -243,12 +336,30 @@ func (s *BotServer) Go() (err error) {
 		return err
 	}
 	stats = stats.SetPrefix(s.Name())
-	handler := githubbot.NewHandler(stats, s.kbc, debugConfig, db, config, atr, s.opts.HTTPPrefix, botConfig.AppName)
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	tr := httpClient.Transport(http.DefaultTransport)
+	atr, err := ghinstallation.NewAppsTransport(tr, botConfig.AppID, appKey)
+	if err != nil {
+		s.Errorf("failed to make github apps transport: %s", err)
+		return err
+	}
+	scheduler := base.NewScheduler(stats, s.kbc, debugConfig, db.DB, s.IsLeader, base.DefaultSchedulerOptions())
+	cleaner.SetScheduler(scheduler)
+	handler := githubbot.NewHandler(stats, s.kbc, debugConfig, db, config, atr, httpClient, scheduler,
+		s.opts.HTTPPrefix, botConfig.AppName)
+	scheduler.Register(githubbot.DigestJobName, handler.RunDigestJob)
+	scheduler.Register(githubbot.ReminderJobName, handler.RunReminderJob)
 	httpSrv := githubbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, handler, config, atr, botConfig.WebhookSecret)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
-	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats) })
+	s.GoWithRecover(eg, scheduler.Run)
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, scheduler, stats, cleaner) })
 	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
 	if err := eg.Wait(); err != nil {
 		s.Debug("wait error: %s", err)
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
@@ -84,13 +85,13 @@ func mainInner() int {
 		itr := ghinstallation.NewFromAppsTransport(atr, subscription.InstallationID)
 		client := github.NewClient(&http.Client{Transport: itr})
 
-		defaultBranch, err := githubbot.GetDefaultBranch(subscription.Repo, client)
+		defaultBranch, err := githubbot.GetDefaultBranch(context.Background(), subscription.Repo, client)
 		if err != nil {
 			fmt.Printf("Error getting default branch for subscription %d/%d: %s\n", i, len(subs), err)
 			continue
 		}
 
-		err = db.WatchBranch(subscription.ConvID, subscription.Repo, defaultBranch)
+		err = db.WatchBranch(context.Background(), subscription.ConvID, subscription.Repo, defaultBranch)
 		if err != nil {
 			fmt.Printf("Error watching branch: %s", err)
 			return 1
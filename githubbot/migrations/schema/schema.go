@@ -0,0 +1,127 @@
+// Migration script to add the feature columns and tables used by newer
+// versions of the bot to an existing database
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// newFeatures are the features columns added after the table was created, all
+// off for existing subscriptions.
+var newFeatures = []string{"comments", "tags", "reviews", "deployments", "workflows"}
+
+var newTables = []string{
+	`CREATE TABLE IF NOT EXISTS filter_rules (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		rule varchar(255) NOT NULL,
+		UNIQUE KEY unique_subscription (conv_id, repo, rule)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+	`CREATE TABLE IF NOT EXISTS check_boards (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		sha char(40) NOT NULL,
+		msg_id int(11) NOT NULL,
+		checks text NOT NULL,
+		expire_time datetime NOT NULL,
+		PRIMARY KEY (conv_id, repo, sha),
+		KEY expire_time (expire_time)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS digests (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		frequency varchar(16) NOT NULL,
+		at_time char(5) NOT NULL,
+		timezone varchar(64) NOT NULL,
+		PRIMARY KEY (conv_id, repo)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+	`CREATE TABLE IF NOT EXISTS digest_events (
+		id bigint(20) NOT NULL AUTO_INCREMENT,
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		event mediumtext NOT NULL,
+		ctime datetime NOT NULL,
+		PRIMARY KEY (id),
+		KEY subscription (conv_id, repo)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS reminders (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		threshold_secs int(11) NOT NULL,
+		weekdays boolean NOT NULL DEFAULT 1,
+		at_time char(5) NOT NULL,
+		timezone varchar(64) NOT NULL,
+		PRIMARY KEY (conv_id, repo)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+}
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func columnExists(sdb *sql.DB, table, column string) (bool, error) {
+	var count int
+	row := sdb.QueryRow(`
+		SELECT COUNT(*)
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+	`, table, column)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func mainInner() int {
+	var dsn string
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&dsn, "dsn", os.Getenv("BOT_DSN"), "Bot database DSN")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Printf("failed to parse options: %s", err)
+		return 1
+	}
+
+	if len(dsn) == 0 {
+		fmt.Printf("must specify a database DSN\n")
+		return 3
+	}
+
+	sdb, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fmt.Printf("failed to connect to MySQL: %s", err)
+		return 1
+	}
+	defer sdb.Close()
+
+	for _, feature := range newFeatures {
+		exists, err := columnExists(sdb, "features", feature)
+		if err != nil {
+			fmt.Printf("failed to look up features.%s: %s", feature, err)
+			return 1
+		}
+		if exists {
+			continue
+		}
+		if _, err := sdb.Exec(fmt.Sprintf(`ALTER TABLE features ADD COLUMN %s boolean NOT NULL DEFAULT 0`,
+			feature)); err != nil {
+			fmt.Printf("failed to add features.%s: %s", feature, err)
+			return 1
+		}
+		fmt.Printf("Added features.%s\n", feature)
+	}
+
+	for _, table := range newTables {
+		if _, err := sdb.Exec(table); err != nil {
+			fmt.Printf("failed to create table: %s", err)
+			return 1
+		}
+	}
+	fmt.Printf("Created %d tables if they were missing\n", len(newTables))
+	return 0
+}
@@ -4,3 +4,33 @@ CREATE TABLE `subscriptions` (
   `oauth_identifier` varchar(128) NOT NULL,
   UNIQUE KEY unique_subscription (`conv_id`, `repo`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `branches` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `branch` varchar(128) NOT NULL,
+  UNIQUE KEY unique_subscription (`conv_id`, `repo`, `branch`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `features` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `issues` boolean NOT NULL DEFAULT 1,
+  `pull_requests` boolean NOT NULL DEFAULT 1,
+  `commits` boolean NOT NULL DEFAULT 0,
+  `statuses` boolean NOT NULL DEFAULT 1,
+  `releases` boolean NOT NULL DEFAULT 1,
+  `comments` boolean NOT NULL DEFAULT 0,
+  `tags` boolean NOT NULL DEFAULT 0,
+  `reviews` boolean NOT NULL DEFAULT 0,
+  `deployments` boolean NOT NULL DEFAULT 0,
+  `workflows` boolean NOT NULL DEFAULT 0,
+  UNIQUE KEY unique_subscription (`conv_id`, `repo`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `filter_rules` (
+  `conv_id` char(64) NOT NULL,
+  `repo` varchar(128) NOT NULL,
+  `rule` varchar(255) NOT NULL,
+  UNIQUE KEY unique_subscription (`conv_id`, `repo`, `rule`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,14 @@
+package gitlabbot
+
+import (
+	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
+)
+
+func init() {
+	base.RegisterAdminTables("gitlabbot", append(base.OAuthAdminTables(),
+		base.AdminTable{Name: "subscriptions", ConvColumn: "conv_id", KeyColumns: []string{"repo", "oauth_identifier"}},
+	)...)
+	base.RegisterAdminTables("gitlabbot", git.FilterAdminTables()...)
+	base.RegisterAdminTables("gitlabbot", base.OnboardingAdminTable("gitlabbot"))
+}
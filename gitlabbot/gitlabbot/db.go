@@ -1,28 +1,33 @@
 package gitlabbot
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 	"golang.org/x/oauth2"
 )
 
 type DB struct {
 	*base.DB
+	*git.Store
 }
 
 func NewDB(db *sql.DB) *DB {
+	baseDB := base.NewDB(db)
 	return &DB{
-		DB: base.NewDB(db),
+		DB:    baseDB,
+		Store: git.NewStore(baseDB),
 	}
 }
 
 // webhook subscription methods
 
-func (d *DB) CreateSubscription(convID chat1.ConvIDStr, repo string, oauthIdentifier string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CreateSubscription(ctx context.Context, convID chat1.ConvIDStr, repo string, oauthIdentifier string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO subscriptions
 			(conv_id, repo, oauth_identifier)
@@ -34,8 +39,8 @@ func (d *DB) CreateSubscription(convID chat1.ConvIDStr, repo string, oauthIdenti
 	})
 }
 
-func (d *DB) DeleteSubscription(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteSubscription(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM subscriptions
 			WHERE (conv_id = ? AND repo = ?)
@@ -44,8 +49,8 @@ func (d *DB) DeleteSubscription(convID chat1.ConvIDStr, repo string) error {
 	})
 }
 
-func (d *DB) DeleteSubscriptionsForRepo(convID chat1.ConvIDStr, repo string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteSubscriptionsForRepo(ctx context.Context, convID chat1.ConvIDStr, repo string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM subscriptions
 			WHERE (conv_id = ? AND repo = ?)
@@ -151,8 +156,8 @@ func (d *DB) GetToken(identifier string) (*oauth2.Token, error) {
 	}
 }
 
-func (d *DB) PutToken(identifier string, token *oauth2.Token) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) PutToken(ctx context.Context, identifier string, token *oauth2.Token) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`INSERT INTO oauth
 		(identifier, access_token, token_type, ctime, mtime)
 		VALUES (?, ?, ?, NOW(), NOW())
@@ -165,8 +170,8 @@ func (d *DB) PutToken(identifier string, token *oauth2.Token) error {
 	return err
 }
 
-func (d *DB) DeleteToken(identifier string) error {
-	err := d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteToken(ctx context.Context, identifier string) error {
+	err := d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec("DELETE FROM oauth WHERE identifier = ?", identifier)
 		return err
 	})
@@ -1,6 +1,7 @@
 package gitlabbot
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -15,6 +16,7 @@ type Handler struct {
 	stats      *base.StatsRegistry
 	kbc        *kbchat.API
 	db         *DB
+	onboarding *base.Onboarding
 	httpPrefix string
 	secret     string
 }
@@ -30,22 +32,25 @@ func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.Ch
 		db:          db,
 		httpPrefix:  httpPrefix,
 		secret:      secret,
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, "gitlabbot", "gitlab", "Hi! I can notify you whenever something happens on a GitLab repository. To get started, set up a repository by sending `!gitlab subscribe <owner/repo>`"),
 	}
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Hi! I can notify you whenever something happens on a GitLab repository. To get started, set up a repository by sending `!gitlab subscribe <owner/repo>`"
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleAuth(msg chat1.MsgSummary, _ string) error {
-	return h.HandleCommand(msg)
+func (h *Handler) HandleAuth(ctx context.Context, msg chat1.MsgSummary, _ string) error {
+	return h.HandleCommand(ctx, msg)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.ToLower(strings.TrimSpace(msg.Content.Text.Body))
 	if !strings.HasPrefix(cmd, "!gitlab") {
@@ -55,10 +60,10 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 	switch {
 	case strings.HasPrefix(cmd, "!gitlab subscribe"):
 		h.stats.Count("subscribe")
-		return h.handleSubscribe(cmd, msg, true)
+		return h.handleSubscribe(ctx, cmd, msg, true)
 	case strings.HasPrefix(cmd, "!gitlab unsubscribe"):
 		h.stats.Count("unsubscribe")
-		return h.handleSubscribe(cmd, msg, false)
+		return h.handleSubscribe(ctx, cmd, msg, false)
 	case strings.HasPrefix(cmd, "!gitlab list"):
 		h.stats.Count("list")
 		return h.handleListSubscriptions(msg)
@@ -66,7 +71,7 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 	return nil
 }
 
-func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool) (err error) {
+func (h *Handler) handleSubscribe(ctx context.Context, cmd string, msg chat1.MsgSummary, create bool) (err error) {
 	toks, userErr, err := base.SplitTokens(cmd)
 	if err != nil {
 		return err
@@ -92,9 +97,27 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool)
 		return fmt.Errorf("error checking subscription: %s", err)
 	}
 
+	if len(args) == 2 {
+		// toggling a feature or branch
+		if !alreadyExists {
+			if create {
+				h.ChatEcho(msg.ConvID, "You aren't subscribed to updates yet!\nSend this first: `!gitlab subscribe %s`", args[0])
+			} else {
+				h.ChatEcho(msg.ConvID, "You aren't subscribed to notifications for `%s`!", repo)
+			}
+			return nil
+		}
+		reply, err := h.db.ToggleFilter(ctx, msg.ConvID, repo, args[1], create)
+		if err != nil {
+			return err
+		}
+		h.ChatEcho(msg.ConvID, "%s", reply)
+		return nil
+	}
+
 	if create {
 		if !alreadyExists {
-			err = h.db.CreateSubscription(msg.ConvID, repo, base.IdentifierFromMsg(msg))
+			err = h.db.CreateSubscription(ctx, msg.ConvID, repo, base.IdentifierFromMsg(msg))
 			if err != nil {
 				return fmt.Errorf("error creating subscription: %s", err)
 			}
@@ -113,10 +136,13 @@ func (h *Handler) handleSubscribe(cmd string, msg chat1.MsgSummary, create bool)
 	}
 
 	if alreadyExists {
-		err = h.db.DeleteSubscriptionsForRepo(msg.ConvID, repo)
+		err = h.db.DeleteSubscriptionsForRepo(ctx, msg.ConvID, repo)
 		if err != nil {
 			return fmt.Errorf("error deleting subscriptions: %s", err)
 		}
+		if err = h.db.DeleteFilter(ctx, msg.ConvID, repo); err != nil {
+			return err
+		}
 		h.ChatEcho(msg.ConvID, "Okay, you won't receive updates for `%s` here.", repo)
 		return nil
 	}
@@ -136,9 +162,9 @@ func (h *Handler) handleListSubscriptions(msg chat1.MsgSummary) (err error) {
 		return nil
 	}
 
-	var res string
-	for _, repo := range subscriptions {
-		res += fmt.Sprintf("- *%s*\n", repo)
+	res, err := h.db.FormatSubscriptions(msg.ConvID, subscriptions)
+	if err != nil {
+		return fmt.Errorf("error getting current features: %s", err)
 	}
 	h.ChatEcho(msg.ConvID, "%s", res)
 	return nil
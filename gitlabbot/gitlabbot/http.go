@@ -10,25 +10,28 @@ import (
 	"github.com/xanzy/go-gitlab"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 	"github.com/keybase/managed-bots/base"
 )
 
 type HTTPSrv struct {
 	*base.HTTPSrv
 
-	kbc     *kbchat.API
-	db      *DB
-	handler *Handler
-	secret  string
+	kbc      *kbchat.API
+	db       *DB
+	handler  *Handler
+	notifier *git.Notifier
+	secret   string
 }
 
 func NewHTTPSrv(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
 	db *DB, handler *Handler, secret string) *HTTPSrv {
 	h := &HTTPSrv{
-		kbc:     kbc,
-		db:      db,
-		handler: handler,
-		secret:  secret,
+		kbc:      kbc,
+		db:       db,
+		handler:  handler,
+		notifier: git.NewNotifier(stats, debugConfig, db.Store),
+		secret:   secret,
 	}
 	h.HTTPSrv = base.NewHTTPSrv(stats, debugConfig)
 	http.HandleFunc("/gitlabbot", h.handleHealthCheck)
@@ -54,69 +57,134 @@ func (h *HTTPSrv) handleWebhook(_ http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var message, repo string
-	switch event := event.(type) {
-	case *gitlab.IssueEvent:
-		message = git.FormatIssueMsg(
-			event.ObjectAttributes.Action,
-			event.User.Username,
-			event.Project.Name,
-			event.ObjectAttributes.IID,
-			event.ObjectAttributes.Title,
-			event.ObjectAttributes.URL,
-		)
-		repo = event.Project.PathWithNamespace
-	case *gitlab.MergeEvent:
-		message = git.FormatPullRequestMsg(
-			git.GITLAB,
-			event.ObjectAttributes.Action,
-			event.User.Username,
-			event.Project.PathWithNamespace,
-			event.ObjectAttributes.IID,
-			event.ObjectAttributes.Title,
-			event.ObjectAttributes.URL,
-			event.ObjectAttributes.TargetBranch,
-		)
-		repo = event.Project.PathWithNamespace
-	case *gitlab.PushEvent:
-		if len(event.Commits) == 0 {
-			break
-		}
-		branch := git.RefToName(event.Ref)
-		commitMsgs := getCommitMessages(event)
-		lastCommitDiffURL := event.Commits[len(event.Commits)-1].URL
-
-		message = git.FormatPushMsg(
-			event.UserUsername,
-			event.Project.Name,
-			branch,
-			len(event.Commits),
-			commitMsgs,
-			lastCommitDiffURL)
-		repo = event.Project.PathWithNamespace
-	case *gitlab.PipelineEvent:
-		repo = event.Project.PathWithNamespace
-		message = formatPipelineMsg(event, event.User.Username)
-	}
-
-	if message == "" || repo == "" {
+	gitEvent := toEvent(event)
+	if gitEvent == nil {
 		return
 	}
-	repo = strings.ToLower(repo)
 	signature := r.Header.Get("X-Gitlab-Token")
 
-	convs, err := h.db.GetSubscribedConvs(repo)
+	convs, err := h.db.GetSubscribedConvs(gitEvent.Repo)
 	if err != nil {
 		h.Errorf("Error getting subscriptions for repo: %s", err)
 		return
 	}
 
+	var validConvs []chat1.ConvIDStr
 	for _, convID := range convs {
-		var secretToken = base.MakeSecret(repo, convID, h.secret)
+		var secretToken = base.MakeSecret(gitEvent.Repo, convID, h.secret)
 		if signature != secretToken {
-			h.Debug("Error validating payload signature for conversation %s: %v", convID, err)
+			h.Debug("Error validating payload signature for conversation %s", convID)
 			continue
 		}
-		h.ChatEcho(convID, "%s", message)
+		validConvs = append(validConvs, convID)
+	}
+	h.notifier.Notify(gitEvent, validConvs, nil)
+}
+
+// toEvent translates the webhook event, it returns nil for events that
+// aren't supported.
+func toEvent(event interface{}) (res *git.Event) {
+	res = &git.Event{Provider: git.GITLAB}
+	switch event := event.(type) {
+	case *gitlab.IssueEvent:
+		res.Kind = git.EventIssue
+		res.Action = event.ObjectAttributes.Action
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Author = event.User.Username
+		res.Number = event.ObjectAttributes.IID
+		res.Title = event.ObjectAttributes.Title
+		res.URL = event.ObjectAttributes.URL
+	case *gitlab.MergeEvent:
+		res.Kind = git.EventPullRequest
+		res.Action = event.ObjectAttributes.Action
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.PathWithNamespace
+		res.Author = event.User.Username
+		res.Number = event.ObjectAttributes.IID
+		res.Title = event.ObjectAttributes.Title
+		res.TargetBranch = event.ObjectAttributes.TargetBranch
+		res.URL = event.ObjectAttributes.URL
+	case *gitlab.PushEvent:
+		if len(event.Commits) == 0 {
+			return nil
+		}
+		res.Kind = git.EventPush
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Branch = git.RefToName(event.Ref)
+		res.Author = event.UserUsername
+		res.Commits = getCommitMessages(event)
+		res.URL = event.Commits[len(event.Commits)-1].URL
+	case *gitlab.TagEvent:
+		res.Kind = git.EventTag
+		res.Action = "created"
+		if strings.Trim(event.After, "0") == "" {
+			res.Action = "deleted"
+		}
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Author = event.UserName
+		res.Tag = strings.TrimPrefix(event.Ref, "refs/tags/")
+		res.URL = fmt.Sprintf("%s/-/tags/%s", event.Project.WebURL, res.Tag)
+	case *gitlab.PipelineEvent:
+		res.Kind = git.EventCheck
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.PathWithNamespace
+		res.CheckState = pipelineState(event.ObjectAttributes.Status)
+		if event.MergeRequest.IID != 0 {
+			res.IsPullRequest = true
+			res.Number = event.MergeRequest.IID
+			res.URL = event.MergeRequest.URL
+		} else {
+			res.Branch = event.ObjectAttributes.Ref
+			res.URL = fmt.Sprintf("%s/pipelines/%d", event.Project.WebURL, event.ObjectAttributes.ID)
+		}
+	case *gitlab.IssueCommentEvent:
+		if event.ObjectAttributes.System {
+			return nil
+		}
+		res.Kind = git.EventComment
+		res.Action = "created"
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Author = event.User.Username
+		res.Number = event.Issue.IID
+		res.Title = event.Issue.Title
+		res.Body = event.ObjectAttributes.Note
+		res.URL = event.ObjectAttributes.URL
+	case *gitlab.MergeCommentEvent:
+		if event.ObjectAttributes.System {
+			return nil
+		}
+		res.Kind = git.EventComment
+		res.Action = "created"
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Author = event.User.Username
+		res.IsPullRequest = true
+		res.Number = event.MergeRequest.IID
+		res.Title = event.MergeRequest.Title
+		res.Body = event.ObjectAttributes.Note
+		res.URL = event.ObjectAttributes.URL
+	case *gitlab.CommitCommentEvent:
+		if event.ObjectAttributes.System || event.Commit == nil {
+			return nil
+		}
+		res.Kind = git.EventComment
+		res.Action = "created"
+		res.Repo = event.Project.PathWithNamespace
+		res.RepoName = event.Project.Name
+		res.Author = event.User.Username
+		res.Commit = event.Commit.ID
+		res.Body = event.ObjectAttributes.Note
+		res.URL = event.Commit.URL
+	default:
+		return nil
+	}
+	if res.Repo == "" {
+		return nil
 	}
+	res.Repo = strings.ToLower(res.Repo)
+	return res
 }
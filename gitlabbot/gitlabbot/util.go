@@ -22,40 +22,16 @@ func getCommitMessages(event *gitlab.PushEvent) []string {
 	return commitMsgs
 }
 
-// formatters
-
-func formatPipelineMsg(evt *gitlab.PipelineEvent, username string) (res string) {
-	action := evt.ObjectAttributes.Status
-	suite := evt.ObjectAttributes
-	repo := evt.Project.PathWithNamespace
-	isMergeRequest := evt.MergeRequest.IID != 0
-	pipelineURL := fmt.Sprintf("%s/pipelines/%d", evt.Project.WebURL, evt.ObjectAttributes.ID)
-
-	defer func() {
-		if strings.HasPrefix(username, "@") && isMergeRequest && res != "" {
-			res = res + "\n" + username
-		}
-	}()
-	switch action {
+// pipelineState translates pipeline statuses, it returns an empty string
+// for the ones not worth a message.
+func pipelineState(status string) string {
+	switch status {
 	case "success":
-		if !isMergeRequest {
-			return fmt.Sprintf(":white_check_mark: Tests passed for %s/%s.\n%s", repo, suite.Ref, pipelineURL)
-		}
-		mr := evt.MergeRequest
-		return fmt.Sprintf(":white_check_mark: All tests passed for merge request #%d on %s.\n%s", mr.IID, repo, mr.URL)
+		return "success"
 	case "failed":
-		if !isMergeRequest {
-			return fmt.Sprintf(":x: Tests failed for %s/%s.\n%s", repo, suite.Ref, pipelineURL)
-		}
-
-		mr := evt.MergeRequest
-		return fmt.Sprintf(":x: Tests failed for merge request #%d on %s.\n%s", mr.IID, repo, mr.URL)
+		return "failure"
 	case "canceled":
-		if !isMergeRequest {
-			return fmt.Sprintf(":warning: Tests cancelled for %s/%s.\n%s", repo, suite.Ref, pipelineURL)
-		}
-		mr := evt.MergeRequest
-		return fmt.Sprintf(":warning: Tests cancelled for pull request #%d on %s.\n%s", mr.IID, repo, mr.URL)
+		return "cancelled"
 	default:
 		return ""
 	}
@@ -68,7 +44,7 @@ To configure your project to send notifications, go to %s/%s/hooks and add a new
 For “URL”, enter %s%s/gitlabbot/webhook%s.
 For “Secret Token”, enter %s%s%s.
 Remember to check all the triggers you would like me to update you on.
-Note that I currently support the following Webhook Events: Push, Issues, Merge Request, Pipeline
+Note that I currently support the following Webhook Events: Push, Tag Push, Issues, Comments, Merge Request, Pipeline
 
 Happy coding!`,
 		hostedURL, repo, back, httpAddress, back, back, base.MakeSecret(repo, msg.ConvID, secret), back)
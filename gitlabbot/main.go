@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/keybase/managed-bots/gitlabbot/gitlabbot"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
 	"github.com/keybase/managed-bots/base"
+	"github.com/keybase/managed-bots/base/git"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -41,7 +43,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("gitlabbot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("gitlabbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -52,27 +54,39 @@ func NewBotServer(opts Options) *BotServer {
 const backs = "```"
 
 func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	features := strings.Join(git.FeatureNames(), ", ")
 	subExtended := fmt.Sprintf(`Enables posting updates from the provided GitLab project to this conversation.
 
+Running this command with a branch limits pushes and pipelines to the branches you subscribe to, branches can be patterns such as %srelease/*%s. Running it with an event type limits the events to the types you subscribe to.
+
+Event type must be one of %s%s%s
+
 Example:%s
-!gitlab subscribe keybase/client%s
+!gitlab subscribe keybase/client
+!gitlab subscribe keybase/client pulls
+!gitlab subscribe keybase/client main%s
 
 Subscribe to a self-hosted or enterprise project:%s
 !gitlab subscribe https://mywebsite.com/owner/repo%s`,
-		backs, backs, backs, backs)
+		"`", "`", backs, features, backs, backs, backs, backs, backs)
 
 	unsubExtended := fmt.Sprintf(`Disables updates from the provided GitLab project to this conversation.
 
+Running this command without a branch or event type will unsubscribe you from all events on the specified project.
+
+Event type must be one of %s%s%s
+
 Example:%s
-!gitlab unsubscribe keybase/client%s`,
-		backs, backs)
+!gitlab unsubscribe keybase/client
+!gitlab unsubscribe keybase/client commits%s`,
+		backs, features, backs, backs, backs)
 
 	cmds := []chat1.UserBotCommandInput{
 		{
 			Name:        "gitlab subscribe",
 			Description: "Enable updates from GitLab projects",
 			ExtendedDescription: &chat1.UserBotExtendedDescription{
-				Title:       `*!gitlab subscribe* <username/project>`,
+				Title:       `*!gitlab subscribe* <username/project> [branch or event type]`,
 				DesktopBody: subExtended,
 				MobileBody:  subExtended,
 			},
@@ -81,7 +95,7 @@ Example:%s
 			Name:        "gitlab unsubscribe",
 			Description: "Disable updates from GitLab projects",
 			ExtendedDescription: &chat1.UserBotExtendedDescription{
-				Title:       `*!gitlab unsubscribe* <username/project>`,
+				Title:       `*!gitlab unsubscribe* <username/project> [branch or event type]`,
 				DesktopBody: unsubExtended,
 				MobileBody:  unsubExtended,
 			},
@@ -92,6 +106,7 @@ Example:%s
 		},
 		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
 	}
+	cmds = append(cmds, base.OnboardingAdvertisements("gitlab", false)...)
 	return kbchat.Advertisement{
 		Alias: "GitLab",
 		Advertisements: []chat1.AdvertiseCommandAPIParam{
@@ -152,12 +167,17 @@ func (s *BotServer) Go() (err error) {
 		return err
 	}
 	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
 	handler := gitlabbot.NewHandler(stats, s.kbc, debugConfig, db, s.opts.HTTPPrefix, secret)
 	httpSrv := gitlabbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, handler, secret)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
-	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats) })
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats, cleaner) })
 	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
 	if err := eg.Wait(); err != nil {
 		s.Debug("wait error: %s", err)
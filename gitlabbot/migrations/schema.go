@@ -0,0 +1,80 @@
+// Migration script to add the tables used by newer versions of the bot to an
+// existing database
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// newTables are created empty, which leaves existing subscriptions getting
+// every kind of event on every branch, as before.
+var newTables = []string{
+	`CREATE TABLE IF NOT EXISTS branches (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		branch varchar(128) NOT NULL,
+		UNIQUE KEY unique_subscription (conv_id, repo, branch)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+	`CREATE TABLE IF NOT EXISTS features (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		issues boolean NOT NULL DEFAULT 1,
+		pull_requests boolean NOT NULL DEFAULT 1,
+		commits boolean NOT NULL DEFAULT 0,
+		statuses boolean NOT NULL DEFAULT 1,
+		releases boolean NOT NULL DEFAULT 1,
+		comments boolean NOT NULL DEFAULT 0,
+		tags boolean NOT NULL DEFAULT 0,
+		reviews boolean NOT NULL DEFAULT 0,
+		deployments boolean NOT NULL DEFAULT 0,
+		workflows boolean NOT NULL DEFAULT 0,
+		UNIQUE KEY unique_subscription (conv_id, repo)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+	`CREATE TABLE IF NOT EXISTS filter_rules (
+		conv_id char(64) NOT NULL,
+		repo varchar(128) NOT NULL,
+		rule varchar(255) NOT NULL,
+		UNIQUE KEY unique_subscription (conv_id, repo, rule)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8`,
+}
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	var dsn string
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&dsn, "dsn", os.Getenv("BOT_DSN"), "Bot database DSN")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Printf("failed to parse options: %s", err)
+		return 1
+	}
+
+	if len(dsn) == 0 {
+		fmt.Printf("must specify a database DSN\n")
+		return 3
+	}
+
+	sdb, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fmt.Printf("failed to connect to MySQL: %s", err)
+		return 1
+	}
+	defer sdb.Close()
+
+	for _, table := range newTables {
+		if _, err := sdb.Exec(table); err != nil {
+			fmt.Printf("failed to create table: %s", err)
+			return 1
+		}
+	}
+	fmt.Printf("Created %d tables if they were missing\n", len(newTables))
+	return 0
+}
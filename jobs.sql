@@ -0,0 +1,14 @@
+CREATE TABLE `jobs` (
+  `id` varchar(191) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `payload` blob NOT NULL,
+  `cron` varchar(128) NOT NULL DEFAULT '',
+  `run_at` datetime NOT NULL,
+  `attempts` int(11) NOT NULL DEFAULT 0,
+  `last_error` text,
+  `locked_by` varchar(32) NOT NULL DEFAULT '',
+  `locked_until` datetime DEFAULT NULL,
+  `ctime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `run_at` (`run_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
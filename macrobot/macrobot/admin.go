@@ -0,0 +1,17 @@
+package macrobot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("macrobot",
+		// channel_name only holds a conversation ID for conversation macros,
+		// team macros are keyed by the team name
+		base.AdminTable{
+			Name:       "macro",
+			ConvColumn: "channel_name",
+			ConvWhere:  "is_conv = 1",
+			KeyColumns: []string{"channel_name", "macro_name"},
+		},
+		base.OnboardingAdminTable("macrobot"),
+	)
+}
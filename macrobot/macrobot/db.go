@@ -1,6 +1,7 @@
 package macrobot
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -17,8 +18,8 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
-func (d *DB) Create(name string, convID chat1.ConvIDStr, isConv bool, macroName, macroMessage string) (created bool, err error) {
-	err = d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) Create(ctx context.Context, name string, convID chat1.ConvIDStr, isConv bool, macroName, macroMessage string) (created bool, err error) {
+	err = d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		if isConv {
 			name = string(convID)
 		}
@@ -86,8 +87,8 @@ func (d *DB) List(name string, convID chat1.ConvIDStr) (list []Macro, err error)
 	return list, nil
 }
 
-func (d *DB) Remove(name string, convID chat1.ConvIDStr, macroName string) (removed bool, err error) {
-	err = d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) Remove(ctx context.Context, name string, convID chat1.ConvIDStr, macroName string) (removed bool, err error) {
+	err = d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		// First try to delete for the conv
 		res, err := tx.Exec(`
 			DELETE FROM macro
@@ -1,6 +1,7 @@
 package macrobot
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -16,9 +17,10 @@ type Handler struct {
 	sync.Mutex
 	*base.DebugOutput
 
-	stats *base.StatsRegistry
-	kbc   *kbchat.API
-	db    *DB
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	db         *DB
+	onboarding *base.Onboarding
 	// Keep track of new teams we've seen.
 	newConvCache map[string]struct{}
 }
@@ -32,10 +34,12 @@ var whiteListedCommands = [...]string{
 
 func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB) *Handler {
 	return &Handler{
-		DebugOutput:  base.NewDebugOutput("Handler", debugConfig),
-		stats:        stats.SetPrefix("Handler"),
-		kbc:          kbc,
-		db:           db,
+		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
+		stats:       stats.SetPrefix("Handler"),
+		kbc:         kbc,
+		db:          db,
+		onboarding: base.NewOnboarding(stats, kbc, debugConfig, db.DB, "macrobot", "macro",
+			"I can create and run simple macros! Try `!macro create` to get started."),
 		newConvCache: make(map[string]struct{}),
 	}
 }
@@ -59,14 +63,16 @@ func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
 			delete(h.newConvCache, conv.Channel.Name)
 		}()
 	}
-	welcomeMsg := "I can create and run simple macros! Try `!macro create` to get started."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 
@@ -84,13 +90,13 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 
 	switch {
 	case strings.HasPrefix(cmd, "!macro create "):
-		return h.handleCreate(msg, false, tokens[2:])
+		return h.handleCreate(ctx, msg, false, tokens[2:])
 	case strings.HasPrefix(cmd, "!macro create-for-channel"):
-		return h.handleCreate(msg, true, tokens[2:])
+		return h.handleCreate(ctx, msg, true, tokens[2:])
 	case strings.HasPrefix(cmd, "!macro list"):
 		return h.handleList(msg)
 	case strings.HasPrefix(cmd, "!macro remove"):
-		return h.handleRemove(msg, tokens[2:])
+		return h.handleRemove(ctx, msg, tokens[2:])
 	default:
 		return h.handleRun(msg, tokens)
 	}
@@ -111,7 +117,7 @@ func (h *Handler) handleRun(msg chat1.MsgSummary, args []string) error {
 	return nil
 }
 
-func (h *Handler) handleCreate(msg chat1.MsgSummary, forceConv bool, args []string) error {
+func (h *Handler) handleCreate(ctx context.Context, msg chat1.MsgSummary, forceConv bool, args []string) error {
 	if len(args) != 2 {
 		h.ChatEcho(msg.ConvID, "Invalid number of arguments. Expected two: <name> <message>")
 		return nil
@@ -137,7 +143,7 @@ func (h *Handler) handleCreate(msg chat1.MsgSummary, forceConv bool, args []stri
 	// non-team conversations always get a conv type advertisement. Teams have
 	// the option of registering a per team or per channel macro.
 	isConv := msg.Channel.MembersType != "team" || forceConv
-	created, err := h.db.Create(msg.Channel.Name, msg.ConvID, isConv, macroName, macroMessage)
+	created, err := h.db.Create(ctx, msg.Channel.Name, msg.ConvID, isConv, macroName, macroMessage)
 	if err != nil {
 		return err
 	}
@@ -187,7 +193,7 @@ func (h *Handler) handleList(msg chat1.MsgSummary) error {
 	return nil
 }
 
-func (h *Handler) handleRemove(msg chat1.MsgSummary, args []string) error {
+func (h *Handler) handleRemove(ctx context.Context, msg chat1.MsgSummary, args []string) error {
 	if len(args) != 1 {
 		h.ChatEcho(msg.ConvID, "Invalid number of arguments. Expected one: <name>")
 		return nil
@@ -202,7 +208,7 @@ func (h *Handler) handleRemove(msg chat1.MsgSummary, args []string) error {
 	}
 
 	macroName := args[0]
-	removed, err := h.db.Remove(msg.Channel.Name, msg.ConvID, macroName)
+	removed, err := h.db.Remove(ctx, msg.Channel.Name, msg.ConvID, macroName)
 	if err != nil {
 		return err
 	}
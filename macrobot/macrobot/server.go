@@ -0,0 +1,136 @@
+package macrobot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"golang.org/x/sync/errgroup"
+)
+
+type BotServer struct {
+	*base.Server
+
+	opts base.Options
+	env  *base.BotEnv
+	kbc  *kbchat.API
+}
+
+func NewBotServer(opts base.Options, env *base.BotEnv) *BotServer {
+	return &BotServer{
+		Server: base.NewServer("macrobot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+		}),
+		opts: opts,
+		env:  env,
+	}
+}
+
+func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	createDesc := fmt.Sprintf("Create or update a macro for the current team or conversation. %s",
+		fmt.Sprintf(CreateCmdHelp, backs, backs, back, back, back, back))
+	removeDesc := fmt.Sprintf(`Remove a macro from the current team or conversation. You must specify the name of the macro.
+
+Examples:%s
+!macro remove docs
+!macro remove lunchflip%s`,
+		backs, backs)
+
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "macro create",
+			Description: "Create or update a macro for the current team or conversation",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!macro create* <name> <message>`,
+				DesktopBody: createDesc,
+				MobileBody:  createDesc,
+			},
+		},
+		{
+			Name:        "macro list",
+			Description: "List available macros for the current team or conversation",
+		},
+		{
+			Name:        "macro remove",
+			Description: "Remove a macro from the current team or conversation",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title:       `*!macro remove* <name>`,
+				DesktopBody: removeDesc,
+				MobileBody:  removeDesc,
+			},
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("macro", false)...)
+	return kbchat.Advertisement{
+		Alias: "Macro Bot",
+		Advertisements: []chat1.AdvertiseCommandAPIParam{
+			{
+				Typ:      "public",
+				Commands: cmds,
+			},
+		},
+	}
+}
+
+func (s *BotServer) Go() (err error) {
+	if s.kbc, err = s.Start(s.opts.ErrReportConv); err != nil {
+		return err
+	}
+	sdb, closeDB, err := s.env.OpenDB(s.opts.DSN)
+	if err != nil {
+		s.Errorf("failed to connect to MySQL: %s", err)
+		return err
+	}
+	defer closeDB()
+	db := NewDB(sdb)
+
+	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
+	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
+	if err != nil {
+		s.Debug("unable to create stats: %v", err)
+		return err
+	}
+	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	handler := NewHandler(stats, s.kbc, debugConfig, db)
+	httpSrv := NewHTTPSrv(stats, debugConfig)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
+	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
+	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
+	s.GoWithRecover(eg, httpSrv.Listen)
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats, cleaner) })
+	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
+	if err := eg.Wait(); err != nil {
+		s.Debug("wait error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	base.RegisterBot("macrobot", NewBot)
+}
+
+// NewBot parses the command line in argv and returns the bot, env is nil
+// unless the bot is run by a base.Launcher.
+func NewBot(argv []string, env *base.BotEnv) (base.Bot, error) {
+	opts := base.NewOptions()
+	fs := flag.NewFlagSet(argv[0], flag.ContinueOnError)
+	if err := opts.Parse(fs, argv); err != nil {
+		return nil, fmt.Errorf("Unable to parse options: %v", err)
+	}
+	if len(opts.DSN) == 0 && !env.HasDB() {
+		return nil, errors.New("must specify a database DSN")
+	}
+	return NewBotServer(*opts, env), nil
+}
@@ -40,7 +40,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("meetbot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("meetbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -49,18 +49,20 @@ func NewBotServer(opts Options) *BotServer {
 }
 
 func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "meet",
+			Description: "New Google Meet",
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("meet", false)...)
 	return kbchat.Advertisement{
 		Alias: "Google Meet",
 		Advertisements: []chat1.AdvertiseCommandAPIParam{
 			{
-				Typ: "public",
-				Commands: []chat1.UserBotCommandInput{
-					{
-						Name:        "meet",
-						Description: "New Google Meet",
-					},
-					base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
-				},
+				Typ:      "public",
+				Commands: cmds,
 			},
 		},
 	}
@@ -113,8 +115,10 @@ func (s *BotServer) Go() (err error) {
 		return err
 	}
 	stats = stats.SetPrefix(s.Name())
-	handler := meetbot.NewHandler(stats, s.kbc, debugConfig, db, config)
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	handler := meetbot.NewHandler(stats, s.kbc, debugConfig, db, config, httpClient)
 	httpSrv := meetbot.NewHTTPSrv(stats, s.kbc, debugConfig, db, handler, config)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	eg := &errgroup.Group{}
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
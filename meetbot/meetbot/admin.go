@@ -0,0 +1,8 @@
+package meetbot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("meetbot", base.OAuthAdminTables()...)
+	base.RegisterAdminTables("meetbot", base.OnboardingAdminTable("meetbot"))
+}
@@ -17,56 +17,62 @@ import (
 type Handler struct {
 	*base.DebugOutput
 
-	stats  *base.StatsRegistry
-	kbc    *kbchat.API
-	db     *base.OAuthDB
-	config *oauth2.Config
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	db         *base.OAuthDB
+	onboarding *base.Onboarding
+	config     *oauth2.Config
+	httpClient *base.HTTPClient
 }
 
 var _ base.Handler = (*Handler)(nil)
 
 func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
-	db *base.OAuthDB, config *oauth2.Config) *Handler {
+	db *base.OAuthDB, config *oauth2.Config, httpClient *base.HTTPClient) *Handler {
 	return &Handler{
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
 		kbc:         kbc,
 		db:          db,
 		config:      config,
+		httpClient:  httpClient,
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, "meetbot", "meet", "Hello! I can get you set up with a Google Meet video call anytime, just send me `!meet`."),
 	}
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Hello! I can get you set up with a Google Meet video call anytime, just send me `!meet`."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleAuth(msg chat1.MsgSummary, _ string) error {
-	return h.HandleCommand(msg)
+func (h *Handler) HandleAuth(ctx context.Context, msg chat1.MsgSummary, _ string) error {
+	return h.HandleCommand(ctx, msg)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	if strings.HasPrefix(cmd, "!meet") {
 		h.stats.Count("meet")
-		return h.meetHandler(msg)
+		return h.meetHandler(ctx, msg)
 	}
 	return nil
 }
 
-func (h *Handler) meetHandler(msg chat1.MsgSummary) error {
+func (h *Handler) meetHandler(ctx context.Context, msg chat1.MsgSummary) error {
 	retry := func() error {
 		// retry auth after nuking stored credentials
-		if err := h.db.DeleteToken(base.IdentifierFromMsg(msg)); err != nil {
+		if err := h.db.DeleteToken(ctx, base.IdentifierFromMsg(msg)); err != nil {
 			return err
 		}
-		return h.meetHandlerInner(msg)
+		return h.meetHandlerInner(ctx, msg)
 	}
-	err := h.meetHandlerInner(msg)
+	err := h.meetHandlerInner(ctx, msg)
 	switch err.(type) {
 	case nil, base.OAuthRequiredError:
 		return nil
@@ -82,12 +88,13 @@ func (h *Handler) meetHandler(msg chat1.MsgSummary) error {
 	}
 }
 
-func (h *Handler) meetHandlerInner(msg chat1.MsgSummary) error {
+func (h *Handler) meetHandlerInner(ctx context.Context, msg chat1.MsgSummary) error {
 	identifier := base.IdentifierFromMsg(msg)
-	client, err := base.GetOAuthClient(identifier, msg, h.kbc, h.config, h.db,
+	client, err := base.GetOAuthClient(ctx, identifier, msg, h.kbc, h.config, h.db,
 		base.GetOAuthOpts{
 			AuthMessageTemplate:    "Authorize me by clicking this link:\n%s",
 			OAuthOfflineAccessType: true,
+			HTTPClient:             h.httpClient.Client(),
 		})
 	if err != nil {
 		return err
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/keybase/managed-bots/base"
+	_ "github.com/keybase/managed-bots/macrobot/macrobot"
+	_ "github.com/keybase/managed-bots/pollbot/pollbot"
+	_ "github.com/keybase/managed-bots/triviabot/triviabot"
+	_ "github.com/keybase/managed-bots/webhookbot/webhookbot"
+)
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	var bots, configPath string
+	opts := base.LauncherOptions{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&bots, "bots", os.Getenv("BOT_BOTS"),
+		fmt.Sprintf("Comma separated list of bots to run, from: %s", strings.Join(base.RegisteredBots(), ", ")))
+	fs.StringVar(&opts.HomeRoot, "home-root", os.Getenv("BOT_HOME_ROOT"),
+		"Directory holding a keybase home directory for each bot")
+	fs.StringVar(&opts.DSN, "dsn", os.Getenv("BOT_DSN"), "Database DSN shared by all bots")
+	fs.StringVar(&opts.HTTPAddr, "http-addr", ":8080", "Address for the shared HTTP server")
+	fs.StringVar(&configPath, "config", os.Getenv("BOT_CONFIG"),
+		"Path to a JSON file mapping bot names to extra command line arguments")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Printf("Unable to parse options: %v\n", err)
+		return 3
+	}
+	for _, bot := range strings.Split(bots, ",") {
+		if bot = strings.TrimSpace(bot); bot != "" {
+			opts.Bots = append(opts.Bots, bot)
+		}
+	}
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			fmt.Printf("Unable to read config: %v\n", err)
+			return 3
+		}
+		if err := json.Unmarshal(data, &opts.Args); err != nil {
+			fmt.Printf("Unable to parse config: %v\n", err)
+			return 3
+		}
+	}
+	if err := base.NewLauncher(opts).Run(); err != nil {
+		fmt.Printf("error running bots: %s\n", err)
+		return 3
+	}
+	return 0
+}
@@ -5,6 +5,7 @@ CREATE TABLE `polls` (
   `msg_id` int(11) NOT NULL,
   `result_msg_id` int(11) NOT NULL,
   `choices` int(11) NOT NULL,
+  `locale` varchar(16) NOT NULL DEFAULT 'en',
    PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
@@ -13,4 +14,4 @@ CREATE TABLE `votes` (
   `username` varchar(50) NOT NULL,
   `choice` int(11) NOT NULL,
    PRIMARY KEY (`id`, `username`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,63 @@
+// Migration script to add the locale column to the polls table
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	var dsn string
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&dsn, "dsn", os.Getenv("BOT_DSN"), "Bot database DSN")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Printf("failed to parse options: %s", err)
+		return 1
+	}
+
+	if len(dsn) == 0 {
+		fmt.Printf("must specify a database DSN\n")
+		return 3
+	}
+
+	sdb, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fmt.Printf("failed to connect to MySQL: %s", err)
+		return 1
+	}
+	defer sdb.Close()
+
+	var count int
+	row := sdb.QueryRow(`
+		SELECT COUNT(*)
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'polls' AND column_name = 'locale'
+	`)
+	if err := row.Scan(&count); err != nil {
+		fmt.Printf("failed to look up the polls table: %s", err)
+		return 1
+	}
+	if count > 0 {
+		fmt.Printf("polls.locale already exists, nothing to migrate\n")
+		return 0
+	}
+
+	// existing polls were all created in English
+	if _, err := sdb.Exec(`
+		ALTER TABLE polls ADD COLUMN locale varchar(16) NOT NULL DEFAULT 'en'
+	`); err != nil {
+		fmt.Printf("failed to add polls.locale: %s", err)
+		return 1
+	}
+	fmt.Printf("Added polls.locale\n")
+	return 0
+}
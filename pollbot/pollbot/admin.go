@@ -0,0 +1,13 @@
+package pollbot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("pollbot",
+		base.AdminTable{Name: "polls", ConvColumn: "conv_id", KeyColumns: []string{"id"}},
+		// a vote's id is its poll's
+		base.AdminTable{Name: "votes", Parent: "polls", ParentColumn: "id", ParentKey: "id",
+			KeyColumns: []string{"id", "username"}},
+	)
+	base.RegisterAdminTables("pollbot", base.OnboardingAdminTable("pollbot"), base.SettingsAdminTable("pollbot"))
+}
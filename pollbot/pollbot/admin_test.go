@@ -0,0 +1,114 @@
+package pollbot
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/keybase/managed-bots/base"
+	"github.com/stretchr/testify/require"
+)
+
+// recordDriver records the statements run against it, answering queries on
+// a table with the rows in its tables map.
+type recordDriver struct {
+	sync.Mutex
+	stmts  []string
+	tables map[string][][]driver.Value
+}
+
+func (d *recordDriver) Open(string) (driver.Conn, error) { return recordConn{d}, nil }
+
+func (d *recordDriver) statements() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.stmts...)
+}
+
+type recordConn struct{ d *recordDriver }
+
+func (c recordConn) Prepare(query string) (driver.Stmt, error) { return recordStmt{c.d, query}, nil }
+func (c recordConn) Close() error                              { return nil }
+func (c recordConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c recordConn) Commit() error                             { return nil }
+func (c recordConn) Rollback() error                           { return nil }
+
+type recordStmt struct {
+	d     *recordDriver
+	query string
+}
+
+func (s recordStmt) Close() error  { return nil }
+func (s recordStmt) NumInput() int { return -1 }
+
+func (s recordStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.stmts = append(s.d.stmts, strings.Join(strings.Fields(s.query), " "))
+	return driver.RowsAffected(1), nil
+}
+
+func (s recordStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.stmts = append(s.d.stmts, strings.Join(strings.Fields(s.query), " "))
+	fields := strings.Fields(s.query)
+	for i, field := range fields {
+		if field == "FROM" && i+1 < len(fields) {
+			return &recordRows{values: s.d.tables[fields[i+1]]}, nil
+		}
+	}
+	return &recordRows{}, nil
+}
+
+type recordRows struct {
+	values [][]driver.Value
+}
+
+func (r *recordRows) Columns() []string { return []string{"id", "username"} }
+func (r *recordRows) Close() error      { return nil }
+
+func (r *recordRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestConvCleanerArchivesVotes(t *testing.T) {
+	d := &recordDriver{tables: map[string][][]driver.Value{
+		"votes": {{"p1", "alice"}, {"p1", "bob"}},
+	}}
+	sql.Register("pollbot-record", d)
+	sdb, err := sql.Open("pollbot-record", "")
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	stats, err := base.NewStatsRegistry(nil, "")
+	require.NoError(t, err)
+	cleaner := base.NewConvCleaner(stats, nil, base.NewDB(sdb), "pollbot")
+	archived, err := cleaner.Cleanup("abc", base.ConvGoneDeleted)
+	require.NoError(t, err)
+	require.Equal(t, 2, archived)
+
+	// the votes are found through their polls, so they go first
+	stmts := d.statements()
+	deleteVotes := "DELETE FROM votes WHERE id IN (SELECT id FROM polls WHERE conv_id = ?)"
+	require.Contains(t, stmts, deleteVotes)
+	require.Contains(t, stmts, "DELETE FROM polls WHERE conv_id = ?")
+	var votesAt, pollsAt int
+	for i, stmt := range stmts {
+		switch stmt {
+		case deleteVotes:
+			votesAt = i
+		case "DELETE FROM polls WHERE conv_id = ?":
+			pollsAt = i
+		}
+	}
+	require.Less(t, votesAt, pollsAt)
+}
@@ -1,6 +1,7 @@
 package pollbot
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -24,28 +25,30 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
-func (d *DB) CreatePoll(id string, convID chat1.ConvIDStr, msgID chat1.MessageID, resultMsgID chat1.MessageID, numChoices int) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CreatePoll(ctx context.Context, id string, convID chat1.ConvIDStr, msgID chat1.MessageID, resultMsgID chat1.MessageID, numChoices int,
+	locale base.Locale) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO polls
-			(id, conv_id, msg_id, result_msg_id, choices)
+			(id, conv_id, msg_id, result_msg_id, choices, locale)
 			VALUES
-			(?, ?, ?, ?, ?)
-		`, id, convID, msgID, resultMsgID, numChoices)
+			(?, ?, ?, ?, ?, ?)
+		`, id, convID, msgID, resultMsgID, numChoices, locale)
 		return err
 	})
 }
 
-func (d *DB) GetPollInfo(id string) (convID chat1.ConvIDStr, resultMsgID chat1.MessageID, numChoices int, err error) {
+func (d *DB) GetPollInfo(id string) (convID chat1.ConvIDStr, resultMsgID chat1.MessageID, numChoices int,
+	locale base.Locale, err error) {
 	row := d.DB.QueryRow(`
-		SELECT conv_id, result_msg_id, choices
+		SELECT conv_id, result_msg_id, choices, locale
 		FROM polls
 		WHERE id = ?
 	`, id)
-	if err := row.Scan(&convID, &resultMsgID, &numChoices); err != nil {
-		return convID, resultMsgID, numChoices, err
+	if err := row.Scan(&convID, &resultMsgID, &numChoices, &locale); err != nil {
+		return convID, resultMsgID, numChoices, locale, err
 	}
-	return convID, resultMsgID, numChoices, nil
+	return convID, resultMsgID, numChoices, locale, nil
 }
 
 func (d *DB) GetTally(id string) (res Tally, err error) {
@@ -69,8 +72,8 @@ func (d *DB) GetTally(id string) (res Tally, err error) {
 	return res, nil
 }
 
-func (d *DB) CastVote(username string, vote Vote) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CastVote(ctx context.Context, username string, vote Vote) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			REPLACE INTO votes
 			(id, username, choice)
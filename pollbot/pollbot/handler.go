@@ -1,6 +1,7 @@
 package pollbot
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/url"
@@ -17,6 +18,8 @@ type Handler struct {
 	stats      *base.StatsRegistry
 	kbc        *kbchat.API
 	db         *DB
+	onboarding *base.Onboarding
+	settings   *base.Settings
 	httpSrv    *HTTPSrv
 	httpPrefix string
 }
@@ -24,7 +27,7 @@ type Handler struct {
 var _ base.Handler = (*Handler)(nil)
 
 func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
-	httpSrv *HTTPSrv, db *DB, httpPrefix string) *Handler {
+	httpSrv *HTTPSrv, db *DB, httpPrefix string, locale base.Locale) *Handler {
 	return &Handler{
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
@@ -32,6 +35,8 @@ func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.Ch
 		db:          db,
 		httpSrv:     httpSrv,
 		httpPrefix:  httpPrefix,
+		settings:    base.NewSettings(kbc, debugConfig, db.DB, "pollbot", "poll", base.LanguageSetting(catalog, locale)),
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, "pollbot", "poll", "Find out the answers to the hardest questions. Try `!poll 'Should we move the office to a beach?' Yes No`"),
 	}
 }
 
@@ -41,10 +46,10 @@ func (h *Handler) generateVoteLink(id string, choice int) string {
 	return strings.ReplaceAll(link, "%", "%%")
 }
 
-func (h *Handler) generateAnonymousPoll(convID chat1.ConvIDStr, prompt string,
+func (h *Handler) generateAnonymousPoll(ctx context.Context, convID chat1.ConvIDStr, locale base.Locale, prompt string,
 	options []string) error {
 	id := base.RandHexString(8)
-	promptBody := fmt.Sprintf("Anonymous Poll: *%s*\n\n", prompt)
+	promptBody := catalog.T(locale, "poll.anonymous", prompt) + "\n\n"
 	sendRes, err := h.kbc.SendMessageByConvID(convID, "%s", promptBody)
 	if err != nil {
 		return fmt.Errorf("failed to send poll: %s", err)
@@ -59,26 +64,26 @@ func (h *Handler) generateAnonymousPoll(convID chat1.ConvIDStr, prompt string,
 			h.generateVoteLink(id, index+1))
 	}
 	h.ChatEcho(convID, "%s", body)
-	if sendRes, err = h.kbc.SendMessageByConvID(convID, "*Results*\n_No votes yet_"); err != nil {
+	if sendRes, err = h.kbc.SendMessageByConvID(convID, "%s", formatTally(locale, nil, len(options))); err != nil {
 		return fmt.Errorf("failed to send poll: %s", err)
 	}
 	if sendRes.Result.MessageID == nil {
 		return fmt.Errorf("failed to get ID of result message")
 	}
 	resultMsgID := *sendRes.Result.MessageID
-	if err := h.db.CreatePoll(id, convID, promptMsgID, resultMsgID, len(options)); err != nil {
+	if err := h.db.CreatePoll(ctx, id, convID, promptMsgID, resultMsgID, len(options), locale); err != nil {
 		return fmt.Errorf("failed to create poll: %s", err)
 	}
 	return nil
 }
 
-func (h *Handler) generatePoll(convID chat1.ConvIDStr, prompt string,
+func (h *Handler) generatePoll(convID chat1.ConvIDStr, locale base.Locale, prompt string,
 	options []string) error {
-	body := fmt.Sprintf("Poll: *%s*\n\n", prompt)
+	body := catalog.T(locale, "poll", prompt) + "\n\n"
 	for index, option := range options {
 		body += fmt.Sprintf("%s  %s\n", base.NumberToEmoji(index+1), option)
 	}
-	body += "Tap a reaction below to register your vote!"
+	body += catalog.T(locale, "poll.vote")
 	sendRes, err := h.kbc.SendMessageByConvID(convID, "%s", body)
 	if err != nil {
 		return fmt.Errorf("failed to send poll: %s", err)
@@ -95,7 +100,9 @@ func (h *Handler) generatePoll(convID chat1.ConvIDStr, prompt string,
 	return nil
 }
 
-func (h *Handler) handlePoll(cmd string, convID chat1.ConvIDStr) error {
+func (h *Handler) handlePoll(ctx context.Context, cmd string, msg chat1.MsgSummary) error {
+	convID := msg.ConvID
+	locale := h.settings.Locale(base.SettingsTargetFromMsg(msg))
 	cmd = strings.ReplaceAll(cmd, "‘", "'")
 	cmd = strings.ReplaceAll(cmd, "’", "'")
 	cmd = strings.ReplaceAll(cmd, "“", "\"")
@@ -111,21 +118,21 @@ func (h *Handler) handlePoll(cmd string, convID chat1.ConvIDStr) error {
 	flags := flag.NewFlagSet(toks[0], flag.ContinueOnError)
 	flags.BoolVar(&anonymous, "anonymous", false, "")
 	if err := flags.Parse(toks[1:]); err != nil {
-		h.ChatEcho(convID, "failed to parse poll command: %s", err)
+		h.ChatEcho(convID, "%s", catalog.T(locale, "poll.parse_error", err))
 		return nil
 	}
 	args := flags.Args()
 	if len(args) < 2 {
-		h.ChatEcho(convID, "must specify a prompt and at least one option")
+		h.ChatEcho(convID, "%s", catalog.T(locale, "poll.missing_options"))
 		return nil
 	}
 	prompt := args[0]
 	h.stats.Count("handlePoll")
 	if anonymous {
 		h.stats.Count("handlePoll - anonymous")
-		return h.generateAnonymousPoll(convID, prompt, args[1:])
+		return h.generateAnonymousPoll(ctx, convID, locale, prompt, args[1:])
 	}
-	return h.generatePoll(convID, prompt, args[1:])
+	return h.generatePoll(convID, locale, prompt, args[1:])
 }
 
 func (h *Handler) handleLogin(convName, username string) {
@@ -135,11 +142,8 @@ func (h *Handler) handleLogin(convName, username string) {
 		return
 	}
 	token := h.httpSrv.LoginToken(username)
-	body := fmt.Sprintf(`Thanks for using the Keybase polling service!
-
-To login your web browser in order to vote in anonymous polls, please follow the link below. Once that is completed, you will be able to vote in anonymous polls simply by clicking the links that I provide in the polls.
-
-%s`, fmt.Sprintf("%s/pollbot/login?token=%s&username=%s", h.httpPrefix, token, username))
+	locale := h.settings.Locale(base.SettingsTarget{Username: username})
+	body := catalog.T(locale, "login", fmt.Sprintf("%s/pollbot/login?token=%s&username=%s", h.httpPrefix, token, username))
 	if _, err := h.kbc.SendMessageByTlfName(username, "%s", body); err != nil {
 		h.Debug("failed to send login attempt: %s", err)
 		return
@@ -147,18 +151,23 @@ To login your web browser in order to vote in anonymous polls, please follow the
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Find out the answers to the hardest questions. Try `!poll 'Should we move the office to a beach?' Yes No`"
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
+	if handled, err := h.settings.HandleCommand(ctx, msg); handled {
+		return err
+	}
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	switch {
 	case strings.HasPrefix(cmd, "!poll"):
-		return h.handlePoll(cmd, msg.ConvID)
+		return h.handlePoll(ctx, cmd, msg)
 	case strings.ToLower(cmd) == "login":
 		h.handleLogin(msg.Channel.Name, msg.Sender.Username)
 	}
@@ -88,12 +88,12 @@ func (h *HTTPSrv) handleVote(w http.ResponseWriter, r *http.Request) {
 	}
 	vstr := r.URL.Query().Get("")
 	vote := NewVoteFromEncoded(vstr)
-	if err := h.db.CastVote(username, vote); err != nil {
+	if err := h.db.CastVote(r.Context(), username, vote); err != nil {
 		h.Errorf("failed to cast vote: %s", err)
 		h.showError(w)
 		return
 	}
-	convID, resultMsgID, numChoices, err := h.db.GetPollInfo(vote.ID)
+	convID, resultMsgID, numChoices, locale, err := h.db.GetPollInfo(vote.ID)
 	if err != nil {
 		h.Errorf("failed to find poll result msg: %s", err)
 		h.showError(w)
@@ -105,7 +105,7 @@ func (h *HTTPSrv) handleVote(w http.ResponseWriter, r *http.Request) {
 		h.showError(w)
 		return
 	}
-	if _, err := h.kbc.EditByConvID(convID, resultMsgID, formatTally(tally, numChoices)); err != nil {
+	if _, err := h.kbc.EditByConvID(convID, resultMsgID, formatTally(locale, tally, numChoices)); err != nil {
 		h.Errorf("failed to post result: %s", err)
 		h.showError(w)
 		return
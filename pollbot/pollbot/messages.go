@@ -0,0 +1,77 @@
+package pollbot
+
+import "github.com/keybase/managed-bots/base"
+
+var catalog = base.NewCatalog(map[string]string{
+	"poll":                 "Poll: *%s*",
+	"poll.anonymous":       "Anonymous Poll: *%s*",
+	"poll.vote":            "Tap a reaction below to register your vote!",
+	"poll.results":         "*Results*",
+	"poll.no_votes":        "_No votes yet_",
+	"poll.vote_count":      "%d votes",
+	"poll.vote_count_one":  "1 vote",
+	"poll.parse_error":     "failed to parse poll command: %s",
+	"poll.missing_options": "must specify a prompt and at least one option",
+	"login": `Thanks for using the Keybase polling service!
+
+To login your web browser in order to vote in anonymous polls, please follow the link below. Once that is completed, you will be able to vote in anonymous polls simply by clicking the links that I provide in the polls.
+
+%s`,
+	"ad.poll":      "Start a poll",
+	"ad.poll.body": "Start either a public or an anonymous poll. Public polls are driven by people clicking reactions on the polling message. Anonymous polls offer a link a user can click to register their vote. The polling service will update the results of anonymous polls as they are received without revealing the voter, while also enforcing one vote per person.",
+	"ad.example":   "Example:",
+}).Add("de", map[string]string{
+	"poll":                 "Umfrage: *%s*",
+	"poll.anonymous":       "Anonyme Umfrage: *%s*",
+	"poll.vote":            "Tippe unten auf eine Reaktion, um abzustimmen!",
+	"poll.results":         "*Ergebnisse*",
+	"poll.no_votes":        "_Noch keine Stimmen_",
+	"poll.vote_count":      "%d Stimmen",
+	"poll.vote_count_one":  "1 Stimme",
+	"poll.parse_error":     "Umfragebefehl konnte nicht gelesen werden: %s",
+	"poll.missing_options": "Bitte gib eine Frage und mindestens eine Antwort an",
+	"login": `Danke, dass du den Keybase-Umfragedienst nutzt!
+
+Um deinen Browser für die Abstimmung in anonymen Umfragen anzumelden, folge bitte dem Link unten. Danach kannst du in anonymen Umfragen einfach abstimmen, indem du auf die Links in den Umfragen klickst.
+
+%s`,
+	"ad.poll":      "Eine Umfrage starten",
+	"ad.poll.body": "Starte eine öffentliche oder eine anonyme Umfrage. In öffentlichen Umfragen wird durch Reaktionen auf die Umfragenachricht abgestimmt. Anonyme Umfragen bieten einen Link, über den man abstimmen kann. Der Umfragedienst aktualisiert die Ergebnisse anonymer Umfragen, sobald Stimmen eingehen, ohne die Abstimmenden preiszugeben, und lässt nur eine Stimme pro Person zu.",
+	"ad.example":   "Beispiel:",
+}).Add("es", map[string]string{
+	"poll":                 "Encuesta: *%s*",
+	"poll.anonymous":       "Encuesta anónima: *%s*",
+	"poll.vote":            "¡Toca una reacción abajo para votar!",
+	"poll.results":         "*Resultados*",
+	"poll.no_votes":        "_Todavía no hay votos_",
+	"poll.vote_count":      "%d votos",
+	"poll.vote_count_one":  "1 voto",
+	"poll.parse_error":     "no se pudo leer el comando de encuesta: %s",
+	"poll.missing_options": "indica una pregunta y al menos una opción",
+	"login": `¡Gracias por usar el servicio de encuestas de Keybase!
+
+Para iniciar sesión en tu navegador y votar en encuestas anónimas, sigue el enlace de abajo. Después podrás votar en encuestas anónimas simplemente haciendo clic en los enlaces que incluyo en ellas.
+
+%s`,
+	"ad.poll":      "Iniciar una encuesta",
+	"ad.poll.body": "Inicia una encuesta pública o anónima. En las encuestas públicas se vota con reacciones al mensaje de la encuesta. Las encuestas anónimas ofrecen un enlace para registrar el voto. El servicio de encuestas actualiza los resultados de las encuestas anónimas a medida que llegan los votos sin revelar quién votó y permite un solo voto por persona.",
+	"ad.example":   "Ejemplo:",
+}).Add("fr", map[string]string{
+	"poll":                 "Sondage : *%s*",
+	"poll.anonymous":       "Sondage anonyme : *%s*",
+	"poll.vote":            "Appuyez sur une réaction ci-dessous pour voter !",
+	"poll.results":         "*Résultats*",
+	"poll.no_votes":        "_Aucun vote pour l'instant_",
+	"poll.vote_count":      "%d votes",
+	"poll.vote_count_one":  "1 vote",
+	"poll.parse_error":     "impossible de lire la commande de sondage : %s",
+	"poll.missing_options": "indiquez une question et au moins une option",
+	"login": `Merci d'utiliser le service de sondages de Keybase !
+
+Pour connecter votre navigateur et voter dans les sondages anonymes, suivez le lien ci-dessous. Vous pourrez ensuite voter dans les sondages anonymes simplement en cliquant sur les liens que je fournis dans les sondages.
+
+%s`,
+	"ad.poll":      "Lancer un sondage",
+	"ad.poll.body": "Lancez un sondage public ou anonyme. Dans les sondages publics, on vote en réagissant au message du sondage. Les sondages anonymes proposent un lien pour enregistrer son vote. Le service met à jour les résultats des sondages anonymes au fur et à mesure sans révéler qui a voté, tout en limitant à un vote par personne.",
+	"ad.example":   "Exemple :",
+})
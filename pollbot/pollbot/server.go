@@ -0,0 +1,163 @@
+package pollbot
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"golang.org/x/sync/errgroup"
+)
+
+type Options struct {
+	*base.Options
+	HTTPPrefix  string
+	LoginSecret string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Options: base.NewOptions(),
+	}
+}
+
+type BotServer struct {
+	*base.Server
+
+	opts Options
+	env  *base.BotEnv
+	kbc  *kbchat.API
+}
+
+func NewBotServer(opts Options, env *base.BotEnv) *BotServer {
+	return &BotServer{
+		Server: base.NewServer("pollbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+		}),
+		opts: opts,
+		env:  env,
+	}
+}
+
+const backs = "```"
+
+func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	locale := base.ParseLocale(s.opts.Locale)
+	pollExtended := fmt.Sprintf(`%s
+
+	%s%s
+		!poll "Should we move the office to a beach?" "Yes" "No"
+		!poll  --anonymous "Where should the next meetup be?" "Miami" "Las Vegas" "Houston"%s`,
+		catalog.T(locale, "ad.poll.body"), catalog.T(locale, "ad.example"), backs, backs)
+
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "poll",
+			Description: catalog.T(locale, "ad.poll"),
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title: `*!poll* [--anonymous] <prompt> <option1> [option2]...
+` + catalog.T(locale, "ad.poll"),
+				DesktopBody: pollExtended,
+				MobileBody:  pollExtended,
+			},
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("poll", false)...)
+	cmds = append(cmds, base.SettingsAdvertisement("poll"))
+	return kbchat.Advertisement{
+		Alias: "Polling Service",
+		Advertisements: []chat1.AdvertiseCommandAPIParam{
+			{
+				Typ:      "public",
+				Commands: cmds,
+			},
+		},
+	}
+}
+
+func (s *BotServer) getLoginSecret() (secret string, err error) {
+	defer s.Trace(&err, "getLoginSecret")()
+	if s.opts.LoginSecret != "" {
+		return s.opts.LoginSecret, nil
+	}
+	path := fmt.Sprintf("/keybase/private/%s/login.secret", s.kbc.GetUsername())
+	cmd := s.opts.Command("fs", "read", path)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	s.Debug("Running `keybase fs read` on %q and waiting for it to finish...\n", path)
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (s *BotServer) Go() (err error) {
+	if s.kbc, err = s.Start(s.opts.ErrReportConv); err != nil {
+		return err
+	}
+	sdb, closeDB, err := s.env.OpenDB(s.opts.DSN)
+	if err != nil {
+		s.Errorf("failed to connect to MySQL: %s", err)
+		return err
+	}
+	defer closeDB()
+	db := NewDB(sdb)
+
+	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
+	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
+	if err != nil {
+		s.Debug("unable to create stats %v", err)
+		return err
+	}
+	loginSecret, err := s.getLoginSecret()
+	if err != nil {
+		s.Errorf("failed to get login secret: %s", err)
+		return
+	}
+	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	httpSrv := NewHTTPSrv(stats, s.kbc, debugConfig, db, loginSecret)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
+	handler := NewHandler(stats, s.kbc, debugConfig, httpSrv, db, s.opts.HTTPPrefix, base.ParseLocale(s.opts.Locale))
+	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
+	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
+	s.GoWithRecover(eg, httpSrv.Listen)
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats, cleaner) })
+	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
+	if err := eg.Wait(); err != nil {
+		s.Debug("wait error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	base.RegisterBot("pollbot", NewBot)
+}
+
+// NewBot parses the command line in argv and returns the bot, env is nil
+// unless the bot is run by a base.Launcher.
+func NewBot(argv []string, env *base.BotEnv) (base.Bot, error) {
+	opts := NewOptions()
+	fs := flag.NewFlagSet(argv[0], flag.ContinueOnError)
+	fs.StringVar(&opts.HTTPPrefix, "http-prefix", os.Getenv("BOT_HTTP_PREFIX"), "")
+	fs.StringVar(&opts.LoginSecret, "login-secret", os.Getenv("BOT_LOGIN_SECRET"), "Login token secret")
+	if err := opts.Parse(fs, argv); err != nil {
+		return nil, fmt.Errorf("Unable to parse options: %v", err)
+	}
+	if len(opts.DSN) == 0 && !env.HasDB() {
+		return nil, errors.New("must specify a database DSN")
+	}
+	return NewBotServer(*opts, env), nil
+}
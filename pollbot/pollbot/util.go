@@ -8,10 +8,10 @@ import (
 	"github.com/keybase/managed-bots/base"
 )
 
-func formatTally(tally Tally, numChoices int) (res string) {
-	res = "*Results*\n"
+func formatTally(locale base.Locale, tally Tally, numChoices int) (res string) {
+	res = catalog.T(locale, "poll.results") + "\n"
 	if len(tally) == 0 {
-		res += "_No votes yet_"
+		res += catalog.T(locale, "poll.no_votes")
 		return res
 	}
 	total := 0
@@ -28,15 +28,14 @@ func formatTally(tally Tally, numChoices int) (res string) {
 			t.choice = i + 1
 			t.votes = 0
 		}
-		s := ""
+		votes := catalog.T(locale, "poll.vote_count_one")
 		if t.votes != 1 {
-			s = "s"
+			votes = catalog.T(locale, "poll.vote_count", t.votes)
 		}
 		prop := float64(t.votes) / float64(total)
 		num := int(math.Max(10*prop, 1))
 		bar := strings.Repeat("🟢", num)
-		res += fmt.Sprintf("%s %s\n`(%.02f%%, %d vote%s)`\n\n", base.NumberToEmoji(t.choice), bar, prop*100,
-			t.votes, s)
+		res += fmt.Sprintf("%s %s\n`(%.02f%%, %s)`\n\n", base.NumberToEmoji(t.choice), bar, prop*100, votes)
 	}
 	return res
 }
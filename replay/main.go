@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keybase/managed-bots/base"
+	_ "github.com/keybase/managed-bots/macrobot/macrobot"
+	_ "github.com/keybase/managed-bots/pollbot/pollbot"
+	_ "github.com/keybase/managed-bots/triviabot/triviabot"
+	_ "github.com/keybase/managed-bots/webhookbot/webhookbot"
+)
+
+func main() {
+	// we are also the fake keybase binary the bot runs against
+	if base.IsFakeKeybase() {
+		os.Exit(base.RunFakeKeybase(os.Args[1:]))
+	}
+	rc := mainInner()
+	os.Exit(rc)
+}
+
+func mainInner() int {
+	var golden string
+	var update bool
+	opts := base.ReplayOptions{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&opts.Bot, "bot", "",
+		fmt.Sprintf("Bot to replay against, from: %s", strings.Join(base.RegisteredBots(), ", ")))
+	fs.StringVar(&opts.Recording, "recording", "", "Recording made with the bot's --record flag")
+	fs.StringVar(&opts.Username, "username", "", "Username of the bot in the recording, defaults to --bot")
+	fs.DurationVar(&opts.Settle, "settle", 2*time.Second,
+		"How long the bot must be quiet after the last event before the replay is done")
+	fs.DurationVar(&opts.Timeout, "timeout", 2*time.Minute, "How long to wait for the replay to finish")
+	fs.StringVar(&golden, "golden", "", "File of expected output to compare against, optional")
+	fs.BoolVar(&update, "update", false, "Write the output to --golden rather than comparing")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Printf("Unable to parse options: %v\n", err)
+		return 3
+	}
+	if opts.Bot == "" || opts.Recording == "" {
+		fmt.Printf("must specify --bot and --recording\n")
+		return 3
+	}
+	if opts.Username == "" {
+		opts.Username = opts.Bot
+	}
+	// anything after the flags, e.g. `-- --dsn root@/scratch`, is for the bot
+	opts.Args = fs.Args()
+
+	sent, err := base.Replay(opts)
+	if err != nil {
+		fmt.Printf("error replaying: %s\n", err)
+		return 3
+	}
+	var lines []string
+	for _, msg := range sent {
+		lines = append(lines, msg.String())
+	}
+	output := strings.Join(lines, "\n") + "\n"
+
+	switch {
+	case golden == "":
+		fmt.Print(output)
+	case update:
+		if err := os.WriteFile(golden, []byte(output), 0644); err != nil {
+			fmt.Printf("unable to write %s: %s\n", golden, err)
+			return 3
+		}
+	default:
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			fmt.Printf("unable to read %s: %s\n", golden, err)
+			return 3
+		}
+		if string(expected) != output {
+			fmt.Printf("output differs from %s, got:\n%s", golden, output)
+			return 1
+		}
+	}
+	return 0
+}
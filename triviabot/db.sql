@@ -11,4 +11,4 @@ CREATE TABLE `tokens` (
   `conv_id` varchar(100) NOT NULL,
   `token` varchar(100) NOT NULL,
   PRIMARY KEY (`conv_id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
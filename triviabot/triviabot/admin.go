@@ -0,0 +1,11 @@
+package triviabot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("triviabot",
+		base.AdminTable{Name: "leaderboard", ConvColumn: "conv_id", KeyColumns: []string{"username"}},
+		base.AdminTable{Name: "tokens", ConvColumn: "conv_id", SecretColumns: []string{"token"}},
+	)
+	base.RegisterAdminTables("triviabot", base.OnboardingAdminTable("triviabot"), base.SettingsAdminTable("triviabot"))
+}
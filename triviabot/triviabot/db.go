@@ -1,6 +1,7 @@
 package triviabot
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -17,8 +18,8 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
-func (d *DB) RecordAnswer(convID chat1.ConvIDStr, username string, pointAdjust int, isCorrect bool) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) RecordAnswer(ctx context.Context, convID chat1.ConvIDStr, username string, pointAdjust int, isCorrect bool) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		correct := 0
 		incorrect := 0
 		if isCorrect {
@@ -67,8 +68,8 @@ func (d *DB) TopUsers(convID chat1.ConvIDStr) (res []TopUser, err error) {
 	return res, nil
 }
 
-func (d *DB) ResetConv(convID chat1.ConvIDStr) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) ResetConv(ctx context.Context, convID chat1.ConvIDStr) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		if _, err := tx.Exec(`
 			DELETE FROM leaderboard WHERE conv_id  = ?
 		`, base.ShortConvID(convID)); err != nil {
@@ -88,8 +89,8 @@ func (d *DB) GetAPIToken(convID chat1.ConvIDStr) (res string, err error) {
 	return res, nil
 }
 
-func (d *DB) SetAPIToken(convID chat1.ConvIDStr, token string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) SetAPIToken(ctx context.Context, convID chat1.ConvIDStr, token string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		if _, err := tx.Exec(`
 			REPLACE INTO tokens (conv_id, token) VALUES (?, ?)
 		`, base.ShortConvID(convID), token); err != nil {
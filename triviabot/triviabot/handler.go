@@ -1,6 +1,7 @@
 package triviabot
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,19 +19,26 @@ type Handler struct {
 	kbc         *kbchat.API
 	debugConfig *base.ChatDebugOutputConfig
 	db          *DB
+	onboarding  *base.Onboarding
+	settings    *base.Settings
+	httpClient  *base.HTTPClient
 	sessions    map[chat1.ConvIDStr]*session
 }
 
 var _ base.Handler = (*Handler)(nil)
 
-func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB) *Handler {
+func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB,
+	httpClient *base.HTTPClient) *Handler {
 	return &Handler{
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
 		kbc:         kbc,
 		debugConfig: debugConfig,
 		db:          db,
+		httpClient:  httpClient,
 		sessions:    make(map[chat1.ConvIDStr]*session),
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, "triviabot", "trivia", "Are you up to the challenge? Try `!trivia begin` to find out."),
+		settings:    base.NewSettings(kbc, debugConfig, db.DB, "triviabot", "trivia", settingDefs...),
 	}
 }
 
@@ -38,8 +46,12 @@ func (h *Handler) handleStart(msg chat1.MsgSummary) {
 	h.Lock()
 	defer h.Unlock()
 	convID := msg.ConvID
-	session := newSession(h.kbc, h.debugConfig, h.db, convID)
-	doneCb, err := session.start(0)
+	total, err := h.settings.GetInt(base.SettingsTargetFromMsg(msg), settingQuestions)
+	if err != nil {
+		h.Debug("handleStart: unable to get %s setting: %s", settingQuestions, err)
+	}
+	session := newSession(h.kbc, h.debugConfig, h.db, h.httpClient.Client(), convID)
+	doneCb, err := session.start(total)
 	if err != nil {
 		h.ChatErrorf(convID, "handleState: failed to start: %s", err)
 	}
@@ -85,9 +97,9 @@ func (h *Handler) handleTop(convID chat1.ConvIDStr) error {
 	return nil
 }
 
-func (h *Handler) handleReset(msg chat1.MsgSummary) error {
+func (h *Handler) handleReset(ctx context.Context, msg chat1.MsgSummary) error {
 	convID := msg.ConvID
-	if err := h.db.ResetConv(convID); err != nil {
+	if err := h.db.ResetConv(ctx, convID); err != nil {
 		return fmt.Errorf("handleReset: failed to reset: %s", err)
 	}
 	h.ChatEcho(convID, "Leaderboard reset")
@@ -110,11 +122,10 @@ func (h *Handler) handleAnswer(convID chat1.ConvIDStr, reaction chat1.MessageRea
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Are you up to the challenge? Try `!trivia begin` to find out."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Reaction != nil && msg.Sender.Username != h.kbc.GetUsername() {
 		h.handleAnswer(msg.ConvID, *msg.Content.Reaction, msg.Sender.Username)
 		return nil
@@ -122,6 +133,12 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
+	if handled, err := h.settings.HandleCommand(ctx, msg); handled {
+		return err
+	}
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	switch {
 	case strings.HasPrefix(cmd, "!trivia begin"):
@@ -135,7 +152,7 @@ func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
 		return h.handleTop(msg.ConvID)
 	case strings.HasPrefix(cmd, "!trivia reset"):
 		h.stats.Count("reset")
-		return h.handleReset(msg)
+		return h.handleReset(ctx, msg)
 	}
 	return nil
 }
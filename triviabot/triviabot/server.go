@@ -0,0 +1,120 @@
+package triviabot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"golang.org/x/sync/errgroup"
+)
+
+type BotServer struct {
+	*base.Server
+
+	opts base.Options
+	env  *base.BotEnv
+	kbc  *kbchat.API
+}
+
+func NewBotServer(opts base.Options, env *base.BotEnv) *BotServer {
+	return &BotServer{
+		Server: base.NewServer("triviabot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+		}),
+		opts: opts,
+		env:  env,
+	}
+}
+
+func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "trivia begin",
+			Description: "Begin a new question asking session",
+		},
+		{
+			Name:        "trivia end",
+			Description: "End the current question asking session",
+		},
+		{
+			Name:        "trivia top",
+			Description: "Show the top users for this conversation",
+		},
+		{
+			Name:        "trivia reset",
+			Description: "Reset the scores leaderboard",
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("trivia", false)...)
+	cmds = append(cmds, base.SettingsAdvertisement("trivia"))
+	return kbchat.Advertisement{
+		Alias: "Trivia",
+		Advertisements: []chat1.AdvertiseCommandAPIParam{
+			{
+				Typ:      "public",
+				Commands: cmds,
+			},
+		},
+	}
+}
+
+func (s *BotServer) Go() (err error) {
+	if s.kbc, err = s.Start(s.opts.ErrReportConv); err != nil {
+		return err
+	}
+	sdb, closeDB, err := s.env.OpenDB(s.opts.DSN)
+	if err != nil {
+		s.Errorf("failed to connect to MySQL: %s", err)
+		return err
+	}
+	defer closeDB()
+	db := NewDB(sdb)
+
+	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
+	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
+	if err != nil {
+		s.Debug("unable to create stats: %v", err)
+		return err
+	}
+	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	handler := NewHandler(stats, s.kbc, debugConfig, db, httpClient)
+	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
+	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(stats, cleaner) })
+	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
+	if err := eg.Wait(); err != nil {
+		s.Debug("wait error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	base.RegisterBot("triviabot", NewBot)
+}
+
+// NewBot parses the command line in argv and returns the bot, env is nil
+// unless the bot is run by a base.Launcher.
+func NewBot(argv []string, env *base.BotEnv) (base.Bot, error) {
+	opts := base.NewOptions()
+	fs := flag.NewFlagSet(argv[0], flag.ContinueOnError)
+	if err := opts.Parse(fs, argv); err != nil {
+		return nil, fmt.Errorf("Unable to parse options: %v", err)
+	}
+	if len(opts.DSN) == 0 && !env.HasDB() {
+		return nil, errors.New("must specify a database DSN")
+	}
+	return NewBotServer(*opts, env), nil
+}
@@ -1,6 +1,7 @@
 package triviabot
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -95,6 +96,7 @@ type session struct {
 
 	kbc            *kbchat.API
 	db             *DB
+	httpClient     *http.Client
 	convID         chat1.ConvIDStr
 	numUsersInConv int
 	curQuestion    *question
@@ -104,10 +106,12 @@ type session struct {
 	dupCheck       map[string]bool
 }
 
-func newSession(kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB, convID chat1.ConvIDStr) *session {
+func newSession(kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig, db *DB, httpClient *http.Client,
+	convID chat1.ConvIDStr) *session {
 	return &session{
 		DebugOutput: base.NewDebugOutput("session", debugConfig),
 		db:          db,
+		httpClient:  httpClient,
 		convID:      convID,
 		answerCh:    make(chan answer, 10),
 		kbc:         kbc,
@@ -121,7 +125,7 @@ func (s *session) getCategory() int {
 }
 
 func (s *session) getAPIToken() (string, error) {
-	resp, err := http.Get("https://opentdb.com/api_token.php?command=request")
+	resp, err := s.httpClient.Get("https://opentdb.com/api_token.php?command=request")
 	if err != nil {
 		return "", err
 	}
@@ -151,7 +155,7 @@ func (s *session) getToken(forceAPI bool) (token string, err error) {
 			s.ChatErrorf(s.convID, "getToken: failed to get token from API: %s", err)
 			return "", err
 		}
-		if err := s.db.SetAPIToken(s.convID, token); err != nil {
+		if err := s.db.SetAPIToken(context.Background(), s.convID, token); err != nil {
 			s.Errorf("getToken: failed to set token in DB: %s", err)
 		}
 	} else {
@@ -173,7 +177,7 @@ func (s *session) getNextQuestion() error {
 		url := fmt.Sprintf("https://opentdb.com/api.php?amount=1&category=%d&token=%s&type=multiple",
 			s.getCategory(), token)
 		s.Debug("getNextQuestion: url: %s", url)
-		resp, err := http.Get(url)
+		resp, err := s.httpClient.Get(url)
 		if err != nil {
 			return err
 		}
@@ -312,7 +316,7 @@ func (s *session) waitForCorrectAnswer() {
 					continue
 				}
 				isCorrect, pointAdjust := s.getAnswerPoints(answer, *s.curQuestion)
-				if err := s.db.RecordAnswer(s.convID, answer.username, pointAdjust, isCorrect); err != nil {
+				if err := s.db.RecordAnswer(context.Background(), s.convID, answer.username, pointAdjust, isCorrect); err != nil {
 					s.Errorf("waitForCorrectAnswer: failed to record answer: %s", err)
 				}
 				s.regDupe(answer.username)
@@ -364,14 +368,14 @@ func (s *session) start(intotal int) (doneCb chan struct{}, err error) {
 			default:
 			}
 			if err := s.getNextQuestion(); err != nil {
-				if base.IsDeletedConvError(err) {
+				if s.CheckDeletedConv(s.convID, err) {
 					return
 				}
 				s.ChatErrorf(s.convID, "start: failed to get next question: %s", err)
 				continue
 			}
 			if err := s.askQuestion(); err != nil {
-				if base.IsDeletedConvError(err) {
+				if s.CheckDeletedConv(s.convID, err) {
 					return
 				}
 				s.ChatErrorf(s.convID, "start: failed to ask question: %s", err)
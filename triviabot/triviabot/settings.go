@@ -0,0 +1,19 @@
+package triviabot
+
+import (
+	"strconv"
+
+	"github.com/keybase/managed-bots/base"
+)
+
+const settingQuestions = "questions"
+
+var settingDefs = []base.SettingDef{
+	{
+		Name:        settingQuestions,
+		Description: "How many questions `!trivia begin` asks",
+		Type:        base.SettingInt,
+		Default:     strconv.Itoa(defaultTotal),
+		Scopes:      []base.SettingScope{base.SettingScopeConv, base.SettingScopeTeam},
+	},
+}
@@ -4,4 +4,4 @@ CREATE TABLE `hooks` (
   `conv_id` varchar(100) NOT NULL,
   PRIMARY KEY (`id`),
   KEY `conv_id` (`conv_id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
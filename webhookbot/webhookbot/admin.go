@@ -0,0 +1,10 @@
+package webhookbot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("webhookbot",
+		base.AdminTable{Name: "hooks", ConvColumn: "conv_id", KeyColumns: []string{"id", "name"}},
+	)
+	base.RegisterAdminTables("webhookbot", base.OnboardingAdminTable("webhookbot"))
+}
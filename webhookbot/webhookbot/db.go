@@ -1,6 +1,7 @@
 package webhookbot
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"database/sql"
@@ -35,12 +36,12 @@ func (d *DB) makeID(name string, convID chat1.ConvIDStr) (string, error) {
 	return base.URLEncoder().EncodeToString(h.Sum(nil)[:20]), nil
 }
 
-func (d *DB) Create(name string, convID chat1.ConvIDStr) (string, error) {
+func (d *DB) Create(ctx context.Context, name string, convID chat1.ConvIDStr) (string, error) {
 	id, err := d.makeID(name, convID)
 	if err != nil {
 		return "", err
 	}
-	err = d.RunTxn(func(tx *sql.Tx) error {
+	err = d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		if _, err := tx.Exec(`
 			INSERT INTO hooks
 			(id, name, conv_id)
@@ -89,8 +90,8 @@ func (d *DB) List(convID chat1.ConvIDStr) (res []Webhook, err error) {
 	return res, nil
 }
 
-func (d *DB) Remove(name string, convID chat1.ConvIDStr) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) Remove(ctx context.Context, name string, convID chat1.ConvIDStr) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE FROM hooks WHERE conv_id = ? AND name = ?
 		`, convID, name)
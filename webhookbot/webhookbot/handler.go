@@ -1,6 +1,7 @@
 package webhookbot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -16,6 +17,7 @@ type Handler struct {
 	stats      *base.StatsRegistry
 	kbc        *kbchat.API
 	db         *DB
+	onboarding *base.Onboarding
 	httpSrv    *HTTPSrv
 	httpPrefix string
 }
@@ -24,7 +26,7 @@ var _ base.Handler = (*Handler)(nil)
 
 func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
 	httpSrv *HTTPSrv, db *DB, httpPrefix string) *Handler {
-	return &Handler{
+	h := &Handler{
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
 		kbc:         kbc,
@@ -32,6 +34,9 @@ func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.Ch
 		httpSrv:     httpSrv,
 		httpPrefix:  httpPrefix,
 	}
+	h.onboarding = base.NewOnboarding(stats, kbc, debugConfig, db.DB, "webhookbot", "webhook", "I can create generic webhooks into Keybase! Try `!webhook create` to get started.")
+	h.onboarding.SetWizard(h.HandleCommand, h.setupSteps()...)
+	return h
 }
 
 func (h *Handler) formURL(id string) string {
@@ -51,7 +56,7 @@ func (h *Handler) checkAllowed(msg chat1.MsgSummary) error {
 	return nil
 }
 
-func (h *Handler) handleRemove(cmd string, msg chat1.MsgSummary) (err error) {
+func (h *Handler) handleRemove(ctx context.Context, cmd string, msg chat1.MsgSummary) (err error) {
 	convID := msg.ConvID
 	toks := strings.Split(cmd, " ")
 	if len(toks) != 3 {
@@ -69,7 +74,7 @@ func (h *Handler) handleRemove(cmd string, msg chat1.MsgSummary) (err error) {
 	}
 	h.stats.Count("remove")
 	name := toks[2]
-	if err := h.db.Remove(name, convID); err != nil {
+	if err := h.db.Remove(ctx, name, convID); err != nil {
 		return fmt.Errorf("handleRemove: failed to remove webhook: %s", err)
 	}
 	h.ChatEcho(convID, "Success!")
@@ -108,7 +113,7 @@ func (h *Handler) handleList(_ string, msg chat1.MsgSummary) (err error) {
 	return nil
 }
 
-func (h *Handler) handleCreate(cmd string, msg chat1.MsgSummary) (err error) {
+func (h *Handler) handleCreate(ctx context.Context, cmd string, msg chat1.MsgSummary) (err error) {
 	convID := msg.ConvID
 	toks := strings.Split(cmd, " ")
 	if len(toks) != 3 {
@@ -127,7 +132,7 @@ func (h *Handler) handleCreate(cmd string, msg chat1.MsgSummary) (err error) {
 
 	h.stats.Count("create")
 	name := toks[2]
-	id, err := h.db.Create(name, convID)
+	id, err := h.db.Create(ctx, name, convID)
 	if err != nil {
 		return fmt.Errorf("handleCreate: failed to create webhook: %s", err)
 	}
@@ -139,22 +144,24 @@ func (h *Handler) handleCreate(cmd string, msg chat1.MsgSummary) (err error) {
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "I can create generic webhooks into Keybase! Try `!webhook create` to get started."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	switch {
 	case strings.HasPrefix(cmd, "!webhook create"):
-		return h.handleCreate(cmd, msg)
+		return h.handleCreate(ctx, cmd, msg)
 	case strings.HasPrefix(cmd, "!webhook list"):
 		return h.handleList(cmd, msg)
 	case strings.HasPrefix(cmd, "!webhook remove"):
-		return h.handleRemove(cmd, msg)
+		return h.handleRemove(ctx, cmd, msg)
 	}
 	return nil
 }
@@ -6,9 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"strings"
-	"time"
 
 	"github.com/didip/tollbooth/v7"
 	"github.com/gorilla/mux"
@@ -81,38 +78,13 @@ func (h *HTTPSrv) handleHook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.Stats.Count("handle - success")
-	if _, err := h.Config().KBC.SendMessageByConvID(hook.ConvID, "[hook: *%s*]\n\n%s", hook.Name, msg); err != nil {
-		if base.IsDeletedConvError(err) {
-			h.Debug("handleHook: failed to send message: %s", err)
+	title := fmt.Sprintf("[hook: *%s*]", hook.Name)
+	if err := base.SendLongMessageByConvID(h.Config().KBC, h.DebugOutput, hook.ConvID, title, msg); err != nil {
+		if h.CheckDeletedConv(hook.ConvID, err) {
+			// the hook is being cleaned up along with the conversation
+			w.WriteHeader(http.StatusGone)
 			return
 		}
-
-		// error created in https://github.com/keybase/client/blob/7d6aa64f3fba66adba7a5dd1cc7c523d5086a548/go/chat/msgchecker/plaintext_checker.go#L50
-		if strings.Contains(err.Error(), "exceeds the maximum length") {
-			fileName := fmt.Sprintf("webhookbot-%s-%d.txt", hook.Name, time.Now().Unix())
-			filePath := fmt.Sprintf("/tmp/%s", fileName)
-			if err := os.WriteFile(filePath, []byte(msg), 0644); err != nil {
-				h.Errorf("failed to write %s: %s", filePath, err)
-				return
-			}
-			base.GoWithRecover(h.DebugOutput, func() {
-				defer func() {
-					// Cleanup after the file is sent.
-					time.Sleep(time.Minute)
-					h.Debug("cleaning up %s", filePath)
-					if err = os.Remove(filePath); err != nil {
-						h.Errorf("unable to clean up %s: %v", filePath, err)
-					}
-				}()
-				title := fmt.Sprintf("[hook: *%s*]", hook.Name)
-				if _, err := h.Config().KBC.SendAttachmentByConvID(hook.ConvID, filePath, title); err != nil {
-					h.Errorf("failed to send attachment %s: %s", filePath, err)
-					return
-				}
-			})
-			return
-		}
-
 		h.Debug("handleHook: failed to send message: %s", err)
 	}
 }
@@ -0,0 +1,156 @@
+package webhookbot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat"
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+	"golang.org/x/sync/errgroup"
+)
+
+type Options struct {
+	*base.Options
+	HTTPPrefix string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Options: base.NewOptions(),
+	}
+}
+
+type BotServer struct {
+	*base.Server
+
+	opts Options
+	env  *base.BotEnv
+	kbc  *kbchat.API
+}
+
+func NewBotServer(opts Options, env *base.BotEnv) *BotServer {
+	return &BotServer{
+		Server: base.NewServer("webhookbot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
+			KeybaseLocation: opts.KeybaseLocation,
+			HomeDir:         opts.Home,
+			NumPipes:        5,
+		}),
+		opts: opts,
+		env:  env,
+	}
+}
+
+const back = "`"
+const backs = "```"
+
+func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	createExtended := fmt.Sprintf(`Create a new webhook for sending messages into the current conversation. You must supply a name as well to identify the webhook. To use a webhook URL, supply a %smsg%s URL parameter, or a JSON POST body with a field %smsg%s.
+
+	Example:%s
+		!webhook create alerts%s`, back, back, back, back, backs, backs)
+	removeExtended := fmt.Sprintf(`Remove a webhook from the current conversation. You must supply the name of the webhook.
+
+	Example:%s
+		!webhook remove alerts%s`, backs, backs)
+
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "webhook create",
+			Description: "Create a new webhook for sending into the current conversation",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title: `*!webhook create* <name>
+Create a webhook`,
+				DesktopBody: createExtended,
+				MobileBody:  createExtended,
+			},
+		},
+		{
+			Name:        "webhook list",
+			Description: "List active webhooks in the current conversation",
+		},
+		{
+			Name:        "webhook remove",
+			Description: "Remove a webhook from the current conversation",
+			ExtendedDescription: &chat1.UserBotExtendedDescription{
+				Title: `*!webhook remove* <name>
+Remove a webhook`,
+				DesktopBody: removeExtended,
+				MobileBody:  removeExtended,
+			},
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("webhook", true)...)
+	return kbchat.Advertisement{
+		Alias: "Webhooks",
+		Advertisements: []chat1.AdvertiseCommandAPIParam{
+			{
+				Typ:      "public",
+				Commands: cmds,
+			},
+		},
+	}
+}
+
+func (s *BotServer) Go() (err error) {
+	if s.kbc, err = s.Start(s.opts.ErrReportConv); err != nil {
+		return err
+	}
+	sdb, closeDB, err := s.env.OpenDB(s.opts.DSN)
+	if err != nil {
+		s.Errorf("failed to connect to MySQL: %s", err)
+		return err
+	}
+	defer closeDB()
+	db := NewDB(sdb)
+
+	debugConfig := base.NewChatDebugOutputConfig(s.kbc, s.opts.ErrReportConv)
+	stats, err := base.NewStatsRegistry(debugConfig, s.opts.StathatEZKey)
+	if err != nil {
+		s.Debug("unable to create stats: %v", err)
+		return err
+	}
+	stats = stats.SetPrefix(s.Name())
+	cleaner := base.NewConvCleaner(stats, debugConfig, db.DB, s.Name())
+	debugConfig.Cleaner = cleaner
+	s.SetConvCleaner(cleaner)
+	httpSrv := NewHTTPSrv(stats, debugConfig, db)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
+	handler := NewHandler(stats, s.kbc, debugConfig, httpSrv, db, s.opts.HTTPPrefix)
+	eg := &errgroup.Group{}
+	s.GoWithRecover(eg, cleaner.Run)
+	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
+	s.GoWithRecover(eg, httpSrv.Listen)
+	s.GoWithRecover(eg, func() error { return s.HandleSignals(httpSrv, stats, cleaner) })
+	s.GoWithRecover(eg, func() error { return s.AnnounceAndAdvertise(s.makeAdvertisement(), "I live.") })
+	if err := eg.Wait(); err != nil {
+		s.Debug("wait error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	base.RegisterBot("webhookbot", NewBot)
+}
+
+// NewBot parses the command line in argv and returns the bot, env is nil
+// unless the bot is run by a base.Launcher.
+func NewBot(argv []string, env *base.BotEnv) (base.Bot, error) {
+	opts := NewOptions()
+	fs := flag.NewFlagSet(argv[0], flag.ContinueOnError)
+	fs.StringVar(&opts.HTTPPrefix, "http-prefix", os.Getenv("BOT_HTTP_PREFIX"),
+		"Desired prefix for generated webhooks")
+	if err := opts.Parse(fs, argv); err != nil {
+		return nil, fmt.Errorf("Unable to parse options: %v", err)
+	}
+	if len(opts.DSN) == 0 && !env.HasDB() {
+		return nil, errors.New("must specify a database DSN")
+	}
+	return NewBotServer(*opts, env), nil
+}
@@ -0,0 +1,37 @@
+package webhookbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
+	"github.com/keybase/managed-bots/base"
+)
+
+// setupSteps offers to create the conversation's first webhook.
+func (h *Handler) setupSteps() []base.WizardStep {
+	return []base.WizardStep{
+		{
+			Prompt: "Let's create your first webhook. What should it be called? Reply with a name, or `skip`.",
+			Commands: func(answers []string) ([]string, error) {
+				name := answers[len(answers)-1]
+				if strings.ContainsAny(name, " \t\n") {
+					return nil, base.WizardAnswerError("Webhook names can't have spaces in them.")
+				}
+				return []string{fmt.Sprintf("!webhook create %s", name)}, nil
+			},
+			Check: func(msg chat1.MsgSummary, answers []string) (bool, error) {
+				hooks, err := h.db.List(msg.ConvID)
+				if err != nil {
+					return false, err
+				}
+				for _, hook := range hooks {
+					if hook.Name == answers[len(answers)-1] {
+						return true, nil
+					}
+				}
+				return false, nil
+			},
+		},
+	}
+}
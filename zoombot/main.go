@@ -43,7 +43,7 @@ type BotServer struct {
 
 func NewBotServer(opts Options) *BotServer {
 	return &BotServer{
-		Server: base.NewServer("zoombot", opts.Announcement, opts.AWSOpts, opts.MultiDSN, opts.ReadSelf, kbchat.RunOptions{
+		Server: base.NewServer("zoombot", opts.Announcement, opts.AWSOpts, opts.TracingOpts, opts.MultiDSN, opts.ReadSelf, opts.RecordFile, kbchat.RunOptions{
 			KeybaseLocation: opts.KeybaseLocation,
 			HomeDir:         opts.Home,
 		}),
@@ -52,18 +52,20 @@ func NewBotServer(opts Options) *BotServer {
 }
 
 func (s *BotServer) makeAdvertisement() kbchat.Advertisement {
+	cmds := []chat1.UserBotCommandInput{
+		{
+			Name:        "zoom",
+			Description: "New Zoom meeting",
+		},
+		base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
+	}
+	cmds = append(cmds, base.OnboardingAdvertisements("zoom", false)...)
 	return kbchat.Advertisement{
 		Alias: "Zoom",
 		Advertisements: []chat1.AdvertiseCommandAPIParam{
 			{
-				Typ: "public",
-				Commands: []chat1.UserBotCommandInput{
-					{
-						Name:        "zoom",
-						Description: "New Zoom meeting",
-					},
-					base.GetFeedbackCommandAdvertisement(s.kbc.GetUsername()),
-				},
+				Typ:      "public",
+				Commands: cmds,
 			},
 		},
 	}
@@ -144,8 +146,10 @@ func (s *BotServer) Go() (err error) {
 		return err
 	}
 	stats = stats.SetPrefix(s.Name())
-	handler := zoombot.NewHandler(stats, s.kbc, debugConfig, db, config)
+	httpClient := base.NewHTTPClient(stats, debugConfig, base.DefaultHTTPClientOptions())
+	handler := zoombot.NewHandler(stats, s.kbc, debugConfig, db, config, httpClient)
 	httpSrv := zoombot.NewHTTPSrv(stats, s.kbc, debugConfig, db, handler, config, credentials)
+	base.NewDashboard(s.Server, stats, debugConfig, db.DB, s.opts.DashboardOpts)
 	eg := &errgroup.Group{}
 	s.GoWithRecover(eg, func() error { return s.Listen(handler) })
 	s.GoWithRecover(eg, httpSrv.Listen)
@@ -0,0 +1,10 @@
+package zoombot
+
+import "github.com/keybase/managed-bots/base"
+
+func init() {
+	base.RegisterAdminTables("zoombot", append(base.OAuthAdminTables(),
+		base.AdminTable{Name: "user", KeyColumns: []string{"user_id", "account_id", "identifier"}},
+	)...)
+	base.RegisterAdminTables("zoombot", base.OnboardingAdminTable("zoombot"))
+}
@@ -229,14 +229,13 @@ func CreateMeeting(client *http.Client, userID string, request *CreateMeetingReq
 	return &meeting, nil
 }
 
-func DataCompliance(clientID, clientSecret string, request *DataComplianceRequest) (*DataComplianceResponse, error) {
+func DataCompliance(client *http.Client, clientID, clientSecret string, request *DataComplianceRequest) (*DataComplianceResponse, error) {
 	apiURL := fmt.Sprintf("%s/oauth/data/compliance", apiBaseURL)
 	payload, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
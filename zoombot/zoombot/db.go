@@ -1,6 +1,7 @@
 package zoombot
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/keybase/managed-bots/base"
@@ -16,8 +17,8 @@ func NewDB(db *sql.DB) *DB {
 	}
 }
 
-func (d *DB) CreateUser(userID, accountID, identifier string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) CreateUser(ctx context.Context, userID, accountID, identifier string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			INSERT INTO user
 			(user_id, account_id, identifier)
@@ -29,8 +30,8 @@ func (d *DB) CreateUser(userID, accountID, identifier string) error {
 	})
 }
 
-func (d *DB) DeleteUserAndToken(userID, accountID string) error {
-	return d.RunTxn(func(tx *sql.Tx) error {
+func (d *DB) DeleteUserAndToken(ctx context.Context, userID, accountID string) error {
+	return d.RunTxnContext(ctx, func(tx *sql.Tx) error {
 		_, err := tx.Exec(`
 			DELETE user, oauth
 			FROM user
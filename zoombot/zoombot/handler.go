@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/keybase/go-keybase-chat-bot/kbchat"
 	"github.com/keybase/go-keybase-chat-bot/kbchat/types/chat1"
@@ -16,72 +15,78 @@ import (
 type Handler struct {
 	*base.DebugOutput
 
-	stats  *base.StatsRegistry
-	kbc    *kbchat.API
-	db     *DB
-	config *oauth2.Config
+	stats      *base.StatsRegistry
+	kbc        *kbchat.API
+	db         *DB
+	onboarding *base.Onboarding
+	config     *oauth2.Config
+	httpClient *base.HTTPClient
 }
 
 var _ base.Handler = (*Handler)(nil)
 
 func NewHandler(stats *base.StatsRegistry, kbc *kbchat.API, debugConfig *base.ChatDebugOutputConfig,
-	db *DB, config *oauth2.Config) *Handler {
+	db *DB, config *oauth2.Config, httpClient *base.HTTPClient) *Handler {
 	return &Handler{
 		DebugOutput: base.NewDebugOutput("Handler", debugConfig),
 		stats:       stats.SetPrefix("Handler"),
 		kbc:         kbc,
 		db:          db,
 		config:      config,
+		httpClient:  httpClient,
+		onboarding:  base.NewOnboarding(stats, kbc, debugConfig, db.DB, "zoombot", "zoom", "Hello! I can get you set up with a Zoom instant meeting anytime, just send me `!zoom`."),
 	}
 }
 
 func (h *Handler) HandleNewConv(conv chat1.ConvSummary) error {
-	welcomeMsg := "Hello! I can get you set up with a Zoom instant meeting anytime, just send me `!zoom`."
-	return base.HandleNewTeam(h.stats, h.DebugOutput, h.kbc, conv, welcomeMsg)
+	return h.onboarding.HandleNewConv(conv)
 }
 
-func (h *Handler) HandleAuth(msg chat1.MsgSummary, identifier string) error {
+func (h *Handler) HandleAuth(ctx context.Context, msg chat1.MsgSummary, identifier string) error {
 	token, err := h.db.GetToken(identifier)
 	if err != nil {
 		return fmt.Errorf("error getting token: %s", err)
 	}
-	client := h.config.Client(context.Background(), token)
+	clientCtx := context.WithValue(ctx, oauth2.HTTPClient, h.httpClient.Client())
+	client := h.config.Client(clientCtx, token)
 
 	user, err := GetUser(client, currentUserID)
 	if err != nil {
 		return err
 	}
 
-	err = h.db.CreateUser(user.ID, user.AccountID, identifier)
+	err = h.db.CreateUser(ctx, user.ID, user.AccountID, identifier)
 	if err != nil {
 		return fmt.Errorf("error creating user entry: %s", err)
 	}
-	return h.HandleCommand(msg)
+	return h.HandleCommand(ctx, msg)
 }
 
-func (h *Handler) HandleCommand(msg chat1.MsgSummary) error {
+func (h *Handler) HandleCommand(ctx context.Context, msg chat1.MsgSummary) error {
 	if msg.Content.Text == nil {
 		return nil
 	}
+	if handled, err := h.onboarding.HandleCommand(ctx, msg); handled {
+		return err
+	}
 
 	cmd := strings.TrimSpace(msg.Content.Text.Body)
 	if strings.HasPrefix(cmd, "!zoom") {
 		h.stats.Count("zoom")
-		return h.zoomHandler(msg, 0)
+		return h.zoomHandler(ctx, msg)
 	}
 	return nil
 }
 
-func (h *Handler) zoomHandler(msg chat1.MsgSummary, attempts int) error {
+func (h *Handler) zoomHandler(ctx context.Context, msg chat1.MsgSummary) error {
 	retry := func() error {
 		// retry auth after nuking stored credentials
-		if err := h.db.DeleteToken(IdentifierFromMsg(msg)); err != nil {
+		if err := h.db.DeleteToken(ctx, IdentifierFromMsg(msg)); err != nil {
 			return err
 		}
-		attempts++
-		return h.zoomHandlerInner(msg, attempts)
+		return h.zoomHandlerInner(ctx, msg)
 	}
-	err := h.zoomHandlerInner(msg, attempts)
+	err := h.zoomHandlerInner(ctx, msg)
 	switch err := err.(type) {
 	case nil, base.OAuthRequiredError:
 		return nil
@@ -99,12 +104,13 @@ func (h *Handler) zoomHandler(msg chat1.MsgSummary, attempts int) error {
 	}
 }
 
-func (h *Handler) zoomHandlerInner(msg chat1.MsgSummary, attempts int) error {
+func (h *Handler) zoomHandlerInner(ctx context.Context, msg chat1.MsgSummary) error {
 	identifier := IdentifierFromMsg(msg)
-	client, err := base.GetOAuthClient(identifier, msg, h.kbc, h.config, h.db,
+	client, err := base.GetOAuthClient(ctx, identifier, msg, h.kbc, h.config, h.db,
 		base.GetOAuthOpts{
 			AuthMessageTemplate:    "Authorize me by clicking this link:\n%s",
 			OAuthOfflineAccessType: true,
+			HTTPClient:             h.httpClient.Client(),
 		})
 	if err != nil || client == nil {
 		return err
@@ -117,22 +123,9 @@ func (h *Handler) zoomHandlerInner(msg chat1.MsgSummary, attempts int) error {
 	case nil:
 		h.ChatEcho(msg.ConvID, "%s", meeting.JoinURL)
 	case ZoomAPIError:
+		// rate limited requests have already been retried by the HTTP client
 		if err.Code == http.StatusTooManyRequests {
-			if attempts > 5 {
-				h.ChatEcho(msg.ConvID, "%s", err.Error())
-				return nil
-			}
-			go func() {
-				attempts++
-				h.Debug("zoomHandlerInner: retrying attempt #%d: %v", attempts, err)
-				time.Sleep(500 * time.Millisecond)
-				err := h.zoomHandler(msg, attempts)
-				switch err := err.(type) {
-				case nil, base.OAuthRequiredError:
-				default:
-					h.ChatErrorf(msg.ConvID, "zoomHandler unable to create meeting: attempt #%d %v", attempts, err)
-				}
-			}()
+			h.ChatEcho(msg.ConvID, "%s", err.Error())
 			return nil
 		}
 	}
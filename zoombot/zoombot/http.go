@@ -60,14 +60,14 @@ func (h *HTTPSrv) zoomDeauthorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.DeleteUserAndToken(deauthorizationRequest.Payload.UserID, deauthorizationRequest.Payload.AccountID)
+	err = h.db.DeleteUserAndToken(r.Context(), deauthorizationRequest.Payload.UserID, deauthorizationRequest.Payload.AccountID)
 	if err != nil {
 		h.Errorf("zoomDeauthorize: unable to delete user: %s", err)
 		http.Error(w, "unable to delete user", http.StatusBadRequest)
 		return
 	}
 
-	_, err = DataCompliance(h.credentials.ClientID, h.credentials.ClientSecret, &DataComplianceRequest{
+	_, err = DataCompliance(h.handler.httpClient.Client(), h.credentials.ClientID, h.credentials.ClientSecret, &DataComplianceRequest{
 		ClientID:                     deauthorizationRequest.Payload.ClientID,
 		UserID:                       deauthorizationRequest.Payload.UserID,
 		AccountID:                    deauthorizationRequest.Payload.AccountID,